@@ -8,8 +8,11 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/logging"
+	"github.com/belamov/ypgo-url-shortener/internal/app/metrics"
 	pb "github.com/belamov/ypgo-url-shortener/internal/app/proto"
 	"github.com/belamov/ypgo-url-shortener/internal/app/server"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services"
@@ -27,7 +30,6 @@ var (
 	buildCommit  = "N/A" //nolint:gochecknoglobals
 )
 
-// В таждую функцию file_repository добавить тестувую печать
 // Вот это в отладочном json
 // "program": "${workspaceFolder}/cmd/shortener/main.go"
 // Позволяет отлаживать из любого каталога!
@@ -38,28 +40,47 @@ func main() {
 	fmt.Printf("Build version: %s\n", buildVersion)
 	fmt.Printf("Build date: %s\n", buildDate)
 	fmt.Printf("Build commit: %s\n", buildCommit)
+	metrics.SetBuildInfo(buildVersion, buildDate, buildCommit)
 
 	// Configuration🧹🏦
 	cfg, err := config.New()
 	if err != nil {
-		log.Fatal().Err(err)
+		log.Fatal().Err(err).Msg("can't read config")
+	}
+
+	// Logger🧹🏦
+	// дальше все пишут в log.Logger, обработчики запросов - в логгер из контекста с request_id
+	logger, err := logging.New(cfg.LogLevel, cfg.LogFormat, os.Stderr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("can't create logger")
 	}
+	log.Logger = logger.With().Caller().Logger()
 
 	// Repository🧹🏦
 	repo := storage.GetRepo(cfg)
+	// задержки и ошибки хранилища попадают в /metrics
+	instrumentedRepo := storage.NewInstrumentedRepository(repo, storage.Backend(cfg))
 
 	// Use case🧹🏦
-	gen := &generator.HashGenerator{}
-
 	randomGenerator := &random.TrulyRandomGenerator{}
 
+	// счетчик последовательных id тоже идет через обертку с метриками, если хранилище его поддерживает
+	var counter generator.Counter
+	if _, ok := storage.Unwrap(instrumentedRepo).(storage.Sequencer); ok {
+		counter = instrumentedRepo
+	}
+	gen, err := generator.NewFromConfig(cfg, counter, randomGenerator)
+	if err != nil {
+		log.Fatal().Err(err).Msg("can't create id generator")
+	}
+
 	// ❗TODO: список главных структур services.Shortener (видимо аналог App в zha-go-clean-architecture)
 	// реализует методы интерфейса services.ShortenerInterface
 	// другие ключевые структуры- handlers.Handler - models.ShortURL
 	//
 	// Здесь начало цепочки, следующий шаг- restServer
 	// service имеет тип services.Shortener struct — основной сервис приложения
-	service := services.New(repo, gen, randomGenerator, cfg)
+	service := services.New(instrumentedRepo, gen, randomGenerator, cfg)
 	// ЦЕПОЧКА ОБРАБОТЧИКОВ
 	//
 	// services.New (internal\app\server\server.go) -->
@@ -101,15 +122,58 @@ func main() {
 	// Waiting signal🧹🏦
 	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
+	// удаление ссылок идет в пуле воркеров, очередь останавливается только после остановки серверов
+	// отмена deletionsCtx прерывает повторы и сбрасывает оставшиеся в очереди запросы
+	deletionsCtx, abortDeletions := context.WithCancel(context.Background())
+	defer abortDeletions()
+	deletionsDone := make(chan struct{})
+	go func() {
+		defer close(deletionsDone)
+		service.ProcessDeletions(deletionsCtx)
+	}()
+
+	// переходы сохраняются, пока серверы не остановлены: оставшиеся в буфере сохраняются до закрытия хранилища
+	clicksCtx, stopClicks := context.WithCancel(context.Background())
+	defer stopClicks()
+	clicksDone := make(chan struct{})
+	go func() {
+		defer close(clicksDone)
+		service.SaveClicks(clicksCtx)
+	}()
+
 	wg := &sync.WaitGroup{}
-	// две горутины
-	wg.Add(2) //nolint:gomnd
+	// четыре горутины: два сервера, удаление просроченных ссылок и очистка удаленных ссылок
+	wg.Add(4) //nolint:gomnd
 
-	// Старт в двух горутинах
+	// Старт в четырех горутинах
 	go runServer(ctx, wg, restServer, "REST HTTP server")
 	go runServer(ctx, wg, grpcServer, "GRPC server")
+	go func() {
+		defer wg.Done()
+		service.SweepExpired(ctx, time.Duration(cfg.ExpirySweepInterval)*time.Second)
+	}()
+	go func() {
+		defer wg.Done()
+		service.PurgeDeleted(ctx, time.Duration(cfg.PurgeInterval)*time.Second)
+	}()
 	wg.Wait()
 
+	// серверы остановлены, новых переходов нет: сохраняем оставшиеся в буфере
+	log.Info().Msg("saving buffered clicks")
+	stopClicks()
+	<-clicksDone
+
+	// серверы остановлены, новых запросов на удаление нет: дожидаемся удаления уже принятых
+	log.Info().Msg("waiting for queued deletions")
+	service.StopDeletions()
+	select {
+	case <-deletionsDone:
+	case <-time.After(time.Duration(cfg.DeleteDrainTimeout) * time.Second):
+		log.Warn().Msg("queued deletions aren't finished in time, the rest of them are failed")
+		abortDeletions()
+		<-deletionsDone
+	}
+
 	// Close storage
 	log.Info().Msg("trying to shutdown storage gracefully")
 
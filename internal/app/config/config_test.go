@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,7 +15,76 @@ func TestNew(t *testing.T) {
 		assert.Equal(t, ":8080", c.ServerAddress)
 		assert.Equal(t, "http://localhost:8080", c.BaseURL)
 		assert.Equal(t, "127.0.0.1/24", c.TrustedSubnet)
+		assert.Equal(t, "interval", c.FileSyncMode)
+		assert.Equal(t, 1000, c.FileSyncInterval)
+		assert.Equal(t, 10, c.DatabaseMaxConns)
+		assert.Equal(t, 0, c.DatabaseMinConns)
+		assert.Equal(t, 3600, c.DatabaseMaxConnLifetime)
+		assert.Equal(t, 3000, c.DatabaseAcquireTimeout)
+		assert.Equal(t, "hash", c.IDGenerator)
+		assert.Equal(t, 8, c.IDLength)
+		assert.Empty(t, c.IDAlphabet)
+		assert.Equal(t, UniquenessGlobal, c.URLUniqueness)
+		assert.Equal(t, 60, c.ExpirySweepInterval)
+		assert.Equal(t, 1024, c.ClickBufferSize)
+		assert.Equal(t, 1024, c.DeleteQueueSize)
+		assert.Equal(t, 4, c.DeleteWorkers)
+		assert.Equal(t, 7*24*3600, c.RestoreGracePeriod)
+		assert.Equal(t, 30*24*3600, c.DeletedRetention)
+		assert.Equal(t, 3600, c.PurgeInterval)
+		assert.Equal(t, "info", c.LogLevel)
+		assert.Equal(t, LogFormatJSON, c.LogFormat)
 		assert.Len(t, c.EncryptionKey, 32)
 		assert.NotEmpty(t, c.EncryptionKey)
 	})
 }
+
+// validConfig returns config that passes validation.
+func validConfig() *Config {
+	return &Config{
+		DatabaseMaxConns:    10,
+		ExpirySweepInterval: 60,
+		RestoreGracePeriod:  3600,
+		DeletedRetention:    7200,
+		PurgeInterval:       60,
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		valid  bool
+	}{
+		{name: "valid", modify: func(c *Config) {}, valid: true},
+		{name: "min conns equal to max conns", modify: func(c *Config) { c.DatabaseMinConns = 10 }, valid: true},
+		{name: "min conns greater than max conns", modify: func(c *Config) { c.DatabaseMinConns = 11 }},
+		{name: "zero expiry sweep interval", modify: func(c *Config) { c.ExpirySweepInterval = 0 }},
+		{name: "negative expiry sweep interval", modify: func(c *Config) { c.ExpirySweepInterval = -1 }},
+		{name: "zero click buffer size", modify: func(c *Config) { c.ClickBufferSize = 0 }, valid: true},
+		{name: "negative click buffer size", modify: func(c *Config) { c.ClickBufferSize = -1 }},
+		{name: "zero restore grace period", modify: func(c *Config) { c.RestoreGracePeriod = 0 }, valid: true},
+		{name: "negative restore grace period", modify: func(c *Config) { c.RestoreGracePeriod = -1 }},
+		{name: "retention equal to grace period", modify: func(c *Config) { c.DeletedRetention = 3600 }, valid: true},
+		{name: "retention shorter than grace period", modify: func(c *Config) { c.DeletedRetention = 3599 }},
+		{name: "negative retention", modify: func(c *Config) { c.RestoreGracePeriod, c.DeletedRetention = 0, -1 }},
+		{name: "zero purge interval", modify: func(c *Config) { c.PurgeInterval = 0 }},
+		{name: "negative purge interval", modify: func(c *Config) { c.PurgeInterval = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+			if tt.valid {
+				assert.NoError(t, c.validate())
+			} else {
+				assert.Error(t, c.validate())
+			}
+		})
+	}
+}
+
+func TestConfig_DatabaseAcquireTimeoutDuration(t *testing.T) {
+	assert.Equal(t, 3*time.Second, (&Config{DatabaseAcquireTimeout: 3000}).DatabaseAcquireTimeoutDuration())
+	assert.Zero(t, (&Config{DatabaseAcquireTimeout: -1}).DatabaseAcquireTimeoutDuration(), "negative timeout means no limit")
+}
@@ -1,11 +1,20 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/go-chi/chi/v5"
 )
 
+// deleteRetryAfter is delay in seconds suggested to client when delete queue is full.
+const deleteRetryAfter = 1
+
+// DeleteUrls queues deletion of urls of user and responds with pending job,
+// its status is served by DeleteJob at url from Location header.
 func (h *Handler) DeleteUrls(w http.ResponseWriter, r *http.Request) {
 	var ids []string
 
@@ -22,7 +31,53 @@ func (h *Handler) DeleteUrls(w http.ResponseWriter, r *http.Request) {
 
 	userID := h.getUserID(r)
 
-	go h.service.DeleteUrls(context.Background(), ids, userID) //nolint:contextcheck
+	job, err := h.service.DeleteUrls(r.Context(), ids, userID)
+	if errors.Is(err, services.ErrDeleteQueueFull) || errors.Is(err, services.ErrDeletionsStopped) {
+		w.Header().Set("Retry-After", strconv.Itoa(deleteRetryAfter))
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := json.Marshal(job)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/user/deletions/"+job.ID)
 	w.WriteHeader(http.StatusAccepted)
+	if _, err = w.Write(out); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeleteJob returns status of delete job of user and, when job is done, results by id of url.
+func (h *Handler) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "job") //nolint:contextcheck
+
+	job, err := h.service.GetDeleteJob(r.Context(), jobID, h.getUserID(r))
+	if errors.Is(err, services.ErrDeleteJobNotFound) {
+		http.Error(w, "cant find delete job", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := json.Marshal(job)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(out); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
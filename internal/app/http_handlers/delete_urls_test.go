@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"crypto/aes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +14,7 @@ import (
 	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHandler_DeleteUrls(t *testing.T) {
@@ -72,3 +75,92 @@ func TestHandler_DeleteUrls(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_DeleteUrlsWithFullQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRandom := mocks.NewMockGenerator(ctrl)
+	mockRandom.EXPECT().GenerateNewUserID().Return("new user id").AnyTimes()
+	mockRandom.EXPECT().GenerateRandomBytes(12).Return(make([]byte, 12), nil).AnyTimes()
+
+	cfg := &config.Config{
+		BaseURL:         "http://localhost:8080",
+		ServerAddress:   ":8080",
+		EncryptionKey:   make([]byte, 2*aes.BlockSize),
+		DeleteQueueSize: 1,
+	}
+
+	// nothing processes the queue, so the second request doesn't fit in it
+	service := services.New(mocks.NewMockRepository(ctrl), mocks.NewMockURLGenerator(ctrl), mockRandom, cfg)
+	r := NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	result, _ := testRequest(t, ts, http.MethodDelete, "/api/user/urls", "[\"id1\"]", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+
+	result, _ = testRequest(t, ts, http.MethodDelete, "/api/user/urls", "[\"id2\"]", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+	assert.Equal(t, "1", result.Header.Get("Retry-After"))
+
+	service.StopDeletions()
+	result, _ = testRequest(t, ts, http.MethodDelete, "/api/user/urls", "[\"id3\"]", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+}
+
+func TestHandler_DeleteJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().DeleteUrls(gomock.Any(), []models.ShortURL{{ID: "id1", CreatedByID: "new user id"}, {ID: "id2", CreatedByID: "new user id"}}).
+		Return([]models.DeleteResult{models.DeleteResultDeleted, models.DeleteResultNotFound}, nil)
+
+	mockRandom := mocks.NewMockGenerator(ctrl)
+	mockRandom.EXPECT().GenerateNewUserID().Return("new user id").AnyTimes()
+	mockRandom.EXPECT().GenerateRandomBytes(12).Return(make([]byte, 12), nil).AnyTimes()
+
+	cfg := &config.Config{
+		BaseURL:       "http://localhost:8080",
+		ServerAddress: ":8080",
+		EncryptionKey: make([]byte, 2*aes.BlockSize),
+	}
+
+	service := services.New(mockRepo, mocks.NewMockURLGenerator(ctrl), mockRandom, cfg)
+	r := NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	result, body := testRequest(t, ts, http.MethodDelete, "/api/user/urls", "[\"id1\", \"id2\"]", nil)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusAccepted, result.StatusCode)
+
+	var job models.DeleteJob
+	require.NoError(t, json.Unmarshal([]byte(body), &job))
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, models.DeleteJobPending, job.Status)
+	assert.Equal(t, "/api/user/deletions/"+job.ID, result.Header.Get("Location"))
+
+	result, body = testRequest(t, ts, http.MethodGet, "/api/user/deletions/"+job.ID, "", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.JSONEq(t, `{"id":"`+job.ID+`","status":"pending"}`, body)
+
+	service.StopDeletions()
+	service.ProcessDeletions(context.Background())
+
+	result, body = testRequest(t, ts, http.MethodGet, "/api/user/deletions/"+job.ID, "", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.JSONEq(t, `{"id":"`+job.ID+`","status":"done","results":{"id1":"deleted","id2":"not_found"}}`, body)
+
+	result, _ = testRequest(t, ts, http.MethodGet, "/api/user/deletions/missing", "", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+}
@@ -1,31 +1,131 @@
 package handlers
 
 import (
+	"errors"
+	"html/template"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 	"github.com/go-chi/chi/v5"
 )
 
+// passwordForm is page that asks password of protected url.
+// Form has no action, so it's posted to the url of the page and works when service is served under path prefix.
+var passwordForm = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Password required</title></head>
+<body>
+<form method="post">
+	<p>This link is protected by password.</p>
+	{{if .Error}}<p>{{.Error}}</p>{{end}}
+	<input type="password" name="password" autofocus required>
+	<button type="submit">Open</button>
+</form>
+</body>
+</html>
+`))
+
 func (h *Handler) Expand(w http.ResponseWriter, r *http.Request) {
 	uID := chi.URLParam(r, "id") //nolint:contextcheck
 
 	shortURL, err := h.service.Expand(r.Context(), uID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if errors.Is(err, services.ErrPasswordRequired) {
+		writePasswordForm(w, "", http.StatusOK)
+		return
+	}
+	if writeExpandError(w, err) {
 		return
 	}
 
-	if shortURL.OriginalURL == "" {
-		http.Error(w, "cant find full url", http.StatusNotFound)
+	h.recordClick(r, uID)
+
+	w.Header().Set("Content-Type", "text/html")
+
+	http.Redirect(w, r, shortURL.OriginalURL, http.StatusTemporaryRedirect)
+}
+
+// ExpandWithPassword redirects to url protected by password, the password is sent by the form of Expand.
+func (h *Handler) ExpandWithPassword(w http.ResponseWriter, r *http.Request) {
+	uID := chi.URLParam(r, "id") //nolint:contextcheck
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "cannot parse form", http.StatusBadRequest)
 		return
 	}
 
-	if !shortURL.DeletedAt.IsZero() {
-		http.Error(w, "url is deleted", http.StatusGone)
+	shortURL, err := h.service.ExpandWithPassword(r.Context(), uID, r.PostForm.Get("password"), clientIP(r))
+	if errors.Is(err, services.ErrWrongPassword) {
+		writePasswordForm(w, "Wrong password.", http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, services.ErrTooManyAttempts) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(services.PasswordAttemptsWindow.Seconds())))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if writeExpandError(w, err) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/html")
+	h.recordClick(r, uID)
 
-	http.Redirect(w, r, shortURL.OriginalURL, http.StatusTemporaryRedirect)
+	// 303 makes browser follow the redirect with GET instead of repeating POST
+	http.Redirect(w, r, shortURL.OriginalURL, http.StatusSeeOther)
+}
+
+// recordClick records redirect by url with id for click statistics.
+func (h *Handler) recordClick(r *http.Request, id string) {
+	h.service.RecordClick(models.ClickEvent{
+		Time:      time.Now(),
+		URLID:     id,
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+		IPPrefix:  services.IPPrefix(clientIP(r)),
+	})
+}
+
+// writeExpandError writes response for errors of expanding and reports whether there was an error.
+func writeExpandError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "cant find full url", http.StatusNotFound)
+	case errors.Is(err, storage.ErrDeleted):
+		http.Error(w, "url is deleted", http.StatusGone)
+	case errors.Is(err, storage.ErrExpired):
+		http.Error(w, "url is expired", http.StatusGone)
+	case errors.Is(err, storage.ErrClickLimitReached):
+		http.Error(w, "url click limit is reached", http.StatusGone)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return true
+}
+
+// writePasswordForm writes page with password form of protected url.
+func writePasswordForm(w http.ResponseWriter, errorMessage string, status int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	data := struct {
+		Error string
+	}{Error: errorMessage}
+	if err := passwordForm.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// clientIP returns ip address of client that sent request.
+// Headers aren't trusted, so clients can't evade limits by setting them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"crypto/aes"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -12,8 +14,10 @@ import (
 	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHandler_Expand(t *testing.T) {
@@ -88,6 +92,26 @@ func TestHandler_Expand(t *testing.T) {
 			request: "/deleted",
 			method:  http.MethodGet,
 		},
+		{
+			name: "it returns 410 when trying to expand expired url",
+			want: want{
+				statusCode: http.StatusGone,
+				location:   "",
+				body:       "url is expired",
+			},
+			request: "/expired",
+			method:  http.MethodGet,
+		},
+		{
+			name: "it returns 410 when clicks of url are used up",
+			want: want{
+				statusCode: http.StatusGone,
+				location:   "",
+				body:       "url click limit is reached",
+			},
+			request: "/clicked",
+			method:  http.MethodGet,
+		},
 	}
 
 	for _, tt := range tests {
@@ -97,14 +121,23 @@ func TestHandler_Expand(t *testing.T) {
 
 			mockRepo := mocks.NewMockRepository(ctrl)
 			mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(models.ShortURL{OriginalURL: "url"}, nil).AnyTimes()
-			mockRepo.EXPECT().GetByID(gomock.Any(), "missing").Return(models.ShortURL{}, nil).AnyTimes()
+			mockRepo.EXPECT().GetByID(gomock.Any(), "missing").Return(models.ShortURL{}, storage.ErrNotFound).AnyTimes()
 			mockRepo.EXPECT().GetByID(gomock.Any(), "error").Return(models.ShortURL{}, errors.New("error text")).AnyTimes()
 			mockRepo.EXPECT().GetByID(gomock.Any(), "deleted").Return(models.ShortURL{
 				OriginalURL: "url",
 				ID:          "deleted",
 				CreatedByID: "user id",
 				DeletedAt:   time.Now(),
-			}, nil).AnyTimes()
+			}, storage.ErrDeleted).AnyTimes()
+			mockRepo.EXPECT().GetByID(gomock.Any(), "expired").Return(models.ShortURL{
+				OriginalURL: "url",
+				ID:          "expired",
+				CreatedByID: "user id",
+				ExpiresAt:   time.Now().Add(-time.Hour),
+			}, storage.ErrExpired).AnyTimes()
+			clicked := models.ShortURL{OriginalURL: "url", ID: "clicked", MaxClicks: 1, Clicks: 1}
+			mockRepo.EXPECT().GetByID(gomock.Any(), "clicked").Return(clicked, nil).AnyTimes()
+			mockRepo.EXPECT().Click(gomock.Any(), "clicked").Return(clicked, storage.ErrClickLimitReached).AnyTimes()
 			mockGen := mocks.NewMockURLGenerator(ctrl)
 
 			mockRandom := mocks.NewMockGenerator(ctrl)
@@ -132,3 +165,106 @@ func TestHandler_Expand(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_ExpandRecordsClick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var saved []models.ClickEvent
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(models.ShortURL{OriginalURL: "/url", ID: "id"}, nil).AnyTimes()
+	mockRepo.EXPECT().GetByID(gomock.Any(), "missing").Return(models.ShortURL{}, storage.ErrNotFound).AnyTimes()
+	mockRepo.EXPECT().SaveClickEvents(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, events []models.ClickEvent) error {
+		saved = append(saved, events...)
+		return nil
+	})
+
+	cfg := &config.Config{
+		BaseURL:         "http://localhost:8080",
+		ServerAddress:   ":8080",
+		EncryptionKey:   make([]byte, 2*aes.BlockSize),
+		ClickBufferSize: 10,
+	}
+
+	service := services.New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), cfg)
+	r := NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/id", nil)
+	require.NoError(t, err)
+	req.Header.Set("Referer", "https://example.com/page")
+	req.Header.Set("User-Agent", "test agent")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	result, _ := testRequest(t, ts, http.MethodGet, "/missing", "", nil)
+	defer result.Body.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.SaveClicks(ctx)
+
+	require.Len(t, saved, 1, "only redirects must be recorded")
+	assert.Equal(t, "id", saved[0].URLID)
+	assert.Equal(t, "https://example.com/page", saved[0].Referrer)
+	assert.Equal(t, "test agent", saved[0].UserAgent)
+	assert.Equal(t, "127.0.0.0/24", saved[0].IPPrefix)
+	assert.WithinDuration(t, time.Now(), saved[0].Time, time.Second)
+}
+
+func TestHandler_ExpandWithPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, err := services.HashPassword("secret")
+	require.NoError(t, err)
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetByID(gomock.Any(), "protected").
+		Return(models.ShortURL{OriginalURL: "/url", ID: "protected", PasswordHash: hash}, nil).AnyTimes()
+
+	cfg := &config.Config{
+		BaseURL:       "http://localhost:8080",
+		ServerAddress: ":8080",
+		EncryptionKey: make([]byte, 2*aes.BlockSize),
+	}
+
+	service := services.New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), cfg)
+	r := NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	result, body := testRequest(t, ts, http.MethodGet, "/protected", "", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Empty(t, result.Header.Get("Location"), "protected url must not be redirected without password")
+	assert.Contains(t, body, `<form method="post">`, "form must be posted to the url of the page")
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	postPassword := func(password string) *http.Response {
+		resp, errPost := client.PostForm(ts.URL+"/protected", url.Values{"password": {password}})
+		require.NoError(t, errPost)
+		require.NoError(t, resp.Body.Close())
+		return resp
+	}
+
+	resp := postPassword("secret")
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/url", resp.Header.Get("Location"))
+
+	for i := 0; i < services.MaxPasswordAttempts; i++ {
+		resp = postPassword("wrong")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp = postPassword("secret")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "correct password must not be checked after too many failed attempts")
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
@@ -5,14 +5,18 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"encoding/hex"
+	"encoding/json"
 	"io"
 	"net/http"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/logging"
+	"github.com/belamov/ypgo-url-shortener/internal/app/metrics"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services/crypto"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
 )
 
 // Кука для iter14
@@ -47,13 +51,16 @@ func NewHandler(service *services.Shortener, config *config.Config) *Handler {
 func NewRouter(service *services.Shortener, ipChecker services.IPCheckerInterface, config *config.Config) chi.Router {
 	r := chi.NewRouter()
 
+	r.Use(metrics.HTTPMiddleware)
 	r.Use(middleware.RequestID)
+	r.Use(logging.HTTPMiddleware(log.Logger))
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Compress(flate.BestSpeed))
 
 	h := NewHandler(service, config)
 
 	r.Get("/{id}", h.Expand)
+	r.Post("/{id}", h.ExpandWithPassword)
 	r.Post("/", h.Shorten)
 	r.Post("/api/shorten", h.ShortenAPI)
 	//
@@ -75,6 +82,7 @@ func NewRouter(service *services.Shortener, ipChecker services.IPCheckerInterfac
 	//     ...
 	// ]
 	r.Get("/api/user/urls", h.UserURLs)
+	r.Get("/api/user/urls/{id}/stats", h.URLStats)
 	//
 	// здешний (и новый в 42-й к.) iter10
 	// Добавьте в сервис хендлер GET /ping,
@@ -87,10 +95,13 @@ func NewRouter(service *services.Shortener, ipChecker services.IPCheckerInterfac
 	// Далее добавьте в сервис новый асинхронный хендлер DELETE /api/user/urls,
 	// который принимает список идентификаторов сокращённых URL для удаления в формате:
 	r.Delete("/api/user/urls", h.DeleteUrls)
+	r.Get("/api/user/deletions/{job}", h.DeleteJob)
+	r.Post("/api/user/urls/restore", h.RestoreUrls)
 	//
 	r.Group(func(r chi.Router) {
 		r.Use(FromTrustedSubnet(ipChecker))
 		r.Get("/api/internal/stats", h.Stats)
+		r.Method(http.MethodGet, "/metrics", metrics.Handler())
 	})
 
 	return r
@@ -118,9 +129,21 @@ func (h *Handler) getUserID(r *http.Request) string {
 
 // Ping правильнее вынести в отдельный файл (01.01.2026)
 func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
-	err := h.service.HealthCheck(r.Context())
+	health, err := h.service.HealthCheck(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := json.Marshal(health)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(out); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"crypto/aes"
 	"encoding/hex"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -189,3 +190,46 @@ func TestHandler_getUserID(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Ping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockRandom := mocks.NewMockGenerator(ctrl)
+	mockChecker := mocks.NewMockIPCheckerInterface(ctrl)
+	cfg := &config.Config{
+		BaseURL:       "http://localhost:8080",
+		ServerAddress: ":8080",
+		EncryptionKey: make([]byte, 2*aes.BlockSize),
+	}
+
+	t.Run("storage is available", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Check(gomock.Any()).Return(nil)
+
+		service := services.New(mockRepo, mockGen, mockRandom, cfg)
+		ts := httptest.NewServer(NewRouter(service, mockChecker, cfg))
+		defer ts.Close()
+
+		result, body := testRequest(t, ts, http.MethodGet, "/ping", "", nil)
+		defer result.Body.Close()
+
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+		assert.JSONEq(t, `{"status":"ok"}`, body)
+	})
+
+	t.Run("storage is unavailable", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Check(gomock.Any()).Return(errors.New("connection refused"))
+
+		service := services.New(mockRepo, mockGen, mockRandom, cfg)
+		ts := httptest.NewServer(NewRouter(service, mockChecker, cfg))
+		defer ts.Close()
+
+		result, _ := testRequest(t, ts, http.MethodGet, "/ping", "", nil)
+		defer result.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, result.StatusCode)
+	})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RestoreUrls restores deleted urls of user and responds with ids of restored urls.
+// Urls deleted before restore grace period can't be restored, they are skipped.
+func (h *Handler) RestoreUrls(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+
+	reader, err := getDecompressedReader(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if errDecode := json.NewDecoder(reader).Decode(&ids); errDecode != nil {
+		http.Error(w, "cannot decode json", http.StatusBadRequest)
+		return
+	}
+
+	restored, err := h.service.RestoreUrls(r.Context(), ids, h.getUserID(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := json.Marshal(restored)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(out); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
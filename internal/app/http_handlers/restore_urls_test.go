@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"crypto/aes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_RestoreUrls(t *testing.T) {
+	tests := []struct {
+		repoErr          error
+		name             string
+		ids              string
+		wantBody         string
+		wantResponseCode int
+	}{
+		{
+			name:             "it restores urls",
+			ids:              "[\"id1\", \"id2\"]",
+			wantResponseCode: http.StatusOK,
+			wantBody:         "[\"id2\"]",
+		},
+		{
+			name:             "it responses with error when request is not valid",
+			ids:              "id1, id2",
+			wantResponseCode: http.StatusBadRequest,
+		},
+		{
+			name:             "it responses with error when urls can't be restored",
+			ids:              "[\"id1\", \"id2\"]",
+			repoErr:          errors.New("unexpected error"),
+			wantResponseCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var restored []string
+			if tt.repoErr == nil {
+				restored = []string{"id2"}
+			}
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().
+				RestoreUrls(gomock.Any(), []models.ShortURL{{ID: "id1", CreatedByID: "new user id"}, {ID: "id2", CreatedByID: "new user id"}}, gomock.Any()).
+				Return(restored, tt.repoErr).
+				AnyTimes()
+
+			mockRandom := mocks.NewMockGenerator(ctrl)
+			mockRandom.EXPECT().GenerateNewUserID().Return("new user id").AnyTimes()
+			mockRandom.EXPECT().GenerateRandomBytes(12).Return(make([]byte, 12), nil).AnyTimes()
+
+			cfg := &config.Config{
+				BaseURL:            "http://localhost:8080",
+				ServerAddress:      ":8080",
+				EncryptionKey:      make([]byte, 2*aes.BlockSize),
+				RestoreGracePeriod: 3600,
+			}
+
+			service := services.New(mockRepo, mocks.NewMockURLGenerator(ctrl), mockRandom, cfg)
+			r := NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg)
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			result, body := testRequest(t, ts, http.MethodPost, "/api/user/urls/restore", tt.ids, nil)
+			defer result.Body.Close()
+
+			assert.Equal(t, tt.wantResponseCode, result.StatusCode)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, body)
+			}
+		})
+	}
+}
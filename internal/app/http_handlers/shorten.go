@@ -3,11 +3,16 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/belamov/ypgo-url-shortener/internal/app/responses"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 )
 
@@ -32,9 +37,17 @@ func (h *Handler) Shorten(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// срок жизни ссылки передается в query: ?expires_at=<RFC3339> или ?ttl_seconds=<секунды>,
+	// ограничение числа переходов: ?max_clicks=<число>
+	options, err := queryOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	userID := h.getUserID(r)
 	// отсюда вход в текстовый сократитель
-	shortURL, err := h.service.Shorten(r.Context(), string(url), userID)
+	shortURL, err := h.service.Shorten(r.Context(), string(url), userID, options)
 
 	// ✔️ Проверка на уникальность. iter13 - уникальный индекс и ошибка 409
 	// ♊ пишет, что это правильно!
@@ -50,6 +63,9 @@ func (h *Handler) Shorten(w http.ResponseWriter, r *http.Request) {
 		writeShorteningResult(w, h, shortURL, http.StatusConflict)
 		return
 	}
+	if writeOptionsError(w, err) {
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -72,7 +88,14 @@ func writeShorteningResult(w http.ResponseWriter, h *Handler, shortURL models.Sh
 }
 
 func (h *Handler) ShortenAPI(w http.ResponseWriter, r *http.Request) {
-	var v models.ShortURL
+	var v struct {
+		ExpiresAt   time.Time `json:"expires_at"` // optional moment when url stops working
+		OriginalURL string    `json:"url"`
+		Alias       string    `json:"alias"`       // optional custom id of short url
+		TTLSeconds  int64     `json:"ttl_seconds"` // optional lifetime of url, can't be used along with expires_at
+		Password    string    `json:"password"`    // optional password that protects url
+		MaxClicks   int       `json:"max_clicks"`  // optional count of redirects after which url stops working
+	}
 
 	reader, err := getDecompressedReader(r)
 	if err != nil {
@@ -92,12 +115,21 @@ func (h *Handler) ShortenAPI(w http.ResponseWriter, r *http.Request) {
 
 	userID := h.getUserID(r)
 
-	shortURL, err := h.service.Shorten(r.Context(), v.OriginalURL, userID)
+	shortURL, err := h.service.Shorten(r.Context(), v.OriginalURL, userID, models.ShortenOptions{
+		ExpiresAt: v.ExpiresAt,
+		Alias:     v.Alias,
+		TTL:       time.Duration(v.TTLSeconds) * time.Second,
+		Password:  v.Password,
+		MaxClicks: v.MaxClicks,
+	})
 	var notUniqueErr *storage.NotUniqueURLError
 	if errors.As(err, &notUniqueErr) {
 		writeShorteningAPIResult(w, h, shortURL, http.StatusConflict)
 		return
 	}
+	if writeOptionsError(w, err) {
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -110,6 +142,50 @@ func (h *Handler) ShortenAPI(w http.ResponseWriter, r *http.Request) {
 	writeShorteningAPIResult(w, h, shortURL, http.StatusCreated)
 }
 
+// writeOptionsError writes response for errors of shorten options (custom alias, expiration and click limit)
+// and reports whether err was such error.
+func writeOptionsError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlias),
+		errors.Is(err, services.ErrInvalidExpiration),
+		errors.Is(err, services.ErrInvalidMaxClicks):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrAliasTaken), errors.Is(err, services.ErrAliasConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		return false
+	}
+	return true
+}
+
+// queryOptions parses shorten options from query parameters expires_at in RFC3339 format,
+// ttl_seconds and max_clicks. Empty parameters are skipped.
+func queryOptions(query url.Values) (models.ShortenOptions, error) {
+	var options models.ShortenOptions
+	if expiresAt := query.Get("expires_at"); expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return models.ShortenOptions{}, fmt.Errorf("%w: expires_at must be in RFC3339 format", services.ErrInvalidExpiration)
+		}
+		options.ExpiresAt = t
+	}
+	if ttlSeconds := query.Get("ttl_seconds"); ttlSeconds != "" {
+		ttl, err := strconv.ParseInt(ttlSeconds, 10, 64)
+		if err != nil {
+			return models.ShortenOptions{}, fmt.Errorf("%w: ttl_seconds must be integer", services.ErrInvalidExpiration)
+		}
+		options.TTL = time.Duration(ttl) * time.Second
+	}
+	if maxClicks := query.Get("max_clicks"); maxClicks != "" {
+		clicks, err := strconv.Atoi(maxClicks)
+		if err != nil {
+			return models.ShortenOptions{}, fmt.Errorf("%w: max_clicks must be integer", services.ErrInvalidMaxClicks)
+		}
+		options.MaxClicks = clicks
+	}
+	return options, nil
+}
+
 func writeShorteningAPIResult(w http.ResponseWriter, h *Handler, shortURL models.ShortURL, status int) {
 	res := responses.ShorteningResult{Result: h.service.FormatShortURL(shortURL.ID)}
 
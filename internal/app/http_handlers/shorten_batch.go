@@ -2,16 +2,24 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/belamov/ypgo-url-shortener/internal/app/responses"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 )
 
 func (h *Handler) ShortenBatchAPI(w http.ResponseWriter, r *http.Request) {
 	type request struct {
-		CorrelationID string `json:"correlation_id"`
-		OriginalURL   string `json:"original_url"`
+		ExpiresAt     time.Time `json:"expires_at"` // optional moment when url stops working
+		CorrelationID string    `json:"correlation_id"`
+		OriginalURL   string    `json:"original_url"`
+		Alias         string    `json:"alias"`       // optional custom id of short url
+		TTLSeconds    int64     `json:"ttl_seconds"` // optional lifetime of url, can't be used along with expires_at
+		Password      string    `json:"password"`    // optional password that protects url
+		MaxClicks     int       `json:"max_clicks"`  // optional count of redirects after which url stops working
 	}
 	var input []request
 
@@ -26,7 +34,7 @@ func (h *Handler) ShortenBatchAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	batch := make([]models.ShortURL, len(input))
+	batch := make([]models.ShortenBatchItem, len(input))
 
 	for i, shortURLInput := range input {
 		if shortURLInput.OriginalURL == "" {
@@ -34,9 +42,16 @@ func (h *Handler) ShortenBatchAPI(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// Здесь в batch записываются все данные полученные из запроса клиента
-		batch[i] = models.ShortURL{
+		batch[i] = models.ShortenBatchItem{
 			OriginalURL:   shortURLInput.OriginalURL,
 			CorrelationID: shortURLInput.CorrelationID,
+			Options: models.ShortenOptions{
+				ExpiresAt: shortURLInput.ExpiresAt,
+				Alias:     shortURLInput.Alias,
+				TTL:       time.Duration(shortURLInput.TTLSeconds) * time.Second,
+				Password:  shortURLInput.Password,
+				MaxClicks: shortURLInput.MaxClicks,
+			},
 		}
 	}
 
@@ -44,6 +59,17 @@ func (h *Handler) ShortenBatchAPI(w http.ResponseWriter, r *http.Request) {
 
 	// Здесь получаем ID (shortURL)
 	shortURLBatches, err := h.service.ShortenBatch(r.Context(), batch, userID)
+	// Уже существующие URL не ломают весь пакет: для них возвращается ранее сохраненная
+	// короткая ссылка со статусом exists
+	var existing map[int]models.ShortURL
+	var notUniqueErr *storage.NotUniqueBatchError
+	if errors.As(err, &notUniqueErr) {
+		existing = notUniqueErr.Existing
+		err = nil
+	}
+	if writeOptionsError(w, err) {
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -59,6 +85,10 @@ func (h *Handler) ShortenBatchAPI(w http.ResponseWriter, r *http.Request) {
 		res[i] = responses.ShorteningBatchResult{
 			CorrelationID: shortURLBatch.CorrelationID,
 			ShortURL:      h.service.FormatShortURL(shortURLBatch.ID),
+			Status:        responses.BatchItemCreated,
+		}
+		if _, ok := existing[i]; ok {
+			res[i].Status = responses.BatchItemExists
 		}
 	}
 
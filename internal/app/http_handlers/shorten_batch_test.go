@@ -10,6 +10,7 @@ import (
 	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -31,7 +32,7 @@ func TestHandler_ShortenBatchAPI(t *testing.T) {
 			name: "post with url",
 			want: want{
 				statusCode:  http.StatusCreated,
-				body:        "[{\"correlation_id\":\"corId1\",\"short_url\":\"http://localhost:8080/id1\"},{\"correlation_id\":\"corId2\",\"short_url\":\"http://localhost:8080/id2\"}]",
+				body:        "[{\"correlation_id\":\"corId1\",\"short_url\":\"http://localhost:8080/id1\",\"status\":\"created\"},{\"correlation_id\":\"corId2\",\"short_url\":\"http://localhost:8080/id2\",\"status\":\"created\"}]",
 				contentType: "application/json",
 			},
 			method: http.MethodPost,
@@ -46,6 +47,25 @@ func TestHandler_ShortenBatchAPI(t *testing.T) {
 			method: http.MethodPost,
 			body:   "[{\"correlation_id\":\"corId1\",\"original_url\":\"\"},{\"correlation_id\":\"cor2\",\"original_url\":\"url2\"}]",
 		},
+		{
+			name: "post with alias",
+			want: want{
+				statusCode:  http.StatusCreated,
+				body:        "[{\"correlation_id\":\"corId1\",\"short_url\":\"http://localhost:8080/sale\",\"status\":\"created\"},{\"correlation_id\":\"corId2\",\"short_url\":\"http://localhost:8080/id2\",\"status\":\"created\"}]",
+				contentType: "application/json",
+			},
+			method: http.MethodPost,
+			body:   "[{\"correlation_id\":\"corId1\",\"original_url\":\"url1\",\"alias\":\"sale\"},{\"correlation_id\":\"corId2\",\"original_url\":\"url2\"}]",
+		},
+		{
+			name: "post with invalid alias",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid alias: \"s\" must be from 3 to 64 characters long",
+			},
+			method: http.MethodPost,
+			body:   "[{\"correlation_id\":\"corId1\",\"original_url\":\"url1\",\"alias\":\"s\"}]",
+		},
 		{
 			name: "post without json",
 			want: want{
@@ -85,10 +105,24 @@ func TestHandler_ShortenBatchAPI(t *testing.T) {
 					CorrelationID: "corId2",
 				},
 			}).Return(nil).AnyTimes()
+			mockRepo.EXPECT().SaveBatch(gomock.Any(), []models.ShortURL{
+				{
+					OriginalURL:   "url1",
+					ID:            "sale",
+					CreatedByID:   "user id",
+					CorrelationID: "corId1",
+				},
+				{
+					OriginalURL:   "url2",
+					ID:            "id2",
+					CreatedByID:   "user id",
+					CorrelationID: "corId2",
+				},
+			}).Return(nil).AnyTimes()
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
-			mockGen.EXPECT().GenerateIDFromString("url1").Return("id1", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("url2").Return("id2", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url1").Return("id1", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url2").Return("id2", nil).AnyTimes()
 
 			mockRandom := mocks.NewMockGenerator(ctrl)
 			mockRandom.EXPECT().GenerateNewUserID().Return("user id").AnyTimes()
@@ -127,3 +161,40 @@ func TestHandler_ShortenBatchAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_ShortenBatchAPIWithExistingUrls(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().SaveBatch(gomock.Any(), gomock.Any()).Return(storage.NewNotUniqueBatchError(map[int]models.ShortURL{
+		1: {OriginalURL: "url2", ID: "existing id", CreatedByID: "another user"},
+	}))
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url1").Return("id1", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url2").Return("id2", nil)
+
+	mockRandom := mocks.NewMockGenerator(ctrl)
+	mockRandom.EXPECT().GenerateNewUserID().Return("user id").AnyTimes()
+	mockRandom.EXPECT().GenerateRandomBytes(12).Return(make([]byte, 12), nil).AnyTimes()
+
+	cfg := &config.Config{
+		BaseURL:       "http://localhost:8080",
+		ServerAddress: ":8080",
+		EncryptionKey: make([]byte, 2*aes.BlockSize),
+	}
+
+	service := services.New(mockRepo, mockGen, mockRandom, cfg)
+	ts := httptest.NewServer(NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg))
+	defer ts.Close()
+
+	result, body := testRequest(t, ts, http.MethodPost, "/api/shorten/batch", `[{"correlation_id":"corId1","original_url":"url1"},{"correlation_id":"corId2","original_url":"url2"}]`, nil)
+	defer result.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.JSONEq(t, `[
+		{"correlation_id":"corId1","short_url":"http://localhost:8080/id1","status":"created"},
+		{"correlation_id":"corId2","short_url":"http://localhost:8080/existing id","status":"exists"}
+	]`, body)
+}
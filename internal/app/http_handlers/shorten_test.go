@@ -31,6 +31,7 @@ func TestHandler_Shorten(t *testing.T) {
 		name   string
 		body   string
 		method string
+		query  string
 		want   want
 	}{
 		{
@@ -78,6 +79,26 @@ func TestHandler_Shorten(t *testing.T) {
 			method: http.MethodPost,
 			body:   "existingURL",
 		},
+		{
+			name: "it returns 400 when ttl is not integer",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid expiration: ttl_seconds must be integer",
+			},
+			method: http.MethodPost,
+			body:   "url",
+			query:  "?ttl_seconds=day",
+		},
+		{
+			name: "it returns 400 when expiration time is in the past",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid expiration: 2000-01-01T00:00:00Z is in the past",
+			},
+			method: http.MethodPost,
+			body:   "url",
+			query:  "?expires_at=2000-01-01T00:00:00Z",
+		},
 	}
 
 	for _, tt := range tests {
@@ -86,7 +107,7 @@ func TestHandler_Shorten(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mocks.NewMockRepository(ctrl)
-			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user id").Return(nil, nil).AnyTimes()
+			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user id", models.UsersURLsQuery{}).Return(nil, nil).AnyTimes()
 			mockRepo.EXPECT().Save(gomock.Any(), models.ShortURL{
 				OriginalURL: "existingURL",
 				ID:          "id",
@@ -99,10 +120,10 @@ func TestHandler_Shorten(t *testing.T) {
 			}).Return(nil).AnyTimes()
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
-			mockGen.EXPECT().GenerateIDFromString("url").Return("id", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("existingURL").Return("id", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("").Return("", errors.New("err")).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("error_on_shortening").Return("", errors.New("err")).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "existingURL").Return("id", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "").Return("", errors.New("err")).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "error_on_shortening").Return("", errors.New("err")).AnyTimes()
 
 			mockRandom := mocks.NewMockGenerator(ctrl)
 			mockRandom.EXPECT().GenerateNewUserID().Return("user id").AnyTimes()
@@ -121,13 +142,13 @@ func TestHandler_Shorten(t *testing.T) {
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 
-			result, body := testRequest(t, ts, tt.method, "/", tt.body, nil)
+			result, body := testRequest(t, ts, tt.method, "/"+tt.query, tt.body, nil)
 			defer result.Body.Close()
 
 			assert.Equal(t, tt.want.statusCode, result.StatusCode)
 			assert.Equal(t, tt.want.body, body)
 
-			result, body = testGzippedRequest(t, ts, tt.method, "/", tt.body)
+			result, body = testGzippedRequest(t, ts, tt.method, "/"+tt.query, tt.body)
 			defer result.Body.Close()
 
 			assert.Equal(t, tt.want.statusCode, result.StatusCode)
@@ -215,6 +236,61 @@ func TestHandler_ShortenAPI(t *testing.T) {
 			method: http.MethodPost,
 			body:   "{\"url\":\"existingURL\"}",
 		},
+		{
+			name: "post with alias",
+			want: want{
+				statusCode:  http.StatusCreated,
+				body:        "{\"result\":\"http://localhost:8080/spring-sale\"}",
+				contentType: "application/json",
+			},
+			method: http.MethodPost,
+			body:   "{\"url\":\"url\",\"alias\":\"spring-sale\"}",
+		},
+		{
+			name: "it returns 400 when alias is reserved",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid alias: \"api\" is reserved",
+			},
+			method: http.MethodPost,
+			body:   "{\"url\":\"url\",\"alias\":\"api\"}",
+		},
+		{
+			name: "it returns 409 when alias is taken by another url",
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "alias is already taken: \"taken\"",
+			},
+			method: http.MethodPost,
+			body:   "{\"url\":\"url\",\"alias\":\"taken\"}",
+		},
+		{
+			name: "it returns 409 when url is already shortened with another id",
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "url is already shortened with another id, alias isn't created: \"sale\", url is shortened as \"id\"",
+			},
+			method: http.MethodPost,
+			body:   "{\"url\":\"existingURL\",\"alias\":\"sale\"}",
+		},
+		{
+			name: "it returns 400 when both expiration time and ttl are set",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid expiration: only one of expiration time and ttl can be set",
+			},
+			method: http.MethodPost,
+			body:   "{\"url\":\"url\",\"expires_at\":\"2100-01-01T00:00:00Z\",\"ttl_seconds\":60}",
+		},
+		{
+			name: "it returns 400 when max clicks are negative",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "max clicks must not be negative",
+			},
+			method: http.MethodPost,
+			body:   "{\"url\":\"url\",\"max_clicks\":-1}",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -232,12 +308,35 @@ func TestHandler_ShortenAPI(t *testing.T) {
 				ID:          "id",
 				CreatedByID: "user id",
 			}).Return(storage.ErrNotUnique).AnyTimes()
+			mockRepo.EXPECT().Save(gomock.Any(), models.ShortURL{
+				OriginalURL: "url",
+				ID:          "spring-sale",
+				CreatedByID: "user id",
+			}).Return(nil).AnyTimes()
+			mockRepo.EXPECT().Save(gomock.Any(), models.ShortURL{
+				OriginalURL: "existingURL",
+				ID:          "sale",
+				CreatedByID: "user id",
+			}).Return(storage.NewNotUniqueURLError(
+				models.ShortURL{OriginalURL: "existingURL", ID: "sale"},
+				models.ShortURL{OriginalURL: "existingURL", ID: "id"},
+				nil,
+			)).AnyTimes()
+			mockRepo.EXPECT().Save(gomock.Any(), models.ShortURL{
+				OriginalURL: "url",
+				ID:          "taken",
+				CreatedByID: "user id",
+			}).Return(storage.NewNotUniqueURLError(
+				models.ShortURL{OriginalURL: "url", ID: "taken"},
+				models.ShortURL{OriginalURL: "another url", ID: "taken"},
+				nil,
+			)).AnyTimes()
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
-			mockGen.EXPECT().GenerateIDFromString("url").Return("id", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("existingURL").Return("id", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("").Return("", errors.New("err")).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("error_on_shortening").Return("", errors.New("err")).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "existingURL").Return("id", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "").Return("", errors.New("err")).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "error_on_shortening").Return("", errors.New("err")).AnyTimes()
 
 			mockRandom := mocks.NewMockGenerator(ctrl)
 			mockRandom.EXPECT().GenerateNewUserID().Return("user id").AnyTimes()
@@ -2,11 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 )
 
+// Stats returns stats of the service. Window of created urls is set by from and to query parameters in RFC3339,
+// its periods by granularity (day or hour) and size of top lists by top. Omitted parameters get defaults, see services.GetStats.
 func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.service.GetStats(r.Context())
+	query, err := statsQuery(r.URL.Query())
+	var stats models.Stats
+	if err == nil {
+		stats, err = h.service.GetStats(r.Context(), query)
+	}
+	if errors.Is(err, services.ErrInvalidStatsQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -23,3 +41,29 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// statsQuery reads stats query from query parameters of request.
+func statsQuery(values url.Values) (models.StatsQuery, error) {
+	query := models.StatsQuery{Granularity: models.StatsGranularity(values.Get("granularity"))}
+
+	for param, value := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if values.Get(param) == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, values.Get(param))
+		if err != nil {
+			return models.StatsQuery{}, fmt.Errorf("%w: %s must be in RFC3339 format", services.ErrInvalidStatsQuery, param)
+		}
+		*value = t
+	}
+
+	if values.Get("top") != "" {
+		top, err := strconv.Atoi(values.Get("top"))
+		if err != nil {
+			return models.StatsQuery{}, fmt.Errorf("%w: top must be integer", services.ErrInvalidStatsQuery)
+		}
+		query.Top = top
+	}
+
+	return query, nil
+}
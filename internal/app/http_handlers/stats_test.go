@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
 	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
@@ -19,8 +20,23 @@ func TestHandler_Stats(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	repoStats := models.Stats{
+		Created:          []models.PeriodCount{{Start: day.Add(24 * time.Hour), Count: 2}},
+		TopCreators:      []models.CreatorCount{{UserID: "user", Count: 2}},
+		TopURLs:          []models.URLClicks{{ID: "id", Clicks: 5}},
+		UrlsCount:        2,
+		UsersCount:       1,
+		ActiveUrlsCount:  1,
+		DeletedUrlsCount: 1,
+	}
 	mockRepo := mocks.NewMockRepository(ctrl)
-	mockRepo.EXPECT().GetUsersAndUrlsCount(gomock.Any()).Return(0, 0, nil).AnyTimes()
+	mockRepo.EXPECT().GetStats(gomock.Any(), models.StatsQuery{
+		From:        day,
+		To:          day.Add(2 * 24 * time.Hour),
+		Granularity: models.StatsByDay,
+		Top:         5,
+	}).Return(repoStats, nil).AnyTimes()
 
 	mockGen := mocks.NewMockURLGenerator(ctrl)
 	mockRandom := mocks.NewMockGenerator(ctrl)
@@ -39,15 +55,33 @@ func TestHandler_Stats(t *testing.T) {
 		ts := httptest.NewServer(r)
 		defer ts.Close()
 
-		result, body := testRequest(t, ts, http.MethodGet, "/api/internal/stats", "", nil)
+		result, body := testRequest(t, ts, http.MethodGet, "/api/internal/stats?from=2024-03-01T00:00:00Z&to=2024-03-03T00:00:00Z&granularity=day&top=5", "", nil)
 		defer result.Body.Close()
 
 		assert.Equal(t, http.StatusOK, result.StatusCode)
-		expectedJSON, err := json.Marshal(models.Stats{})
+		wantStats := repoStats
+		wantStats.Created = []models.PeriodCount{{Start: day, Count: 0}, {Start: day.Add(24 * time.Hour), Count: 2}}
+		expectedJSON, err := json.Marshal(wantStats)
 		assert.NoError(t, err)
 		assert.JSONEq(t, string(expectedJSON), body)
 	})
 
+	t.Run("with invalid query", func(t *testing.T) {
+		mockChecker := mocks.NewMockIPCheckerInterface(ctrl)
+		mockChecker.EXPECT().IsRequestFromTrustedSubnet(gomock.Any()).Return(true, nil).Times(3)
+
+		service := services.New(mockRepo, mockGen, mockRandom, cfg)
+		r := NewRouter(service, mockChecker, cfg)
+		ts := httptest.NewServer(r)
+		defer ts.Close()
+
+		for _, query := range []string{"from=yesterday", "granularity=week", "top=many"} {
+			result, _ := testRequest(t, ts, http.MethodGet, "/api/internal/stats?"+query, "", nil)
+			result.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, result.StatusCode, query)
+		}
+	})
+
 	t.Run("not from trusted subnet", func(t *testing.T) {
 		mockChecker := mocks.NewMockIPCheckerInterface(ctrl)
 		mockChecker.EXPECT().IsRequestFromTrustedSubnet(gomock.Any()).Return(false, nil)
@@ -64,3 +98,31 @@ func TestHandler_Stats(t *testing.T) {
 		assert.Equal(t, "forbidden", body)
 	})
 }
+
+func TestHandler_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := &config.Config{
+		BaseURL:       "http://localhost:8080",
+		ServerAddress: ":8080",
+		EncryptionKey: make([]byte, 2*aes.BlockSize),
+	}
+	service := services.New(mocks.NewMockRepository(ctrl), mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), cfg)
+
+	mockChecker := mocks.NewMockIPCheckerInterface(ctrl)
+	mockChecker.EXPECT().IsRequestFromTrustedSubnet(gomock.Any()).Return(true, nil)
+	mockChecker.EXPECT().IsRequestFromTrustedSubnet(gomock.Any()).Return(false, nil)
+
+	ts := httptest.NewServer(NewRouter(service, mockChecker, cfg))
+	defer ts.Close()
+
+	result, body := testRequest(t, ts, http.MethodGet, "/metrics", "", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Contains(t, body, "shortener_http_requests_total")
+
+	result, _ = testRequest(t, ts, http.MethodGet, "/metrics", "", nil)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusForbidden, result.StatusCode, "metrics must be available only from trusted subnet")
+}
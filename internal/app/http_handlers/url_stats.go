@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// URLStats returns click statistics of url shortened by user: total clicks, unique visitors and clicks by day.
+func (h *Handler) URLStats(w http.ResponseWriter, r *http.Request) {
+	uID := chi.URLParam(r, "id") //nolint:contextcheck
+
+	stats, err := h.service.GetClickStats(r.Context(), uID, h.getUserID(r))
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "cant find url", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := json.Marshal(stats)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(out); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services/crypto"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_URLStats(t *testing.T) {
+	type want struct {
+		body       string
+		statusCode int
+	}
+	tests := []struct {
+		name    string
+		request string
+		userID  string
+		want    want
+	}{
+		{
+			name:    "get stats of user's url",
+			request: "/api/user/urls/id/stats",
+			userID:  "user id",
+			want: want{
+				body:       `{"daily":[{"date":"2024-03-01","clicks":2},{"date":"2024-03-02","clicks":1}],"total":3,"unique_visitors":2}`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:    "get stats of url without clicks",
+			request: "/api/user/urls/unclicked/stats",
+			userID:  "user id",
+			want: want{
+				body:       `{"daily":[],"total":0,"unique_visitors":0}`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:    "get stats of another user's url",
+			request: "/api/user/urls/id/stats",
+			userID:  "another user id",
+			want: want{
+				body:       "cant find url",
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:    "get stats of missing url",
+			request: "/api/user/urls/missing/stats",
+			userID:  "user id",
+			want: want{
+				body:       "cant find url",
+				statusCode: http.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user id"}, nil).AnyTimes()
+			mockRepo.EXPECT().GetByID(gomock.Any(), "unclicked").Return(models.ShortURL{OriginalURL: "url", ID: "unclicked", CreatedByID: "user id"}, nil).AnyTimes()
+			mockRepo.EXPECT().GetByID(gomock.Any(), "missing").Return(models.ShortURL{}, storage.ErrNotFound).AnyTimes()
+			mockRepo.EXPECT().GetClickStats(gomock.Any(), "id").Return(models.ClickStats{
+				Daily:          []models.DailyClicks{{Date: "2024-03-01", Clicks: 2}, {Date: "2024-03-02", Clicks: 1}},
+				Total:          3,
+				UniqueVisitors: 2,
+			}, nil).AnyTimes()
+			mockRepo.EXPECT().GetClickStats(gomock.Any(), "unclicked").Return(models.ClickStats{Daily: []models.DailyClicks{}}, nil).AnyTimes()
+
+			mockRandom := mocks.NewMockGenerator(ctrl)
+			mockRandom.EXPECT().GenerateRandomBytes(12).Return(make([]byte, 12), nil).AnyTimes()
+
+			cfg := &config.Config{
+				BaseURL:       "http://localhost:8080",
+				ServerAddress: ":8080",
+				EncryptionKey: make([]byte, 2*aes.BlockSize),
+			}
+
+			service := services.New(mockRepo, mocks.NewMockURLGenerator(ctrl), mockRandom, cfg)
+			r := NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			cryptographer := crypto.GCMAESCryptographer{Key: cfg.EncryptionKey, Random: mockRandom}
+			encryptedCookieValue, _ := cryptographer.Encrypt([]byte(tt.userID))
+			cookies := map[string]string{
+				UserIDCookieName: hex.EncodeToString(encryptedCookieValue),
+			}
+			result, body := testRequest(t, ts, http.MethodGet, tt.request, "", cookies)
+			defer result.Body.Close()
+
+			assert.Equal(t, tt.want.statusCode, result.StatusCode)
+			if tt.want.statusCode == http.StatusOK {
+				assert.JSONEq(t, tt.want.body, body)
+				return
+			}
+			assert.Equal(t, tt.want.body, body)
+		})
+	}
+}
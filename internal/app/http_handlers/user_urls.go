@@ -2,33 +2,55 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/belamov/ypgo-url-shortener/internal/app/responses"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 )
 
+// UserURLs returns page of urls of user. Urls are filtered by status (active, deleted or all),
+// creation window set by created_from and created_to in RFC3339 and substring search of original url,
+// they are sorted by order (asc or desc by creation time) and split to pages of limit urls.
+// Url of the next page is sent in Link header, the next page is selected by cursor parameter.
+// Omitted parameters get defaults, see services.GetUrlsCreatedBy.
 func (h *Handler) UserURLs(w http.ResponseWriter, r *http.Request) {
-	// userID
-	userID := h.getUserID(r)
-	fmt.Println(userID)
-	URLs, err := h.service.GetUrlsCreatedBy(r.Context(), userID)
+	query, err := usersURLsQuery(r.URL.Query())
+	var page models.UsersURLsPage
+	if err == nil {
+		page, err = h.service.GetUrlsCreatedBy(r.Context(), h.getUserID(r), query)
+	}
+	if errors.Is(err, services.ErrInvalidUsersURLsQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if len(URLs) == 0 {
+	if len(page.URLs) == 0 {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
 	formattedURLs := make([]responses.UsersShortURL, 0)
-	for _, URL := range URLs {
-		formattedURLs = append(
-			formattedURLs,
-			responses.UsersShortURL{ShortURL: h.service.FormatShortURL(URL.ID), OriginalURL: URL.OriginalURL},
-		)
+	for _, URL := range page.URLs {
+		formattedURL := responses.UsersShortURL{
+			CreatedAt:   URL.CreatedAt,
+			ShortURL:    h.service.FormatShortURL(URL.ID),
+			OriginalURL: URL.OriginalURL,
+			Deleted:     !URL.DeletedAt.IsZero(),
+		}
+		if remaining, ok := URL.RemainingClicks(); ok {
+			formattedURL.RemainingClicks = &remaining
+		}
+		formattedURLs = append(formattedURLs, formattedURL)
 	}
 
 	out, err := json.Marshal(formattedURLs)
@@ -37,8 +59,51 @@ func (h *Handler) UserURLs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if page.Next != nil {
+		next := r.URL.Query()
+		next.Set("cursor", page.Next.String())
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, next.Encode()))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	if _, err = w.Write(out); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// usersURLsQuery reads query of urls of user from query parameters of request.
+func usersURLsQuery(values url.Values) (models.UsersURLsQuery, error) {
+	query := models.UsersURLsQuery{
+		Status: models.URLStatus(values.Get("status")),
+		Search: values.Get("search"),
+		Order:  models.SortOrder(values.Get("order")),
+	}
+
+	for param, value := range map[string]*time.Time{"created_from": &query.CreatedFrom, "created_to": &query.CreatedTo} {
+		if values.Get(param) == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, values.Get(param))
+		if err != nil {
+			return models.UsersURLsQuery{}, fmt.Errorf("%w: %s must be in RFC3339 format", services.ErrInvalidUsersURLsQuery, param)
+		}
+		*value = t
+	}
+
+	if values.Get("limit") != "" {
+		limit, err := strconv.Atoi(values.Get("limit"))
+		if err != nil {
+			return models.UsersURLsQuery{}, fmt.Errorf("%w: limit must be integer", services.ErrInvalidUsersURLsQuery)
+		}
+		query.Limit = limit
+	}
+
+	if values.Get("cursor") != "" {
+		cursor, err := models.ParseURLCursor(values.Get("cursor"))
+		if err != nil {
+			return models.UsersURLsQuery{}, fmt.Errorf("%w: %s", services.ErrInvalidUsersURLsQuery, err.Error())
+		}
+		query.After = &cursor
+	}
+
+	return query, nil
+}
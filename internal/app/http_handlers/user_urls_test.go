@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
 	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
@@ -40,6 +41,17 @@ func TestHandler_UserURLs(t *testing.T) {
 			},
 			userID: "user id with urls",
 		},
+		{
+			name: "get user's urls with click limit",
+			want: want{
+				body: []responses.UsersShortURL{
+					{ShortURL: "http://localhost:8080/limited", OriginalURL: "url", RemainingClicks: intPtr(2)},
+					{ShortURL: "http://localhost:8080/used", OriginalURL: "url2", RemainingClicks: intPtr(0)},
+				},
+				statusCode: http.StatusOK,
+			},
+			userID: "user id with limited urls",
+		},
 		{
 			name: "get another user's urls ",
 			want: want{
@@ -54,9 +66,15 @@ func TestHandler_UserURLs(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
+			// first page of urls that aren't deleted, one more url tells whether there is the next page
+			defaultQuery := models.UsersURLsQuery{Status: models.URLsActive, Order: models.SortOldestFirst, Limit: 101}
 			mockRepo := mocks.NewMockRepository(ctrl)
-			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user id with urls").Return([]models.ShortURL{{OriginalURL: "url", ID: "id"}}, nil).AnyTimes()
-			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user id without urls").Return([]models.ShortURL{}, nil).AnyTimes()
+			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user id with urls", defaultQuery).Return([]models.ShortURL{{OriginalURL: "url", ID: "id"}}, nil).AnyTimes()
+			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user id with limited urls", defaultQuery).Return([]models.ShortURL{
+				{OriginalURL: "url", ID: "limited", MaxClicks: 3, Clicks: 1},
+				{OriginalURL: "url2", ID: "used", MaxClicks: 1, Clicks: 1},
+			}, nil).AnyTimes()
+			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user id without urls", defaultQuery).Return([]models.ShortURL{}, nil).AnyTimes()
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
 
@@ -95,3 +113,100 @@ func TestHandler_UserURLs(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_UserURLsPage(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cursor := models.URLCursor{CreatedAt: createdAt, ID: "second"}
+	tests := []struct {
+		query            models.UsersURLsQuery
+		name             string
+		params           string
+		wantLink         string
+		wantBody         []responses.UsersShortURL
+		found            int // count of urls found by storage
+		wantResponseCode int
+	}{
+		{
+			name:   "it passes filters to storage and links the next page",
+			params: "?status=all&search=example&order=desc&limit=2&created_from=2024-01-01T00:00:00Z",
+			query: models.UsersURLsQuery{
+				CreatedFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Status:      models.URLsAll,
+				Search:      "example",
+				Order:       models.SortNewestFirst,
+				Limit:       3,
+			},
+			wantBody: []responses.UsersShortURL{
+				{ShortURL: "http://localhost:8080/first", OriginalURL: "http://example.com/1", CreatedAt: createdAt},
+				{ShortURL: "http://localhost:8080/second", OriginalURL: "http://example.com/2", CreatedAt: createdAt, Deleted: true},
+			},
+			found:            3,
+			wantLink:         "</api/user/urls?created_from=2024-01-01T00%3A00%3A00Z&cursor=" + cursor.String() + "&limit=2&order=desc&search=example&status=all>; rel=\"next\"",
+			wantResponseCode: http.StatusOK,
+		},
+		{
+			name:   "it selects the page after cursor",
+			params: "?cursor=" + cursor.String(),
+			query: models.UsersURLsQuery{
+				After:  &cursor,
+				Status: models.URLsActive,
+				Order:  models.SortOldestFirst,
+				Limit:  101,
+			},
+			found: 1,
+			wantBody: []responses.UsersShortURL{
+				{ShortURL: "http://localhost:8080/first", OriginalURL: "http://example.com/1", CreatedAt: createdAt},
+			},
+			wantResponseCode: http.StatusOK,
+		},
+		{name: "it rejects unknown status", params: "?status=archived", wantResponseCode: http.StatusBadRequest},
+		{name: "it rejects unknown order", params: "?order=random", wantResponseCode: http.StatusBadRequest},
+		{name: "it rejects wrong limit", params: "?limit=1001", wantResponseCode: http.StatusBadRequest},
+		{name: "it rejects wrong creation time", params: "?created_to=yesterday", wantResponseCode: http.StatusBadRequest},
+		{name: "it rejects wrong cursor", params: "?cursor=wrong", wantResponseCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "new user id", tt.query).Return([]models.ShortURL{
+				{OriginalURL: "http://example.com/1", ID: "first", CreatedAt: createdAt},
+				{OriginalURL: "http://example.com/2", ID: "second", CreatedAt: createdAt, DeletedAt: createdAt},
+				{OriginalURL: "http://example.com/3", ID: "third", CreatedAt: createdAt},
+			}[:tt.found], nil).MaxTimes(1)
+
+			mockRandom := mocks.NewMockGenerator(ctrl)
+			mockRandom.EXPECT().GenerateNewUserID().Return("new user id").AnyTimes()
+			mockRandom.EXPECT().GenerateRandomBytes(12).Return(make([]byte, 12), nil).AnyTimes()
+
+			cfg := &config.Config{
+				BaseURL:       "http://localhost:8080",
+				ServerAddress: ":8080",
+				EncryptionKey: make([]byte, 2*aes.BlockSize),
+			}
+
+			service := services.New(mockRepo, mocks.NewMockURLGenerator(ctrl), mockRandom, cfg)
+			r := NewRouter(service, mocks.NewMockIPCheckerInterface(ctrl), cfg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			result, body := testRequest(t, ts, http.MethodGet, "/api/user/urls"+tt.params, "", nil)
+			defer result.Body.Close()
+
+			assert.Equal(t, tt.wantResponseCode, result.StatusCode)
+			assert.Equal(t, tt.wantLink, result.Header.Get("Link"))
+			if tt.wantResponseCode != http.StatusOK {
+				return
+			}
+			expectedJSON, err := json.Marshal(tt.wantBody)
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(expectedJSON), body)
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
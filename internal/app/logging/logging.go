@@ -0,0 +1,145 @@
+// Package logging configures logger of the service and carries it in context of requests,
+// so every log line written while handling request has id of the request.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RequestIDHeader is header and grpc metadata key with id of request.
+// Id from incoming request is used when present, otherwise new id is generated.
+const RequestIDHeader = "X-Request-Id"
+
+// grpcRequestIDKey is RequestIDHeader as grpc metadata key, keys of metadata are lowercase.
+const grpcRequestIDKey = "x-request-id"
+
+type ctxKey struct{}
+
+// New returns logger that writes messages of level and above to out in format, see config.LogFormat.
+func New(level string, format string, out io.Writer) (zerolog.Logger, error) {
+	parsedLevel, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return zerolog.Logger{}, fmt.Errorf("unknown log level %q: %w", level, err)
+	}
+
+	switch format {
+	case config.LogFormatJSON:
+	case config.LogFormatConsole:
+		out = zerolog.ConsoleWriter{Out: out}
+	default:
+		return zerolog.Logger{}, fmt.Errorf("unknown log format %q", format)
+	}
+
+	return zerolog.New(out).Level(parsedLevel).With().Timestamp().Logger(), nil
+}
+
+// WithLogger returns copy of ctx that carries logger.
+func WithLogger(ctx context.Context, logger zerolog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns logger carried by ctx or global logger when ctx doesn't carry any.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(zerolog.Logger); ok {
+		return &logger
+	}
+	return &log.Logger
+}
+
+// Detach returns background context that carries logger of ctx.
+// It's used for work that outlives the request, like asynchronous deletion.
+func Detach(ctx context.Context) context.Context {
+	return WithLogger(context.Background(), *FromContext(ctx))
+}
+
+// HTTPMiddleware puts logger with id of request to context of request and logs every handled request.
+// It must be used after middleware.RequestID, route pattern is known only after routing.
+func HTTPMiddleware(logger zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestID := middleware.GetReqID(r.Context())
+			requestLogger := logger.With().Str("request_id", requestID).Logger()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Header().Set(RequestIDHeader, requestID)
+
+			next.ServeHTTP(ww, r.WithContext(WithLogger(r.Context(), requestLogger)))
+
+			route := ""
+			if routeContext := chi.RouteContext(r.Context()); routeContext != nil {
+				route = routeContext.RoutePattern()
+			}
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			event := requestLogger.Info()
+			if code >= http.StatusInternalServerError {
+				event = requestLogger.Error()
+			}
+			event.
+				Str("method", r.Method).
+				Str("route", route).
+				Int("status", code).
+				Dur("latency", time.Since(start)).
+				Int("bytes", ww.BytesWritten()).
+				Msg("http request")
+		})
+	}
+}
+
+// UnaryServerInterceptor puts logger with id of request to context of request and logs every handled grpc request.
+// Id of request is returned in header of response.
+func UnaryServerInterceptor(logger zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(grpcRequestIDKey)) > 0 {
+			requestID = md.Get(grpcRequestIDKey)[0]
+		}
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		requestLogger := logger.With().Str("request_id", requestID).Logger()
+		if err := grpc.SetHeader(ctx, metadata.Pairs(grpcRequestIDKey, requestID)); err != nil {
+			requestLogger.Warn().Err(err).Msg("couldn't set request id header")
+		}
+
+		resp, err := handler(WithLogger(ctx, requestLogger), req)
+
+		bytes := 0
+		if message, ok := resp.(proto.Message); ok {
+			bytes = proto.Size(message)
+		}
+		code := status.Code(err)
+		event := requestLogger.Info()
+		if code == codes.Internal || code == codes.Unknown {
+			event = requestLogger.Error().Err(err)
+		}
+		event.
+			Str("method", info.FullMethod).
+			Str("status", code.String()).
+			Dur("latency", time.Since(start)).
+			Int("bytes", bytes).
+			Msg("grpc request")
+
+		return resp, err
+	}
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New("warn", config.LogFormatJSON, &out)
+	require.NoError(t, err)
+
+	logger.Info().Msg("skipped")
+	logger.Warn().Msg("written")
+	lines := logLines(t, &out)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "written", lines[0]["message"])
+	assert.Equal(t, "warn", lines[0]["level"])
+	assert.Contains(t, lines[0], "time")
+
+	out.Reset()
+	logger, err = New("info", config.LogFormatConsole, &out)
+	require.NoError(t, err)
+	logger.Info().Msg("console")
+	assert.Contains(t, out.String(), "console")
+	assert.False(t, json.Valid(out.Bytes()), "console format must not be json")
+
+	_, err = New("verbose", config.LogFormatJSON, &out)
+	assert.Error(t, err)
+	_, err = New("info", "xml", &out)
+	assert.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, &log.Logger, FromContext(context.Background()), "global logger is used when context doesn't carry any")
+
+	var out bytes.Buffer
+	ctx, cancel := context.WithCancel(WithLogger(context.Background(), zerolog.New(&out).With().Str("request_id", "id").Logger()))
+	cancel()
+
+	detached := Detach(ctx)
+	assert.NoError(t, detached.Err(), "detached context must not be canceled with request")
+	FromContext(detached).Info().Msg("detached")
+
+	lines := logLines(t, &out)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "id", lines[0]["request_id"])
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(HTTPMiddleware(zerolog.New(&out)))
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info().Msg("handling")
+		http.Error(w, "cant find full url", http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set(RequestIDHeader, "request id")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, "request id", recorder.Header().Get(RequestIDHeader))
+	lines := logLines(t, &out)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "handling", lines[0]["message"])
+	assert.Equal(t, "request id", lines[0]["request_id"], "handler must log with id of request")
+
+	access := lines[1]
+	assert.Equal(t, "request id", access["request_id"])
+	assert.Equal(t, http.MethodGet, access["method"])
+	assert.Equal(t, "/{id}", access["route"])
+	assert.Equal(t, float64(http.StatusNotFound), access["status"])
+	assert.Equal(t, float64(len("cant find full url\n")), access["bytes"])
+	assert.Contains(t, access, "latency")
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var out bytes.Buffer
+	interceptor := UnaryServerInterceptor(zerolog.New(&out))
+	info := &grpc.UnaryServerInfo{FullMethod: "/shortener.Shortener/Expand"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpcRequestIDKey, "request id"))
+
+	_, err := interceptor(ctx, "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		FromContext(ctx).Info().Msg("handling")
+		return nil, status.Error(codes.Internal, "storage is down")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	var handled, access map[string]interface{}
+	for _, line := range logLines(t, &out) {
+		switch line["message"] {
+		case "handling":
+			handled = line
+		case "grpc request":
+			access = line
+		}
+	}
+	require.NotNil(t, handled)
+	require.NotNil(t, access)
+	assert.Equal(t, "request id", handled["request_id"])
+	assert.Equal(t, "request id", access["request_id"])
+	assert.Equal(t, info.FullMethod, access["method"])
+	assert.Equal(t, codes.Internal.String(), access["status"])
+	assert.Equal(t, "error", access["level"])
+
+	out.Reset()
+	_, err = interceptor(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	lines := logLines(t, &out)
+	require.NotEmpty(t, lines)
+	assert.NotEmpty(t, lines[len(lines)-1]["request_id"], "id is generated when request doesn't have it")
+}
+
+func logLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &fields))
+		lines = append(lines, fields)
+	}
+	return lines
+}
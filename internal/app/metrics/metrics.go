@@ -0,0 +1,155 @@
+// Package metrics collects metrics of the service and exposes them in Prometheus text format.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "shortener"
+
+// unmatchedRoute is route label of requests that didn't match any route,
+// raw paths aren't used as labels to keep count of series bounded.
+const unmatchedRoute = "unmatched"
+
+//nolint:gochecknoglobals
+var (
+	registry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Count of handled http requests by route pattern.",
+	}, []string{"method", "route", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of http requests by route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Count of handled grpc requests by method.",
+	}, []string{"method", "code"})
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Latency of grpc requests by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Latency of repository operations by storage backend.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "operation"})
+	repositoryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_errors_total",
+		Help:      "Count of failed repository operations by storage backend.",
+	}, []string{"backend", "operation"})
+
+	deleteQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "delete_queue_depth",
+		Help:      "Count of delete requests waiting for workers.",
+	})
+
+	buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Build of running service, value is always 1.",
+	}, []string{"version", "date", "commit"})
+)
+
+func init() {
+	registry.MustRegister(
+		httpRequests,
+		httpDuration,
+		grpcRequests,
+		grpcDuration,
+		repositoryDuration,
+		repositoryErrors,
+		deleteQueueDepth,
+		buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler returns handler that serves all collected metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// SetBuildInfo reports build of running service.
+func SetBuildInfo(version string, date string, commit string) {
+	buildInfo.Reset()
+	buildInfo.WithLabelValues(version, date, commit).Set(1)
+}
+
+// HTTPMiddleware counts requests and measures their latency by chi route pattern.
+// It must be used on the root router, pattern is known only after routing.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+			route = routeContext.RoutePattern()
+		}
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(code)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// UnaryServerInterceptor counts grpc requests and measures their latency by method.
+func UnaryServerInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	start := time.Now()
+
+	resp, err := handler(ctx, req)
+
+	grpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	grpcDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
+
+// ObserveRepository reports latency of repository operation started at start.
+// Failed operation is counted as error, expected outcomes like missing url are not failures.
+func ObserveRepository(backend string, operation string, start time.Time, failed bool) {
+	repositoryDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+	if failed {
+		repositoryErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+// SetDeleteQueueDepth reports count of delete requests waiting for workers.
+func SetDeleteQueueDepth(depth int) {
+	deleteQueueDepth.Set(float64(depth))
+}
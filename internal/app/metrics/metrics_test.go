@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(HTTPMiddleware)
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/first", "/second", "/ping", "/api/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/{id}", "307")), "requests are counted by route pattern")
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/ping", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+	assert.Equal(t, 3, testutil.CollectAndCount(httpDuration))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/shortener.Shortener/Expand"}
+
+	resp, err := UnaryServerInterceptor(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "response", resp)
+
+	_, err = UnaryServerInterceptor(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "not found")
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(grpcRequests.WithLabelValues(info.FullMethod, codes.OK.String())))
+	assert.Equal(t, float64(1), testutil.ToFloat64(grpcRequests.WithLabelValues(info.FullMethod, codes.NotFound.String())))
+}
+
+func TestObserveRepository(t *testing.T) {
+	ObserveRepository("memory", "save", time.Now(), false)
+	ObserveRepository("memory", "save", time.Now(), true)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(repositoryErrors.WithLabelValues("memory", "save")))
+	assert.Equal(t, 1, testutil.CollectAndCount(repositoryDuration))
+}
+
+func TestHandler(t *testing.T) {
+	SetBuildInfo("v1.0.0", "2024-03-01", "abc")
+	SetDeleteQueueDepth(1)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	result := recorder.Result()
+	defer result.Body.Close()
+	body, err := io.ReadAll(result.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Contains(t, string(body), `shortener_build_info{commit="abc",date="2024-03-01",version="v1.0.0"} 1`)
+	assert.Contains(t, string(body), "shortener_delete_queue_depth 1")
+	assert.Contains(t, string(body), "go_goroutines")
+}
+
+func TestSetBuildInfoReplacesPrevious(t *testing.T) {
+	SetBuildInfo("v1", "date", "commit")
+	SetBuildInfo("v2", "date", "commit")
+
+	assert.Equal(t, 1, testutil.CollectAndCount(buildInfo))
+}
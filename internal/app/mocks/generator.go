@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,16 +35,16 @@ func (m *MockURLGenerator) EXPECT() *MockURLGeneratorMockRecorder {
 }
 
 // GenerateIDFromString mocks base method.
-func (m *MockURLGenerator) GenerateIDFromString(arg0 string) (string, error) {
+func (m *MockURLGenerator) GenerateIDFromString(arg0 context.Context, arg1 string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateIDFromString", arg0)
+	ret := m.ctrl.Call(m, "GenerateIDFromString", arg0, arg1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateIDFromString indicates an expected call of GenerateIDFromString.
-func (mr *MockURLGeneratorMockRecorder) GenerateIDFromString(arg0 interface{}) *gomock.Call {
+func (mr *MockURLGeneratorMockRecorder) GenerateIDFromString(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateIDFromString", reflect.TypeOf((*MockURLGenerator)(nil).GenerateIDFromString), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateIDFromString", reflect.TypeOf((*MockURLGenerator)(nil).GenerateIDFromString), arg0, arg1)
 }
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/belamov/ypgo-url-shortener/internal/app/models"
 	gomock "github.com/golang/mock/gomock"
@@ -49,6 +50,21 @@ func (mr *MockRepositoryMockRecorder) Check(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockRepository)(nil).Check), arg0)
 }
 
+// Click mocks base method.
+func (m *MockRepository) Click(arg0 context.Context, arg1 string) (models.ShortURL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Click", arg0, arg1)
+	ret0, _ := ret[0].(models.ShortURL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Click indicates an expected call of Click.
+func (mr *MockRepositoryMockRecorder) Click(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Click", reflect.TypeOf((*MockRepository)(nil).Click), arg0, arg1)
+}
+
 // Close mocks base method.
 func (m *MockRepository) Close(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -63,12 +79,28 @@ func (mr *MockRepositoryMockRecorder) Close(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRepository)(nil).Close), arg0)
 }
 
+// DeleteExpired mocks base method.
+func (m *MockRepository) DeleteExpired(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockRepositoryMockRecorder) DeleteExpired(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockRepository)(nil).DeleteExpired), arg0, arg1)
+}
+
 // DeleteUrls mocks base method.
-func (m *MockRepository) DeleteUrls(arg0 context.Context, arg1 []models.ShortURL) error {
+func (m *MockRepository) DeleteUrls(arg0 context.Context, arg1 []models.ShortURL) ([]models.DeleteResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteUrls", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]models.DeleteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteUrls indicates an expected call of DeleteUrls.
@@ -92,6 +124,36 @@ func (mr *MockRepositoryMockRecorder) GetByID(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockRepository)(nil).GetByID), arg0, arg1)
 }
 
+// GetClickStats mocks base method.
+func (m *MockRepository) GetClickStats(arg0 context.Context, arg1 string) (models.ClickStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClickStats", arg0, arg1)
+	ret0, _ := ret[0].(models.ClickStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClickStats indicates an expected call of GetClickStats.
+func (mr *MockRepositoryMockRecorder) GetClickStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClickStats", reflect.TypeOf((*MockRepository)(nil).GetClickStats), arg0, arg1)
+}
+
+// GetStats mocks base method.
+func (m *MockRepository) GetStats(arg0 context.Context, arg1 models.StatsQuery) (models.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", arg0, arg1)
+	ret0, _ := ret[0].(models.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockRepositoryMockRecorder) GetStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockRepository)(nil).GetStats), arg0, arg1)
+}
+
 // GetUsersAndUrlsCount mocks base method.
 func (m *MockRepository) GetUsersAndUrlsCount(arg0 context.Context) (int, int, error) {
 	m.ctrl.T.Helper()
@@ -109,18 +171,48 @@ func (mr *MockRepositoryMockRecorder) GetUsersAndUrlsCount(arg0 interface{}) *go
 }
 
 // GetUsersUrls mocks base method.
-func (m *MockRepository) GetUsersUrls(arg0 context.Context, arg1 string) ([]models.ShortURL, error) {
+func (m *MockRepository) GetUsersUrls(arg0 context.Context, arg1 string, arg2 models.UsersURLsQuery) ([]models.ShortURL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsersUrls", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetUsersUrls", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]models.ShortURL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsersUrls indicates an expected call of GetUsersUrls.
-func (mr *MockRepositoryMockRecorder) GetUsersUrls(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUsersUrls(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersUrls", reflect.TypeOf((*MockRepository)(nil).GetUsersUrls), arg0, arg1, arg2)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockRepository) PurgeDeleted(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockRepositoryMockRecorder) PurgeDeleted(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersUrls", reflect.TypeOf((*MockRepository)(nil).GetUsersUrls), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockRepository)(nil).PurgeDeleted), arg0, arg1)
+}
+
+// RestoreUrls mocks base method.
+func (m *MockRepository) RestoreUrls(arg0 context.Context, arg1 []models.ShortURL, arg2 time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUrls", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUrls indicates an expected call of RestoreUrls.
+func (mr *MockRepositoryMockRecorder) RestoreUrls(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUrls", reflect.TypeOf((*MockRepository)(nil).RestoreUrls), arg0, arg1, arg2)
 }
 
 // Save mocks base method.
@@ -150,3 +242,17 @@ func (mr *MockRepositoryMockRecorder) SaveBatch(arg0, arg1 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatch", reflect.TypeOf((*MockRepository)(nil).SaveBatch), arg0, arg1)
 }
+
+// SaveClickEvents mocks base method.
+func (m *MockRepository) SaveClickEvents(arg0 context.Context, arg1 []models.ClickEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveClickEvents", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveClickEvents indicates an expected call of SaveClickEvents.
+func (mr *MockRepositoryMockRecorder) SaveClickEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveClickEvents", reflect.TypeOf((*MockRepository)(nil).SaveClickEvents), arg0, arg1)
+}
@@ -36,9 +36,12 @@ func (m *MockShortenerInterface) EXPECT() *MockShortenerInterfaceMockRecorder {
 }
 
 // DeleteUrls mocks base method.
-func (m *MockShortenerInterface) DeleteUrls(arg0 context.Context, arg1 []string, arg2 string) {
+func (m *MockShortenerInterface) DeleteUrls(arg0 context.Context, arg1 []string, arg2 string) (models.DeleteJob, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "DeleteUrls", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "DeleteUrls", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.DeleteJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteUrls indicates an expected call of DeleteUrls.
@@ -62,6 +65,21 @@ func (mr *MockShortenerInterfaceMockRecorder) Expand(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expand", reflect.TypeOf((*MockShortenerInterface)(nil).Expand), arg0, arg1)
 }
 
+// ExpandWithPassword mocks base method.
+func (m *MockShortenerInterface) ExpandWithPassword(arg0 context.Context, arg1, arg2, arg3 string) (models.ShortURL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpandWithPassword", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(models.ShortURL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpandWithPassword indicates an expected call of ExpandWithPassword.
+func (mr *MockShortenerInterfaceMockRecorder) ExpandWithPassword(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpandWithPassword", reflect.TypeOf((*MockShortenerInterface)(nil).ExpandWithPassword), arg0, arg1, arg2, arg3)
+}
+
 // FormatShortURL mocks base method.
 func (m *MockShortenerInterface) FormatShortURL(arg0 string) string {
 	m.ctrl.T.Helper()
@@ -90,42 +108,73 @@ func (mr *MockShortenerInterfaceMockRecorder) GenerateNewUserID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateNewUserID", reflect.TypeOf((*MockShortenerInterface)(nil).GenerateNewUserID))
 }
 
+// GetClickStats mocks base method.
+func (m *MockShortenerInterface) GetClickStats(arg0 context.Context, arg1, arg2 string) (models.ClickStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClickStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.ClickStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClickStats indicates an expected call of GetClickStats.
+func (mr *MockShortenerInterfaceMockRecorder) GetClickStats(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClickStats", reflect.TypeOf((*MockShortenerInterface)(nil).GetClickStats), arg0, arg1, arg2)
+}
+
+// GetDeleteJob mocks base method.
+func (m *MockShortenerInterface) GetDeleteJob(arg0 context.Context, arg1, arg2 string) (models.DeleteJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeleteJob", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.DeleteJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeleteJob indicates an expected call of GetDeleteJob.
+func (mr *MockShortenerInterfaceMockRecorder) GetDeleteJob(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeleteJob", reflect.TypeOf((*MockShortenerInterface)(nil).GetDeleteJob), arg0, arg1, arg2)
+}
+
 // GetStats mocks base method.
-func (m *MockShortenerInterface) GetStats(arg0 context.Context) (models.Stats, error) {
+func (m *MockShortenerInterface) GetStats(arg0 context.Context, arg1 models.StatsQuery) (models.Stats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetStats", arg0)
+	ret := m.ctrl.Call(m, "GetStats", arg0, arg1)
 	ret0, _ := ret[0].(models.Stats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetStats indicates an expected call of GetStats.
-func (mr *MockShortenerInterfaceMockRecorder) GetStats(arg0 interface{}) *gomock.Call {
+func (mr *MockShortenerInterfaceMockRecorder) GetStats(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockShortenerInterface)(nil).GetStats), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockShortenerInterface)(nil).GetStats), arg0, arg1)
 }
 
 // GetUrlsCreatedBy mocks base method.
-func (m *MockShortenerInterface) GetUrlsCreatedBy(arg0 context.Context, arg1 string) ([]models.ShortURL, error) {
+func (m *MockShortenerInterface) GetUrlsCreatedBy(arg0 context.Context, arg1 string, arg2 models.UsersURLsQuery) (models.UsersURLsPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUrlsCreatedBy", arg0, arg1)
-	ret0, _ := ret[0].([]models.ShortURL)
+	ret := m.ctrl.Call(m, "GetUrlsCreatedBy", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.UsersURLsPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUrlsCreatedBy indicates an expected call of GetUrlsCreatedBy.
-func (mr *MockShortenerInterfaceMockRecorder) GetUrlsCreatedBy(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockShortenerInterfaceMockRecorder) GetUrlsCreatedBy(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUrlsCreatedBy", reflect.TypeOf((*MockShortenerInterface)(nil).GetUrlsCreatedBy), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUrlsCreatedBy", reflect.TypeOf((*MockShortenerInterface)(nil).GetUrlsCreatedBy), arg0, arg1, arg2)
 }
 
 // HealthCheck mocks base method.
-func (m *MockShortenerInterface) HealthCheck(arg0 context.Context) error {
+func (m *MockShortenerInterface) HealthCheck(arg0 context.Context) (models.Health, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "HealthCheck", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(models.Health)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // HealthCheck indicates an expected call of HealthCheck.
@@ -134,23 +183,50 @@ func (mr *MockShortenerInterfaceMockRecorder) HealthCheck(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockShortenerInterface)(nil).HealthCheck), arg0)
 }
 
+// RecordClick mocks base method.
+func (m *MockShortenerInterface) RecordClick(arg0 models.ClickEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordClick", arg0)
+}
+
+// RecordClick indicates an expected call of RecordClick.
+func (mr *MockShortenerInterfaceMockRecorder) RecordClick(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordClick", reflect.TypeOf((*MockShortenerInterface)(nil).RecordClick), arg0)
+}
+
+// RestoreUrls mocks base method.
+func (m *MockShortenerInterface) RestoreUrls(arg0 context.Context, arg1 []string, arg2 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUrls", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUrls indicates an expected call of RestoreUrls.
+func (mr *MockShortenerInterfaceMockRecorder) RestoreUrls(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUrls", reflect.TypeOf((*MockShortenerInterface)(nil).RestoreUrls), arg0, arg1, arg2)
+}
+
 // Shorten mocks base method.
-func (m *MockShortenerInterface) Shorten(arg0 context.Context, arg1, arg2 string) (models.ShortURL, error) {
+func (m *MockShortenerInterface) Shorten(arg0 context.Context, arg1, arg2 string, arg3 models.ShortenOptions) (models.ShortURL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Shorten", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "Shorten", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(models.ShortURL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Shorten indicates an expected call of Shorten.
-func (mr *MockShortenerInterfaceMockRecorder) Shorten(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockShortenerInterfaceMockRecorder) Shorten(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shorten", reflect.TypeOf((*MockShortenerInterface)(nil).Shorten), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shorten", reflect.TypeOf((*MockShortenerInterface)(nil).Shorten), arg0, arg1, arg2, arg3)
 }
 
 // ShortenBatch mocks base method.
-func (m *MockShortenerInterface) ShortenBatch(arg0 context.Context, arg1 []models.ShortURL, arg2 string) ([]models.ShortURL, error) {
+func (m *MockShortenerInterface) ShortenBatch(arg0 context.Context, arg1 []models.ShortenBatchItem, arg2 string) ([]models.ShortURL, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ShortenBatch", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]models.ShortURL)
@@ -0,0 +1,28 @@
+package models
+
+// DeleteJobStatus is state of asynchronous deletion of urls.
+type DeleteJobStatus string
+
+const (
+	DeleteJobPending DeleteJobStatus = "pending" // urls are waiting in the queue
+	DeleteJobDone    DeleteJobStatus = "done"    // urls are processed, results are known
+	DeleteJobFailed  DeleteJobStatus = "failed"  // storage failed, urls may be left undeleted
+)
+
+// DeleteResult is outcome of deletion of one url of the job.
+type DeleteResult string
+
+const (
+	DeleteResultDeleted        DeleteResult = "deleted"         // url is deleted by this job
+	DeleteResultAlreadyDeleted DeleteResult = "already_deleted" // url was deleted before this job, it's left as is
+	DeleteResultNotOwned       DeleteResult = "not_owned"       // url was created by another user, it's left as is
+	DeleteResultNotFound       DeleteResult = "not_found"       // there is no url with the id
+)
+
+// DeleteJob is deletion of urls requested by user in one request.
+type DeleteJob struct {
+	Results map[string]DeleteResult `json:"results,omitempty"` // results by id of url, known only when job is done
+	ID      string                  `json:"id"`
+	UserID  string                  `json:"-"`
+	Status  DeleteJobStatus         `json:"status"`
+}
@@ -0,0 +1,20 @@
+// Package models contains business models description.
+package models
+
+// Health describes state of the service and its storage.
+type Health struct {
+	Pool   *PoolStats `json:"pool,omitempty"` // statistics of storage connection pool, if storage uses one
+	Status string     `json:"status"`         // ok when storage is reachable
+}
+
+// PoolStats is a snapshot of storage connection pool statistics.
+type PoolStats struct {
+	AcquireDuration      string `json:"acquire_duration"`       // total time spent waiting for connections
+	AcquireCount         int64  `json:"acquire_count"`          // count of successful acquires
+	CanceledAcquireCount int64  `json:"canceled_acquire_count"` // count of acquires canceled by context or acquire timeout
+	EmptyAcquireCount    int64  `json:"empty_acquire_count"`    // count of acquires that had to wait for a free connection
+	AcquiredConns        int32  `json:"acquired_conns"`         // connections currently in use
+	IdleConns            int32  `json:"idle_conns"`             // connections currently idle
+	TotalConns           int32  `json:"total_conns"`            // all open connections
+	MaxConns             int32  `json:"max_conns"`              // maximum size of the pool
+}
@@ -6,9 +6,43 @@ import "time"
 // ShortURL is main entity for system.
 // ❗TODO: список главных структур handlers.Handler - services.Shortener - models.ShortURL
 type ShortURL struct {
-	DeletedAt     time.Time `json:"deleted_at"`     // is used to mark a record as deleted
-	OriginalURL   string    `json:"url"`            // original URL that was shortened
-	ID            string    `json:"id"`             // unique ID of the short URL.
-	CreatedByID   string    `json:"created_by"`     // ID of the user who created the short URL
-	CorrelationID string    `json:"correlation_id"` // CorrelationID is used for matching original and shorten urls in shorten batch operation
+	CreatedAt     time.Time `json:"created_at"`              // moment the url was saved, set by storage
+	DeletedAt     time.Time `json:"deleted_at"`              // is used to mark a record as deleted
+	ExpiresAt     time.Time `json:"expires_at"`              // url stops working at this moment, zero time means never
+	OriginalURL   string    `json:"url"`                     // original URL that was shortened
+	ID            string    `json:"id"`                      // unique ID of the short URL.
+	CreatedByID   string    `json:"created_by"`              // ID of the user who created the short URL
+	CorrelationID string    `json:"correlation_id"`          // CorrelationID is used for matching original and shorten urls in shorten batch operation
+	UniqueScope   string    `json:"unique_scope,omitempty"`  // original url can be shortened once within scope, empty scope is global
+	MaxClicks     int       `json:"max_clicks,omitempty"`    // url stops working after this count of redirects, zero means never
+	Clicks        int       `json:"clicks,omitempty"`        // count of redirects, counted only for urls with MaxClicks
+	PasswordHash  string    `json:"password_hash,omitempty"` // salted bcrypt hash of password that protects url, empty if url isn't protected
+}
+
+// RemainingClicks returns count of redirects left before url stops working.
+// Urls without click limit report false.
+func (url ShortURL) RemainingClicks() (int, bool) {
+	if url.MaxClicks == 0 {
+		return 0, false
+	}
+	if url.Clicks >= url.MaxClicks {
+		return 0, true
+	}
+	return url.MaxClicks - url.Clicks, true
+}
+
+// ShortenOptions are optional attributes of url being shortened.
+type ShortenOptions struct {
+	ExpiresAt time.Time     // url stops working at this moment, zero time means never
+	Alias     string        // custom id of short url, id is generated when alias is empty
+	TTL       time.Duration // url stops working after this duration, can't be used along with ExpiresAt
+	Password  string        // plaintext password that protects url, empty means no protection
+	MaxClicks int           // url stops working after this count of redirects, zero means never
+}
+
+// ShortenBatchItem is url of batch being shortened along with its options.
+type ShortenBatchItem struct {
+	Options       ShortenOptions
+	OriginalURL   string
+	CorrelationID string
 }
@@ -1,8 +1,96 @@
 // Package models contains business models description.
 package models
 
-// Stats is a struct with two fields, UrlsCount and UsersCount, both of which are integers.
+import "time"
+
+// Stats is statistics of the service for internal dashboards.
 type Stats struct {
-	UrlsCount  int `json:"urls"`  // The number of URLs that have been shortened
-	UsersCount int `json:"users"` // The number of registered users
+	Created          []PeriodCount  `json:"created"`      // counts of urls created in periods of requested window, in order of periods
+	TopCreators      []CreatorCount `json:"top_creators"` // users that created most urls
+	TopURLs          []URLClicks    `json:"top_urls"`     // urls with most clicks
+	UrlsCount        int            `json:"urls"`         // The number of URLs that have been shortened, deleted ones included
+	UsersCount       int            `json:"users"`        // The number of registered users
+	ActiveUrlsCount  int            `json:"active_urls"`  // count of urls that aren't deleted
+	DeletedUrlsCount int            `json:"deleted_urls"` // count of deleted urls, expired urls are deleted by sweeper
+}
+
+// StatsGranularity is length of periods in which created urls are counted.
+type StatsGranularity string
+
+const (
+	StatsByDay  StatsGranularity = "day"
+	StatsByHour StatsGranularity = "hour"
+)
+
+// Duration returns length of period, zero for unknown granularity.
+func (granularity StatsGranularity) Duration() time.Duration {
+	switch granularity {
+	case StatsByDay:
+		return 24 * time.Hour //nolint:gomnd
+	case StatsByHour:
+		return time.Hour
+	default:
+		return 0
+	}
+}
+
+// PeriodStart returns start of period in UTC that contains t.
+func (granularity StatsGranularity) PeriodStart(t time.Time) time.Time {
+	return t.UTC().Truncate(granularity.Duration())
+}
+
+// StatsQuery defines window and size of aggregates in Stats.
+type StatsQuery struct {
+	From        time.Time        // start of window of created urls, inclusive
+	To          time.Time        // end of window of created urls, exclusive
+	Granularity StatsGranularity // length of periods in window
+	Top         int              // size of top lists
+}
+
+// PeriodCount is count of urls created in the period.
+type PeriodCount struct {
+	Start time.Time `json:"start"` // start of period in UTC
+	Count int       `json:"count"`
+}
+
+// CreatorCount is count of urls created by user.
+type CreatorCount struct {
+	UserID string `json:"user_id"`
+	Count  int    `json:"count"`
+}
+
+// URLClicks is count of clicks of url.
+type URLClicks struct {
+	ID     string `json:"id"`
+	Clicks int    `json:"clicks"`
+}
+
+// ClickDayLayout is format of days in ClickStats, days are in UTC.
+const ClickDayLayout = "2006-01-02"
+
+// ClickEvent is a redirect by short url.
+type ClickEvent struct {
+	Time      time.Time `json:"time"`                 // moment of the redirect
+	URLID     string    `json:"url_id"`               // id of short url
+	Referrer  string    `json:"referrer,omitempty"`   // page that linked to short url
+	UserAgent string    `json:"user_agent,omitempty"` // user agent of client
+	IPPrefix  string    `json:"ip_prefix,omitempty"`  // network of client ip, full ip isn't stored
+}
+
+// VisitorKey identifies visitor of url: clients from the same network with the same user agent are the same visitor.
+func (event ClickEvent) VisitorKey() string {
+	return event.IPPrefix + " " + event.UserAgent
+}
+
+// ClickStats is click statistics of short url.
+type ClickStats struct {
+	Daily          []DailyClicks `json:"daily"`           // counts of clicks by day in order of days
+	Total          int           `json:"total"`           // count of all clicks
+	UniqueVisitors int           `json:"unique_visitors"` // count of unique visitors, see ClickEvent.VisitorKey
+}
+
+// DailyClicks is count of clicks in one day.
+type DailyClicks struct {
+	Date   string `json:"date"` // day in ClickDayLayout
+	Clicks int    `json:"clicks"`
 }
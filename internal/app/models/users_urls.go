@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when cursor of urls page can't be parsed.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// URLStatus selects urls of user by deletion.
+type URLStatus string
+
+const (
+	URLsActive  URLStatus = "active"  // urls that aren't deleted, expired urls included
+	URLsDeleted URLStatus = "deleted" // deleted urls only
+	URLsAll     URLStatus = "all"     // both active and deleted urls
+)
+
+// SortOrder is order of urls of user by creation.
+type SortOrder string
+
+const (
+	SortOldestFirst SortOrder = "asc"
+	SortNewestFirst SortOrder = "desc"
+)
+
+// URLCursor is position of url in urls of user ordered by creation time and id,
+// page that starts at cursor contains urls after it.
+type URLCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// CursorOf returns position of url.
+func CursorOf(url ShortURL) URLCursor {
+	return URLCursor{CreatedAt: url.CreatedAt.UTC(), ID: url.ID}
+}
+
+// String encodes cursor to opaque string, see ParseURLCursor.
+func (cursor URLCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + cursor.ID))
+}
+
+// Precedes reports whether cursor is listed before other in order.
+func (cursor URLCursor) Precedes(other URLCursor, order SortOrder) bool {
+	if order == SortNewestFirst {
+		cursor, other = other, cursor
+	}
+	if !cursor.CreatedAt.Equal(other.CreatedAt) {
+		return cursor.CreatedAt.Before(other.CreatedAt)
+	}
+	return cursor.ID < other.ID
+}
+
+// ParseURLCursor decodes cursor encoded by URLCursor.String.
+// Returned error wraps ErrInvalidCursor.
+func ParseURLCursor(s string) (URLCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return URLCursor{}, ErrInvalidCursor
+	}
+	createdAt, id, found := strings.Cut(string(decoded), " ")
+	if !found || id == "" {
+		return URLCursor{}, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return URLCursor{}, ErrInvalidCursor
+	}
+	return URLCursor{CreatedAt: t, ID: id}, nil
+}
+
+// UsersURLsQuery selects page of urls of user.
+type UsersURLsQuery struct {
+	CreatedFrom time.Time  // start of creation window, inclusive, zero time means no start
+	CreatedTo   time.Time  // end of creation window, exclusive, zero time means no end
+	After       *URLCursor // page starts after url at cursor, nil means the first page
+	Status      URLStatus  // empty status selects all urls
+	Search      string     // substring of original url, case sensitive
+	Order       SortOrder  // empty order means oldest first
+	Limit       int        // maximum count of urls in page, zero means no limit
+}
+
+// Matches reports whether url satisfies filters of query, cursor and limit aren't checked.
+func (query UsersURLsQuery) Matches(url ShortURL) bool {
+	deleted := !url.DeletedAt.IsZero()
+	if query.Status == URLsActive && deleted || query.Status == URLsDeleted && !deleted {
+		return false
+	}
+	if !query.CreatedFrom.IsZero() && url.CreatedAt.Before(query.CreatedFrom) {
+		return false
+	}
+	if !query.CreatedTo.IsZero() && !url.CreatedAt.Before(query.CreatedTo) {
+		return false
+	}
+	return strings.Contains(url.OriginalURL, query.Search)
+}
+
+// UsersURLsPage is page of urls of user.
+type UsersURLsPage struct {
+	Next *URLCursor // cursor of the next page, nil on the last page
+	URLs []ShortURL
+}
@@ -2,12 +2,16 @@ package pb
 
 import (
 	"context"
+	"errors"
 
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-func (s *GRPCServer) DeleteUrls(ctx context.Context, r *DeleteUrlsRequest) (*Empty, error) {
+// DeleteUrls queues deletion of urls of user and returns pending job, its status is returned by GetDeleteJob.
+func (s *GRPCServer) DeleteUrls(ctx context.Context, r *DeleteUrlsRequest) (*DeleteJobResponse, error) {
 	if r.GetUserId() == "" {
 		return nil, status.Error(codes.InvalidArgument, `user_id required`)
 	}
@@ -17,7 +21,69 @@ func (s *GRPCServer) DeleteUrls(ctx context.Context, r *DeleteUrlsRequest) (*Emp
 		return nil, status.Error(codes.InvalidArgument, `invalid user_id`)
 	}
 
-	s.service.DeleteUrls(ctx, r.GetUrlIds(), userID)
+	job, err := s.service.DeleteUrls(ctx, r.GetUrlIds(), userID)
+	if errors.Is(err, services.ErrDeleteQueueFull) || errors.Is(err, services.ErrDeletionsStopped) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return deleteJobResponse(job), nil
+}
+
+// GetDeleteJob returns status of delete job of user and, when job is done, results by id of url.
+func (s *GRPCServer) GetDeleteJob(ctx context.Context, r *GetDeleteJobRequest) (*DeleteJobResponse, error) {
+	if r.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, `user_id required`)
+	}
+
+	userID, err := s.decodeAndDecrypt(r.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, `invalid user_id`)
+	}
+
+	job, err := s.service.GetDeleteJob(ctx, r.GetJobId(), userID)
+	if errors.Is(err, services.ErrDeleteJobNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return deleteJobResponse(job), nil
+}
+
+func deleteJobResponse(job models.DeleteJob) *DeleteJobResponse {
+	var results map[string]string
+	if len(job.Results) > 0 {
+		results = make(map[string]string, len(job.Results))
+		for id, result := range job.Results {
+			results[id] = string(result)
+		}
+	}
+	return &DeleteJobResponse{
+		JobId:   job.ID,
+		Status:  string(job.Status),
+		Results: results,
+	}
+}
+
+// RestoreUrls restores deleted urls of user and returns ids of restored urls.
+func (s *GRPCServer) RestoreUrls(ctx context.Context, r *RestoreUrlsRequest) (*RestoreUrlsResponse, error) {
+	if r.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, `user_id required`)
+	}
+
+	userID, err := s.decodeAndDecrypt(r.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, `invalid user_id`)
+	}
+
+	restored, err := s.service.RestoreUrls(ctx, r.GetUrlIds(), userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
 
-	return &Empty{}, err
+	return &RestoreUrlsResponse{UrlIds: restored}, nil
 }
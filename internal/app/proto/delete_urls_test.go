@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"errors"
 
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -61,10 +63,18 @@ func (s *ShortenTestSuite) TestDeleteUrlsWithValidUserID() {
 	}
 
 	s.mockCrypto.EXPECT().Decrypt(decoded).Return([]byte(userID), nil)
-	s.mockService.EXPECT().DeleteUrls(gomock.Any(), urlIds, userID)
+	s.mockService.EXPECT().DeleteUrls(gomock.Any(), urlIds, userID).Return(models.DeleteJob{
+		ID:     "job",
+		UserID: userID,
+		Status: models.DeleteJobPending,
+	}, nil)
 
-	_, err = s.client.DeleteUrls(context.Background(), request)
+	response, err := s.client.DeleteUrls(context.Background(), request)
 	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), "job", response.GetJobId())
+	assert.Equal(s.T(), string(models.DeleteJobPending), response.GetStatus())
+	assert.Empty(s.T(), response.GetResults())
 }
 
 func (s *ShortenTestSuite) TestDeleteUrlsWithoutUserID() {
@@ -83,3 +93,109 @@ func (s *ShortenTestSuite) TestDeleteUrlsWithoutUserID() {
 
 	assert.Equal(s.T(), codes.InvalidArgument, grpcErr.Code())
 }
+
+func (s *ShortenTestSuite) TestDeleteUrlsWithFullQueue() {
+	userID := "encrypted id"
+	encoded := hex.EncodeToString([]byte(userID))
+	decoded, err := hex.DecodeString(encoded)
+	require.NoError(s.T(), err)
+
+	urlIds := []string{"id1", "id2"}
+	request := &DeleteUrlsRequest{
+		UrlIds: urlIds,
+		UserId: encoded,
+	}
+
+	s.mockCrypto.EXPECT().Decrypt(decoded).Return([]byte(userID), nil)
+	s.mockService.EXPECT().DeleteUrls(gomock.Any(), urlIds, userID).Return(models.DeleteJob{}, services.ErrDeleteQueueFull)
+
+	response, err := s.client.DeleteUrls(context.Background(), request)
+	require.Error(s.T(), err)
+	assert.Nil(s.T(), response)
+
+	grpcErr, ok := status.FromError(err)
+	require.True(s.T(), ok)
+
+	assert.Equal(s.T(), codes.Unavailable, grpcErr.Code())
+}
+
+func (s *ShortenTestSuite) TestGetDeleteJob() {
+	userID := "encrypted id"
+	encoded := hex.EncodeToString([]byte(userID))
+	decoded, err := hex.DecodeString(encoded)
+	require.NoError(s.T(), err)
+
+	s.mockCrypto.EXPECT().Decrypt(decoded).Return([]byte(userID), nil)
+	s.mockService.EXPECT().GetDeleteJob(gomock.Any(), "job", userID).Return(models.DeleteJob{
+		Results: map[string]models.DeleteResult{
+			"id1": models.DeleteResultDeleted,
+			"id2": models.DeleteResultNotOwned,
+		},
+		ID:     "job",
+		UserID: userID,
+		Status: models.DeleteJobDone,
+	}, nil)
+
+	response, err := s.client.GetDeleteJob(context.Background(), &GetDeleteJobRequest{UserId: encoded, JobId: "job"})
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), "job", response.GetJobId())
+	assert.Equal(s.T(), string(models.DeleteJobDone), response.GetStatus())
+	assert.Equal(s.T(), map[string]string{"id1": "deleted", "id2": "not_owned"}, response.GetResults())
+}
+
+func (s *ShortenTestSuite) TestGetMissingDeleteJob() {
+	userID := "encrypted id"
+	encoded := hex.EncodeToString([]byte(userID))
+	decoded, err := hex.DecodeString(encoded)
+	require.NoError(s.T(), err)
+
+	s.mockCrypto.EXPECT().Decrypt(decoded).Return([]byte(userID), nil)
+	s.mockService.EXPECT().GetDeleteJob(gomock.Any(), "missing", userID).Return(models.DeleteJob{}, services.ErrDeleteJobNotFound)
+
+	response, err := s.client.GetDeleteJob(context.Background(), &GetDeleteJobRequest{UserId: encoded, JobId: "missing"})
+	require.Error(s.T(), err)
+	assert.Nil(s.T(), response)
+
+	grpcErr, ok := status.FromError(err)
+	require.True(s.T(), ok)
+
+	assert.Equal(s.T(), codes.NotFound, grpcErr.Code())
+}
+
+func (s *ShortenTestSuite) TestGetDeleteJobWithoutUserID() {
+	response, err := s.client.GetDeleteJob(context.Background(), &GetDeleteJobRequest{JobId: "job"})
+	require.Error(s.T(), err)
+	assert.Nil(s.T(), response)
+
+	grpcErr, ok := status.FromError(err)
+	require.True(s.T(), ok)
+
+	assert.Equal(s.T(), codes.InvalidArgument, grpcErr.Code())
+}
+
+func (s *ShortenTestSuite) TestRestoreUrls() {
+	userID := "encrypted id"
+	encoded := hex.EncodeToString([]byte(userID))
+	decoded, err := hex.DecodeString(encoded)
+	require.NoError(s.T(), err)
+
+	s.mockCrypto.EXPECT().Decrypt(decoded).Return([]byte(userID), nil)
+	s.mockService.EXPECT().RestoreUrls(gomock.Any(), []string{"id1", "id2"}, userID).Return([]string{"id2"}, nil)
+
+	response, err := s.client.RestoreUrls(context.Background(), &RestoreUrlsRequest{UserId: encoded, UrlIds: []string{"id1", "id2"}})
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), []string{"id2"}, response.GetUrlIds())
+}
+
+func (s *ShortenTestSuite) TestRestoreUrlsWithoutUserID() {
+	response, err := s.client.RestoreUrls(context.Background(), &RestoreUrlsRequest{UrlIds: []string{"id1"}})
+	require.Error(s.T(), err)
+	assert.Nil(s.T(), response)
+
+	grpcErr, ok := status.FromError(err)
+	require.True(s.T(), ok)
+
+	assert.Equal(s.T(), codes.InvalidArgument, grpcErr.Code())
+}
@@ -2,8 +2,15 @@ package pb
 
 import (
 	"context"
+	"errors"
+	"net"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -12,20 +19,53 @@ func (s *GRPCServer) Expand(ctx context.Context, r *ExpandRequest) (*ExpandRespo
 	if urlID == "" {
 		return nil, status.Error(codes.InvalidArgument, "url_id is required")
 	}
-	shortURL, err := s.service.Expand(ctx, urlID)
-	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
 
-	if shortURL.OriginalURL == "" {
+	var shortURL models.ShortURL
+	var err error
+	if r.GetPassword() != "" {
+		shortURL, err = s.service.ExpandWithPassword(ctx, urlID, r.GetPassword(), clientAddr(ctx))
+	} else {
+		shortURL, err = s.service.Expand(ctx, urlID)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
 		return nil, status.Error(codes.NotFound, "url id is not found")
 	}
-
-	if !shortURL.DeletedAt.IsZero() {
-		return nil, status.Error(codes.NotFound, "url is deleted")
+	if errors.Is(err, storage.ErrDeleted) {
+		return nil, status.Error(codes.FailedPrecondition, "url is deleted")
+	}
+	if errors.Is(err, storage.ErrExpired) {
+		return nil, status.Error(codes.FailedPrecondition, "url is expired")
+	}
+	if errors.Is(err, storage.ErrClickLimitReached) {
+		return nil, status.Error(codes.FailedPrecondition, "url click limit is reached")
+	}
+	if errors.Is(err, services.ErrPasswordRequired) {
+		return nil, status.Error(codes.Unauthenticated, "password is required")
+	}
+	if errors.Is(err, services.ErrWrongPassword) {
+		return nil, status.Error(codes.PermissionDenied, "wrong password")
+	}
+	if errors.Is(err, services.ErrTooManyAttempts) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	return &ExpandResponse{
 		FullUrl: shortURL.OriginalURL,
 	}, nil
 }
+
+// clientAddr returns ip address of client that sent request, it's used for limiting password attempts.
+func clientAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
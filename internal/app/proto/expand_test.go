@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -62,7 +64,7 @@ func (s *ShortenTestSuite) TestExpandUnexpectedError() {
 func (s *ShortenTestSuite) TestExpandNotFound() {
 	request := &ExpandRequest{UrlId: "id"}
 
-	s.mockService.EXPECT().Expand(gomock.Any(), request.UrlId).Return(models.ShortURL{}, nil)
+	s.mockService.EXPECT().Expand(gomock.Any(), request.UrlId).Return(models.ShortURL{}, storage.ErrNotFound)
 
 	response, err := s.client.Expand(context.Background(), request)
 	require.Error(s.T(), err)
@@ -78,7 +80,7 @@ func (s *ShortenTestSuite) TestExpandNotFound() {
 func (s *ShortenTestSuite) TestExpandDeleted() {
 	request := &ExpandRequest{UrlId: "id"}
 
-	s.mockService.EXPECT().Expand(gomock.Any(), request.UrlId).Return(models.ShortURL{DeletedAt: time.Now(), OriginalURL: "url"}, nil)
+	s.mockService.EXPECT().Expand(gomock.Any(), request.UrlId).Return(models.ShortURL{DeletedAt: time.Now(), OriginalURL: "url"}, storage.ErrDeleted)
 
 	response, err := s.client.Expand(context.Background(), request)
 	require.Error(s.T(), err)
@@ -88,5 +90,75 @@ func (s *ShortenTestSuite) TestExpandDeleted() {
 	grpcErr, ok := status.FromError(err)
 	require.True(s.T(), ok)
 
-	assert.Equal(s.T(), codes.NotFound, grpcErr.Code())
+	assert.Equal(s.T(), codes.FailedPrecondition, grpcErr.Code())
+}
+
+func (s *ShortenTestSuite) TestExpandExpired() {
+	request := &ExpandRequest{UrlId: "id"}
+
+	s.mockService.EXPECT().Expand(gomock.Any(), request.UrlId).Return(models.ShortURL{ExpiresAt: time.Now(), OriginalURL: "url"}, storage.ErrExpired)
+
+	response, err := s.client.Expand(context.Background(), request)
+	require.Error(s.T(), err)
+
+	assert.Nil(s.T(), response)
+
+	grpcErr, ok := status.FromError(err)
+	require.True(s.T(), ok)
+
+	assert.Equal(s.T(), codes.FailedPrecondition, grpcErr.Code())
+}
+
+func (s *ShortenTestSuite) TestExpandClickLimitReached() {
+	request := &ExpandRequest{UrlId: "id"}
+
+	s.mockService.EXPECT().Expand(gomock.Any(), request.UrlId).Return(models.ShortURL{MaxClicks: 1, Clicks: 1, OriginalURL: "url"}, storage.ErrClickLimitReached)
+
+	response, err := s.client.Expand(context.Background(), request)
+	require.Error(s.T(), err)
+
+	assert.Nil(s.T(), response)
+
+	grpcErr, ok := status.FromError(err)
+	require.True(s.T(), ok)
+
+	assert.Equal(s.T(), codes.FailedPrecondition, grpcErr.Code())
+}
+
+func (s *ShortenTestSuite) TestExpandWithPassword() {
+	request := &ExpandRequest{UrlId: "id", Password: "secret"}
+
+	s.mockService.EXPECT().ExpandWithPassword(gomock.Any(), request.UrlId, "secret", gomock.Any()).Return(models.ShortURL{OriginalURL: "url"}, nil)
+
+	response, err := s.client.Expand(context.Background(), request)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "url", response.FullUrl)
+}
+
+func (s *ShortenTestSuite) TestExpandPasswordErrors() {
+	tests := []struct {
+		err      error
+		password string
+		code     codes.Code
+	}{
+		{err: services.ErrPasswordRequired, code: codes.Unauthenticated},
+		{err: services.ErrWrongPassword, password: "wrong", code: codes.PermissionDenied},
+		{err: services.ErrTooManyAttempts, password: "wrong", code: codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		request := &ExpandRequest{UrlId: "id", Password: tt.password}
+		if tt.password == "" {
+			s.mockService.EXPECT().Expand(gomock.Any(), request.UrlId).Return(models.ShortURL{}, tt.err)
+		} else {
+			s.mockService.EXPECT().ExpandWithPassword(gomock.Any(), request.UrlId, tt.password, gomock.Any()).Return(models.ShortURL{}, tt.err)
+		}
+
+		response, err := s.client.Expand(context.Background(), request)
+		require.Error(s.T(), err)
+		assert.Nil(s.T(), response)
+
+		grpcErr, ok := status.FromError(err)
+		require.True(s.T(), ok)
+		assert.Equal(s.T(), tt.code, grpcErr.Code())
+	}
 }
@@ -6,10 +6,13 @@ import (
 	"net"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/logging"
+	"github.com/belamov/ypgo-url-shortener/internal/app/metrics"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services/crypto"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
+	zlog "github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 )
 
@@ -48,6 +51,8 @@ func NewGRPCServer(
 			grpc_recovery.StreamServerInterceptor(),
 		)),
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
+			metrics.UnaryServerInterceptor,
+			logging.UnaryServerInterceptor(zlog.Logger),
 			grpc_recovery.UnaryServerInterceptor(),
 		)),
 	)
@@ -3,8 +3,10 @@ package pb
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services"
 	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -24,12 +26,21 @@ func (s *GRPCServer) Shorten(ctx context.Context, r *ShortenRequest) (*Shortenin
 		userID = s.service.GenerateNewUserID()
 	}
 
-	shortURL, err := s.service.Shorten(ctx, r.Url, userID)
+	shortURL, err := s.service.Shorten(ctx, r.Url, userID, models.ShortenOptions{
+		ExpiresAt: unixTime(r.GetExpiresAt()),
+		Alias:     r.GetAlias(),
+		TTL:       time.Duration(r.GetTtlSeconds()) * time.Second,
+		Password:  r.GetPassword(),
+		MaxClicks: int(r.GetMaxClicks()),
+	})
 	var notUniqueErr *storage.NotUniqueURLError
 	if errors.As(err, &notUniqueErr) {
 		// we cannot return "conflict" status with response, response becomes nil for client
 		return s.newShorteningResponse(shortURL, ""), nil
 	}
+	if errOptions := optionsError(err); errOptions != nil {
+		return nil, errOptions
+	}
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -37,6 +48,29 @@ func (s *GRPCServer) Shorten(ctx context.Context, r *ShortenRequest) (*Shortenin
 	return s.newShorteningResponse(shortURL, userID), nil
 }
 
+// optionsError returns status error for errors of shorten options (custom alias, expiration and click limit)
+// and nil for other errors.
+func optionsError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlias),
+		errors.Is(err, services.ErrInvalidExpiration),
+		errors.Is(err, services.ErrInvalidMaxClicks):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrAliasTaken), errors.Is(err, services.ErrAliasConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return nil
+	}
+}
+
+// unixTime converts unix time in seconds to time, zero seconds are converted to zero time.
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
 func (s *GRPCServer) newShorteningResponse(shortURL models.ShortURL, userID string) *ShorteningResponse {
 	return &ShorteningResponse{
 		ResultUrl: s.service.FormatShortURL(shortURL.ID),
@@ -2,8 +2,12 @@ package pb
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/responses"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -18,19 +22,35 @@ func (s *GRPCServer) ShortenBatch(ctx context.Context, r *ShortenBatchRequest) (
 		userID = s.service.GenerateNewUserID()
 	}
 
-	batch := make([]models.ShortURL, len(r.GetUrls()))
+	batch := make([]models.ShortenBatchItem, len(r.GetUrls()))
 
 	for i, url := range r.GetUrls() {
 		if url.OriginalUrl == "" {
 			return nil, status.Error(codes.InvalidArgument, `full_url required`)
 		}
-		batch[i] = models.ShortURL{
+		batch[i] = models.ShortenBatchItem{
 			OriginalURL:   url.OriginalUrl,
 			CorrelationID: url.CorrelationId,
+			Options: models.ShortenOptions{
+				ExpiresAt: unixTime(url.GetExpiresAt()),
+				Alias:     url.GetAlias(),
+				TTL:       time.Duration(url.GetTtlSeconds()) * time.Second,
+				Password:  url.GetPassword(),
+				MaxClicks: int(url.GetMaxClicks()),
+			},
 		}
 	}
 
 	shortURLBatches, err := s.service.ShortenBatch(ctx, batch, userID)
+	var existing map[int]models.ShortURL
+	var notUniqueErr *storage.NotUniqueBatchError
+	if errors.As(err, &notUniqueErr) {
+		existing = notUniqueErr.Existing
+		err = nil
+	}
+	if errOptions := optionsError(err); errOptions != nil {
+		return nil, errOptions
+	}
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -40,6 +60,10 @@ func (s *GRPCServer) ShortenBatch(ctx context.Context, r *ShortenBatchRequest) (
 		res[i] = &ShortenBatchItemResponse{
 			CorrelationId: shortURLBatch.CorrelationID,
 			ResultUrl:     s.service.FormatShortURL(shortURLBatch.ID),
+			Status:        responses.BatchItemCreated,
+		}
+		if _, ok := existing[i]; ok {
+			res[i].Status = responses.BatchItemExists
 		}
 	}
 
@@ -6,6 +6,8 @@ import (
 	"errors"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/responses"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -135,6 +137,40 @@ func (s *ShortenTestSuite) TestShortenBatchWithValidUserID() {
 	}
 }
 
+func (s *ShortenTestSuite) TestShortenBatchWithExistingUrls() {
+	userID := "encrypted"
+	encoded := hex.EncodeToString([]byte(userID))
+	decoded, err := hex.DecodeString(encoded)
+	require.NoError(s.T(), err)
+
+	request := &ShortenBatchRequest{
+		Urls: []*ShortenBatchItemRequest{
+			{OriginalUrl: "url1", CorrelationId: "corId1"},
+			{OriginalUrl: "url2", CorrelationId: "corId2"},
+		},
+		UserId: encoded,
+	}
+
+	result := []models.ShortURL{
+		{OriginalURL: "url1", ID: "id1", CreatedByID: userID, CorrelationID: "corId1"},
+		{OriginalURL: "url2", ID: "existing id", CreatedByID: "another user", CorrelationID: "corId2"},
+	}
+	s.mockCrypto.EXPECT().Decrypt(decoded).Return([]byte(userID), nil)
+	s.mockService.EXPECT().FormatShortURL(result[0].ID).Return(result[0].ID)
+	s.mockService.EXPECT().FormatShortURL(result[1].ID).Return(result[1].ID)
+	s.mockService.EXPECT().ShortenBatch(gomock.Any(), gomock.Any(), userID).
+		Return(result, storage.NewNotUniqueBatchError(map[int]models.ShortURL{1: result[1]}))
+
+	response, err := s.client.ShortenBatch(context.Background(), request)
+	require.NoError(s.T(), err)
+
+	require.Len(s.T(), response.Urls, len(result))
+	assert.Equal(s.T(), "id1", response.Urls[0].ResultUrl)
+	assert.Equal(s.T(), responses.BatchItemCreated, response.Urls[0].Status)
+	assert.Equal(s.T(), "existing id", response.Urls[1].ResultUrl)
+	assert.Equal(s.T(), responses.BatchItemExists, response.Urls[1].Status)
+}
+
 func (s *ShortenTestSuite) TestShortenBatchWithoutUserID() {
 	request := &ShortenBatchRequest{
 		Urls: []*ShortenBatchItemRequest{
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services"
@@ -82,7 +84,7 @@ func (s *ShortenTestSuite) TestRequestWithValidUserID() {
 		CreatedByID: userID,
 	}
 	s.mockCrypto.EXPECT().Decrypt(decoded).Return([]byte(userID), nil)
-	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID).Return(expectedResult, nil)
+	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID, models.ShortenOptions{}).Return(expectedResult, nil)
 	s.mockService.EXPECT().FormatShortURL(expectedResult.ID).Return(expectedResult.ID)
 
 	response, err := s.client.Shorten(context.Background(), request)
@@ -110,7 +112,7 @@ func (s *ShortenTestSuite) TestRequestWithoutUserID() {
 		CreatedByID: userID,
 	}
 	s.mockService.EXPECT().GenerateNewUserID().Return(userID)
-	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID).Return(expectedResult, nil)
+	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID, models.ShortenOptions{}).Return(expectedResult, nil)
 	s.mockService.EXPECT().FormatShortURL(expectedResult.ID).Return(expectedResult.ID)
 
 	response, err := s.client.Shorten(context.Background(), request)
@@ -138,9 +140,9 @@ func (s *ShortenTestSuite) TestRequestAlreadyShorten() {
 		CreatedByID: userID,
 	}
 	s.mockService.EXPECT().GenerateNewUserID().Return(userID)
-	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID).Return(
+	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID, models.ShortenOptions{}).Return(
 		expectedResult,
-		services.NewShorteningError(expectedResult, storage.NewNotUniqueURLError(expectedResult, errors.New(""))),
+		services.NewShorteningError(expectedResult, storage.NewNotUniqueURLError(expectedResult, expectedResult, errors.New(""))),
 	)
 	s.mockService.EXPECT().FormatShortURL(expectedResult.ID).Return(expectedResult.ID)
 
@@ -168,7 +170,7 @@ func (s *ShortenTestSuite) TestUnknownServiceError() {
 		CreatedByID: userID,
 	}
 	s.mockService.EXPECT().GenerateNewUserID().Return(userID)
-	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID).Return(
+	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID, models.ShortenOptions{}).Return(
 		expectedResult,
 		services.NewShorteningError(models.ShortURL{}, errors.New("unexpected error")),
 	)
@@ -183,3 +185,76 @@ func (s *ShortenTestSuite) TestUnknownServiceError() {
 
 	assert.Equal(s.T(), codes.Internal, grpcErr.Code())
 }
+
+func (s *ShortenTestSuite) TestRequestWithAlias() {
+	request := &ShortenRequest{
+		Url:   "url",
+		Alias: "spring-sale",
+	}
+
+	userID := "id"
+	s.mockService.EXPECT().GenerateNewUserID().Return(userID)
+	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID, models.ShortenOptions{Alias: "spring-sale"}).Return(
+		models.ShortURL{OriginalURL: "url", ID: "spring-sale", CreatedByID: userID},
+		nil,
+	)
+	s.mockService.EXPECT().FormatShortURL("spring-sale").Return("http://localhost:8080/spring-sale")
+
+	response, err := s.client.Shorten(context.Background(), request)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "spring-sale", response.UrlId)
+	assert.Equal(s.T(), "http://localhost:8080/spring-sale", response.ResultUrl)
+}
+
+func (s *ShortenTestSuite) TestRequestWithExpiration() {
+	request := &ShortenRequest{
+		Url:        "url",
+		ExpiresAt:  4102444800,
+		TtlSeconds: 60,
+	}
+
+	userID := "id"
+	options := models.ShortenOptions{ExpiresAt: time.Unix(4102444800, 0), TTL: time.Minute}
+	s.mockService.EXPECT().GenerateNewUserID().Return(userID)
+	s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, userID, options).Return(
+		models.ShortURL{},
+		fmt.Errorf("%w: only one of expiration time and ttl can be set", services.ErrInvalidExpiration),
+	)
+
+	response, err := s.client.Shorten(context.Background(), request)
+	require.Error(s.T(), err)
+	assert.Nil(s.T(), response)
+
+	grpcErr, ok := status.FromError(err)
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), codes.InvalidArgument, grpcErr.Code())
+}
+
+func (s *ShortenTestSuite) TestAliasErrors() {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{err: fmt.Errorf("%w: %q is reserved", services.ErrInvalidAlias, "api"), code: codes.InvalidArgument},
+		{err: fmt.Errorf("%w: %q", services.ErrAliasTaken, "api"), code: codes.AlreadyExists},
+		{err: fmt.Errorf("%w: %q", services.ErrAliasConflict, "api"), code: codes.AlreadyExists},
+	}
+	for _, tt := range tests {
+		request := &ShortenRequest{
+			Url:   "url",
+			Alias: "api",
+		}
+
+		s.mockService.EXPECT().GenerateNewUserID().Return("id")
+		s.mockService.EXPECT().Shorten(gomock.Any(), request.Url, "id", models.ShortenOptions{Alias: "api"}).
+			Return(models.ShortURL{}, tt.err)
+
+		response, err := s.client.Shorten(context.Background(), request)
+		require.Error(s.T(), err)
+		assert.Nil(s.T(), response)
+
+		grpcErr, ok := status.FromError(err)
+		require.True(s.T(), ok)
+		assert.Equal(s.T(), tt.code, grpcErr.Code())
+	}
+}
@@ -23,7 +23,9 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ShortenerClient interface {
 	Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShorteningResponse, error)
-	DeleteUrls(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*Empty, error)
+	DeleteUrls(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error)
+	GetDeleteJob(ctx context.Context, in *GetDeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error)
+	RestoreUrls(ctx context.Context, in *RestoreUrlsRequest, opts ...grpc.CallOption) (*RestoreUrlsResponse, error)
 	Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error)
 	ShortenBatch(ctx context.Context, in *ShortenBatchRequest, opts ...grpc.CallOption) (*ShortenBatchResponse, error)
 }
@@ -45,8 +47,8 @@ func (c *shortenerClient) Shorten(ctx context.Context, in *ShortenRequest, opts
 	return out, nil
 }
 
-func (c *shortenerClient) DeleteUrls(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (c *shortenerClient) DeleteUrls(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error) {
+	out := new(DeleteJobResponse)
 	err := c.cc.Invoke(ctx, "/shortener.Shortener/DeleteUrls", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -54,6 +56,24 @@ func (c *shortenerClient) DeleteUrls(ctx context.Context, in *DeleteUrlsRequest,
 	return out, nil
 }
 
+func (c *shortenerClient) GetDeleteJob(ctx context.Context, in *GetDeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error) {
+	out := new(DeleteJobResponse)
+	err := c.cc.Invoke(ctx, "/shortener.Shortener/GetDeleteJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) RestoreUrls(ctx context.Context, in *RestoreUrlsRequest, opts ...grpc.CallOption) (*RestoreUrlsResponse, error) {
+	out := new(RestoreUrlsResponse)
+	err := c.cc.Invoke(ctx, "/shortener.Shortener/RestoreUrls", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *shortenerClient) Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error) {
 	out := new(ExpandResponse)
 	err := c.cc.Invoke(ctx, "/shortener.Shortener/Expand", in, out, opts...)
@@ -77,7 +97,9 @@ func (c *shortenerClient) ShortenBatch(ctx context.Context, in *ShortenBatchRequ
 // for forward compatibility
 type ShortenerServer interface {
 	Shorten(context.Context, *ShortenRequest) (*ShorteningResponse, error)
-	DeleteUrls(context.Context, *DeleteUrlsRequest) (*Empty, error)
+	DeleteUrls(context.Context, *DeleteUrlsRequest) (*DeleteJobResponse, error)
+	GetDeleteJob(context.Context, *GetDeleteJobRequest) (*DeleteJobResponse, error)
+	RestoreUrls(context.Context, *RestoreUrlsRequest) (*RestoreUrlsResponse, error)
 	Expand(context.Context, *ExpandRequest) (*ExpandResponse, error)
 	ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error)
 	mustEmbedUnimplementedShortenerServer()
@@ -90,9 +112,15 @@ type UnimplementedShortenerServer struct {
 func (UnimplementedShortenerServer) Shorten(context.Context, *ShortenRequest) (*ShorteningResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Shorten not implemented")
 }
-func (UnimplementedShortenerServer) DeleteUrls(context.Context, *DeleteUrlsRequest) (*Empty, error) {
+func (UnimplementedShortenerServer) DeleteUrls(context.Context, *DeleteUrlsRequest) (*DeleteJobResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUrls not implemented")
 }
+func (UnimplementedShortenerServer) GetDeleteJob(context.Context, *GetDeleteJobRequest) (*DeleteJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeleteJob not implemented")
+}
+func (UnimplementedShortenerServer) RestoreUrls(context.Context, *RestoreUrlsRequest) (*RestoreUrlsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUrls not implemented")
+}
 func (UnimplementedShortenerServer) Expand(context.Context, *ExpandRequest) (*ExpandResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Expand not implemented")
 }
@@ -148,6 +176,42 @@ func _Shortener_DeleteUrls_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_GetDeleteJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeleteJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetDeleteJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shortener.Shortener/GetDeleteJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetDeleteJob(ctx, req.(*GetDeleteJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_RestoreUrls_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUrlsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).RestoreUrls(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shortener.Shortener/RestoreUrls",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).RestoreUrls(ctx, req.(*RestoreUrlsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Shortener_Expand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ExpandRequest)
 	if err := dec(in); err != nil {
@@ -199,6 +263,14 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUrls",
 			Handler:    _Shortener_DeleteUrls_Handler,
 		},
+		{
+			MethodName: "GetDeleteJob",
+			Handler:    _Shortener_GetDeleteJob_Handler,
+		},
+		{
+			MethodName: "RestoreUrls",
+			Handler:    _Shortener_RestoreUrls_Handler,
+		},
 		{
 			MethodName: "Expand",
 			Handler:    _Shortener_Expand_Handler,
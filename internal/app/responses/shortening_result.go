@@ -1,6 +1,8 @@
 // Package responses contains structs of responses send in http endpoints.
 package responses
 
+import "time"
+
 // ShorteningResult is response with shortened url.
 type ShorteningResult struct {
 	Result string `json:"result"`
@@ -8,12 +10,22 @@ type ShorteningResult struct {
 
 // UsersShortURL is url that was shortened by user.
 type UsersShortURL struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	CreatedAt       time.Time `json:"created_at"`                 // zero time for urls saved before creation time was stored
+	RemainingClicks *int      `json:"remaining_clicks,omitempty"` // count of redirects left, only for urls with click limit
+	ShortURL        string    `json:"short_url"`
+	OriginalURL     string    `json:"original_url"`
+	Deleted         bool      `json:"deleted"`
 }
 
+// Statuses of urls in shortening batch result.
+const (
+	BatchItemCreated = "created" // url was shortened by this request
+	BatchItemExists  = "exists"  // url was shortened before, short url of existing record is returned
+)
+
 // ShorteningBatchResult is shortening result of batch operation.
 type ShorteningBatchResult struct {
 	CorrelationID string `json:"correlation_id"`
 	ShortURL      string `json:"short_url"`
+	Status        string `json:"status"`
 }
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MinAliasLength is minimal length of custom alias.
+	MinAliasLength = 3
+	// MaxAliasLength is maximal length of custom alias, it's limited by size of id column in database.
+	MaxAliasLength = 64
+)
+
+var (
+	// ErrInvalidAlias is returned when custom alias doesn't match alias policy.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrAliasTaken is returned when custom alias is already used by another url.
+	ErrAliasTaken = errors.New("alias is already taken")
+	// ErrAliasConflict is returned along with the stored url when url with custom alias is already shortened
+	// with another id, the alias isn't created then.
+	ErrAliasConflict = errors.New("url is already shortened with another id, alias isn't created")
+)
+
+// aliasPattern is set of characters allowed in aliases.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// reservedAliases are first segments of router paths and names that can be routed in future.
+// Aliases are compared with them case-insensitively.
+var reservedAliases = map[string]bool{
+	"api":     true,
+	"ping":    true,
+	"metrics": true,
+	"debug":   true,
+	"health":  true,
+	"admin":   true,
+	"static":  true,
+}
+
+// ValidateAlias checks that alias matches alias policy: it has from MinAliasLength to MaxAliasLength
+// latin letters, digits, underscores or hyphens, and it isn't reserved.
+// Returned error wraps ErrInvalidAlias.
+func ValidateAlias(alias string) error {
+	if len(alias) < MinAliasLength || len(alias) > MaxAliasLength {
+		return fmt.Errorf("%w: %q must be from %d to %d characters long", ErrInvalidAlias, alias, MinAliasLength, MaxAliasLength)
+	}
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: %q may contain only latin letters, digits, '_' and '-'", ErrInvalidAlias, alias)
+	}
+	if reservedAliases[strings.ToLower(alias)] {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidAlias, alias)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr bool
+	}{
+		{name: "valid alias", alias: "spring-sale"},
+		{name: "alias with digits and underscore", alias: "sale_2024"},
+		{name: "alias of max length", alias: strings.Repeat("a", MaxAliasLength)},
+		{name: "too short alias", alias: "ab", wantErr: true},
+		{name: "too long alias", alias: strings.Repeat("a", MaxAliasLength+1), wantErr: true},
+		{name: "alias with slash", alias: "spring/sale", wantErr: true},
+		{name: "alias with not latin letters", alias: "распродажа", wantErr: true},
+		{name: "reserved alias", alias: "api", wantErr: true},
+		{name: "reserved alias in another case", alias: "PING", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAlias(tt.alias)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAlias)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+)
+
+const (
+	// clickBatchSize is maximum count of click events saved at once.
+	clickBatchSize = 100
+	// clickFlushInterval is how often click events are saved when there are too few of them for a full batch.
+	clickFlushInterval = time.Second
+	// ipv4PrefixBits is size of network prefix of IPv4 client stored with click event.
+	ipv4PrefixBits = 24
+	// ipv6PrefixBits is size of network prefix of IPv6 client stored with click event.
+	ipv6PrefixBits = 48
+)
+
+// RecordClick queues click event for saving by SaveClicks. It never blocks redirect:
+// when the buffer is full, the event is dropped.
+func (service *Shortener) RecordClick(event models.ClickEvent) {
+	select {
+	case service.clicks <- event:
+	default:
+		atomic.AddUint64(&service.droppedClicks, 1)
+	}
+}
+
+// SaveClicks saves queued click events in batches until ctx is canceled,
+// then saves events left in the buffer and returns.
+func (service *Shortener) SaveClicks(ctx context.Context) {
+	ticker := time.NewTicker(clickFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.ClickEvent, 0, clickBatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			service.drainClicks(batch)
+			return
+		case event := <-service.clicks:
+			batch = append(batch, event)
+			if len(batch) < clickBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+		batch = service.saveClicks(ctx, batch)
+	}
+}
+
+// drainClicks saves batch and all the events left in the buffer.
+// Context of SaveClicks is canceled already, so events are saved without it.
+func (service *Shortener) drainClicks(batch []models.ClickEvent) {
+	ctx := context.Background()
+	for {
+		select {
+		case event := <-service.clicks:
+			batch = append(batch, event)
+			if len(batch) == clickBatchSize {
+				batch = service.saveClicks(ctx, batch)
+			}
+		default:
+			service.saveClicks(ctx, batch)
+			return
+		}
+	}
+}
+
+// saveClicks saves batch of click events and returns the batch emptied for reuse.
+// Events that couldn't be saved are logged and lost, statistics aren't worth blocking redirects.
+func (service *Shortener) saveClicks(ctx context.Context, batch []models.ClickEvent) []models.ClickEvent {
+	if dropped := atomic.SwapUint64(&service.droppedClicks, 0); dropped > 0 {
+		log.Warn().Msgf("%d click events were dropped, buffer is full", dropped)
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := service.repository.SaveClickEvents(ctx, batch); err != nil {
+		log.Error().Err(err).Msgf("couldn't save %d click events", len(batch))
+	}
+	return batch[:0]
+}
+
+// GetClickStats returns click statistics of url with id created by userID, statistics of deleted and expired urls are kept.
+// Urls of other users are reported as storage.ErrNotFound, so their ids aren't disclosed.
+func (service *Shortener) GetClickStats(ctx context.Context, id string, userID string) (models.ClickStats, error) {
+	url, err := service.get(ctx, id)
+	if err != nil && !errors.Is(err, storage.ErrDeleted) && !errors.Is(err, storage.ErrExpired) {
+		return models.ClickStats{}, err
+	}
+	if url.CreatedByID != userID {
+		return models.ClickStats{}, storage.ErrNotFound
+	}
+	return service.repository.GetClickStats(ctx, id)
+}
+
+// IPPrefix returns network of client ip in CIDR notation: /24 for IPv4 and /48 for IPv6.
+// Click events store only the network, it's enough to tell visitors apart. Invalid ip has empty prefix.
+func IPPrefix(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+
+	addr = addr.Unmap()
+	bits := ipv6PrefixBits
+	if addr.Is4() {
+		bits = ipv4PrefixBits
+	}
+
+	prefix, err := addr.WithZone("").Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPPrefix(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "203.0.113.42", want: "203.0.113.0/24"},
+		{ip: "::ffff:203.0.113.42", want: "203.0.113.0/24"},
+		{ip: "2001:db8:1234:5678::1", want: "2001:db8:1234::/48"},
+		{ip: "fe80::1%eth0", want: "fe80::/48"},
+		{ip: "not ip", want: ""},
+		{ip: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, IPPrefix(tt.ip))
+		})
+	}
+}
+
+func TestShortener_RecordClickDoesntBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	event := models.ClickEvent{Time: time.Now(), URLID: "id"}
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().SaveClickEvents(gomock.Any(), []models.ClickEvent{event, event}).Return(nil)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{ClickBufferSize: 2})
+
+	// nothing saves events yet, the third one doesn't fit in the buffer
+	for i := 0; i < 3; i++ {
+		service.RecordClick(event)
+	}
+	assert.Equal(t, uint64(1), service.droppedClicks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.SaveClicks(ctx)
+	assert.Zero(t, service.droppedClicks, "dropped events must be reported on saving")
+	assert.Empty(t, service.clicks, "events left in the buffer must be saved on shutdown")
+}
+
+func TestShortener_SaveClicks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make([]models.ClickEvent, clickBatchSize)
+	for i := range events {
+		events[i] = models.ClickEvent{Time: time.Now(), URLID: "id"}
+	}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().SaveClickEvents(ctx, events).DoAndReturn(func(_ context.Context, _ []models.ClickEvent) error {
+		cancel()
+		return nil
+	})
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{ClickBufferSize: clickBatchSize})
+
+	done := make(chan struct{})
+	go func() {
+		service.SaveClicks(ctx)
+		close(done)
+	}()
+	for _, event := range events {
+		service.RecordClick(event)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(clickFlushInterval / 2):
+		t.Fatal("full batch of events must be saved without waiting for flush interval")
+	}
+}
+
+func TestShortener_GetClickStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stats := models.ClickStats{Daily: []models.DailyClicks{{Date: "2024-03-01", Clicks: 1}}, Total: 1, UniqueVisitors: 1}
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetByID(context.Background(), "id").Return(models.ShortURL{ID: "id", CreatedByID: "user"}, nil).AnyTimes()
+	mockRepo.EXPECT().GetByID(context.Background(), "deleted").Return(models.ShortURL{ID: "deleted", CreatedByID: "user"}, storage.ErrDeleted).AnyTimes()
+	mockRepo.EXPECT().GetByID(context.Background(), "missing").Return(models.ShortURL{}, storage.ErrNotFound).AnyTimes()
+	mockRepo.EXPECT().GetClickStats(context.Background(), "id").Return(stats, nil)
+	mockRepo.EXPECT().GetClickStats(context.Background(), "deleted").Return(stats, nil)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.GetClickStats(context.Background(), "id", "user")
+	require.NoError(t, err)
+	assert.Equal(t, stats, got)
+
+	got, err = service.GetClickStats(context.Background(), "deleted", "user")
+	require.NoError(t, err, "stats of deleted url must be kept")
+	assert.Equal(t, stats, got)
+
+	_, err = service.GetClickStats(context.Background(), "id", "another user")
+	assert.ErrorIs(t, err, storage.ErrNotFound, "stats of url of another user must not be disclosed")
+
+	_, err = service.GetClickStats(context.Background(), "missing", "user")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/logging"
+	"github.com/belamov/ypgo-url-shortener/internal/app/metrics"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultDeleteQueueSize is count of queued delete requests when it's not configured.
+	defaultDeleteQueueSize = 1024
+	// defaultDeleteWorkers is count of delete workers when it's not configured.
+	defaultDeleteWorkers = 4
+	// deleteBatchSize is count of urls after which worker stops taking more requests into one batch.
+	deleteBatchSize = 500
+	// deleteMaxAttempts is count of attempts to delete batch before its urls are given up.
+	deleteMaxAttempts = 5
+	// deleteBackoff is delay before the second attempt, every next delay is twice as long.
+	deleteBackoff = 100 * time.Millisecond
+	// deleteJobTTL is period after finishing during which job status is kept.
+	deleteJobTTL = time.Hour
+	// deleteJobsCleanupSize is count of kept jobs after which outdated jobs are removed.
+	deleteJobsCleanupSize = 1024
+)
+
+var (
+	// ErrDeleteQueueFull is returned when delete request can't be queued because workers fall behind.
+	ErrDeleteQueueFull = errors.New("delete queue is full, try again later")
+	// ErrDeletionsStopped is returned when delete request comes after the service started shutting down.
+	ErrDeletionsStopped = errors.New("deletions are stopped, service is shutting down")
+	// ErrDeleteJobNotFound is returned when there is no job with the id or job was requested by another user.
+	ErrDeleteJobNotFound = errors.New("delete job not found")
+)
+
+// deletion is queued delete request.
+type deletion struct {
+	ctx  context.Context // detached context of request, it carries logger of request and is used only for logging
+	job  string          // id of job of request
+	urls []models.ShortURL
+}
+
+// deleteQueue is bounded queue of delete requests, see Shortener.DeleteUrls.
+type deleteQueue struct {
+	requests chan deletion
+	workers  int
+	backoff  time.Duration // see deleteBackoff
+	jobs     *deleteJobs
+	stopped  bool         // no more requests are accepted, requests is closed
+	mutex    sync.RWMutex // guards stopped, so requests isn't written after closing
+}
+
+func newDeleteQueue(size int, workers int) *deleteQueue {
+	if size <= 0 {
+		size = defaultDeleteQueueSize
+	}
+	if workers <= 0 {
+		workers = defaultDeleteWorkers
+	}
+	return &deleteQueue{
+		requests: make(chan deletion, size),
+		workers:  workers,
+		backoff:  deleteBackoff,
+		jobs:     &deleteJobs{jobs: make(map[string]deleteJobEntry)},
+	}
+}
+
+// deleteJobs keeps statuses of delete jobs, finished jobs are kept for deleteJobTTL.
+type deleteJobs struct {
+	jobs  map[string]deleteJobEntry // jobs by id
+	mutex sync.Mutex
+}
+
+// deleteJobEntry is kept job with time when it was finished, zero for pending job.
+type deleteJobEntry struct {
+	finished time.Time
+	job      models.DeleteJob
+}
+
+// put keeps job, finished at the moment now if job isn't pending.
+func (j *deleteJobs) put(job models.DeleteJob, now time.Time) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if len(j.jobs) >= deleteJobsCleanupSize {
+		for id, entry := range j.jobs {
+			if !entry.finished.IsZero() && now.Sub(entry.finished) >= deleteJobTTL {
+				delete(j.jobs, id)
+			}
+		}
+	}
+
+	entry := deleteJobEntry{job: job}
+	if job.Status != models.DeleteJobPending {
+		entry.finished = now
+	}
+	j.jobs[job.ID] = entry
+}
+
+// get returns job with id that wasn't finished longer than deleteJobTTL before now.
+func (j *deleteJobs) get(id string, now time.Time) (models.DeleteJob, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entry, ok := j.jobs[id]
+	if !ok || (!entry.finished.IsZero() && now.Sub(entry.finished) >= deleteJobTTL) {
+		return models.DeleteJob{}, false
+	}
+	return entry.job, true
+}
+
+// finish sets final status and results of pending job with id at the moment now.
+func (j *deleteJobs) finish(id string, status models.DeleteJobStatus, results map[string]models.DeleteResult, now time.Time) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entry, ok := j.jobs[id]
+	if !ok {
+		return
+	}
+	entry.job.Status = status
+	entry.job.Results = results
+	entry.finished = now
+	j.jobs[id] = entry
+}
+
+// remove forgets job with id.
+func (j *deleteJobs) remove(id string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	delete(j.jobs, id)
+}
+
+// DeleteUrls queues deletion of urls with given ids that was created by userID and returns without waiting for it.
+// Returned pending job tracks the deletion, see GetDeleteJob.
+// Returns ErrDeleteQueueFull when the queue is full and ErrDeletionsStopped after StopDeletions,
+// the request must be repeated later in both cases.
+func (service *Shortener) DeleteUrls(ctx context.Context, ids []string, userID string) (models.DeleteJob, error) {
+	job := models.DeleteJob{
+		ID:     uuid.NewString(),
+		UserID: userID,
+		Status: models.DeleteJobPending,
+	}
+	queue := service.deletions
+	if len(ids) == 0 {
+		job.Status = models.DeleteJobDone
+		job.Results = map[string]models.DeleteResult{}
+		queue.jobs.put(job, time.Now())
+		return job, nil
+	}
+	urls := make([]models.ShortURL, 0, len(ids))
+	for _, id := range ids {
+		urls = append(urls, models.ShortURL{ID: id, CreatedByID: userID})
+	}
+
+	queue.mutex.RLock()
+	defer queue.mutex.RUnlock()
+
+	if queue.stopped {
+		return models.DeleteJob{}, ErrDeletionsStopped
+	}
+	// job is kept before queuing, worker may finish it right away
+	queue.jobs.put(job, time.Now())
+	select {
+	case queue.requests <- deletion{ctx: logging.Detach(ctx), job: job.ID, urls: urls}:
+		metrics.SetDeleteQueueDepth(len(queue.requests))
+		return job, nil
+	default:
+		queue.jobs.remove(job.ID)
+		return models.DeleteJob{}, ErrDeleteQueueFull
+	}
+}
+
+// GetDeleteJob returns job with id requested by userID.
+// Returns ErrDeleteJobNotFound for unknown job, job of another user and job that was finished longer than an hour ago.
+func (service *Shortener) GetDeleteJob(_ context.Context, id string, userID string) (models.DeleteJob, error) {
+	job, ok := service.deletions.jobs.get(id, time.Now())
+	if !ok || job.UserID != userID {
+		return models.DeleteJob{}, ErrDeleteJobNotFound
+	}
+	return job, nil
+}
+
+// ProcessDeletions runs workers that delete queued urls in batches.
+// It returns after StopDeletions is called and all the queued requests are processed.
+// When ctx is done, failed batches aren't retried anymore and requests left in the queue fail without deleting,
+// so ctx bounds how long shutdown waits for queued deletions.
+func (service *Shortener) ProcessDeletions(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(service.deletions.workers)
+	for i := 0; i < service.deletions.workers; i++ {
+		go func() {
+			defer wg.Done()
+			service.deleteWorker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// StopDeletions stops accepting delete requests, requests queued before are still processed by ProcessDeletions.
+func (service *Shortener) StopDeletions() {
+	queue := service.deletions
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	if !queue.stopped {
+		queue.stopped = true
+		close(queue.requests)
+	}
+}
+
+// deleteWorker takes queued requests together with requests that are waiting behind them
+// and deletes their urls at once, until the queue is closed and drained.
+func (service *Shortener) deleteWorker(ctx context.Context) {
+	queue := service.deletions
+	for request := range queue.requests {
+		if ctx.Err() != nil {
+			metrics.SetDeleteQueueDepth(len(queue.requests))
+			service.failDeleteJobs([]deletion{request}, ctx.Err())
+			continue
+		}
+
+		batch := []deletion{request}
+		size := len(request.urls)
+	collect:
+		for size < deleteBatchSize {
+			select {
+			case next, ok := <-queue.requests:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, next)
+				size += len(next.urls)
+			default:
+				break collect
+			}
+		}
+		metrics.SetDeleteQueueDepth(len(queue.requests))
+
+		service.deleteBatch(ctx, batch, size)
+	}
+}
+
+// deleteBatch deletes urls of requests in batch, failed attempts are retried with growing delay until ctx is done,
+// and finishes jobs of requests. Failures are logged with loggers of requests.
+func (service *Shortener) deleteBatch(ctx context.Context, batch []deletion, size int) {
+	urls := make([]models.ShortURL, 0, size)
+	for _, request := range batch {
+		urls = append(urls, request.urls...)
+	}
+
+	delay := service.deletions.backoff
+	var err error
+	for attempt := 1; attempt <= deleteMaxAttempts; attempt++ {
+		var results []models.DeleteResult
+		if results, err = service.repository.DeleteUrls(ctx, urls); err == nil {
+			service.finishDeleteJobs(batch, results)
+			return
+		}
+		if attempt == deleteMaxAttempts {
+			break
+		}
+		for _, request := range batch {
+			logging.FromContext(request.ctx).Warn().Err(err).Msgf("couldn't delete %d urls, attempt %d, retrying in %s", len(request.urls), attempt, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			service.failDeleteJobs(batch, ctx.Err())
+			return
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+
+	service.failDeleteJobs(batch, err)
+}
+
+// failDeleteJobs logs error with loggers of requests and marks their jobs as failed.
+func (service *Shortener) failDeleteJobs(batch []deletion, err error) {
+	for _, request := range batch {
+		logging.FromContext(request.ctx).Error().Err(err).Msgf("couldn't delete %d urls", len(request.urls))
+		service.deletions.jobs.finish(request.job, models.DeleteJobFailed, nil, time.Now())
+	}
+}
+
+// finishDeleteJobs sets results of jobs of deleted batch, results are outcomes reported by storage
+// in order of urls of requests in batch. When job has the same id several times, the first outcome is kept.
+func (service *Shortener) finishDeleteJobs(batch []deletion, results []models.DeleteResult) {
+	for _, request := range batch {
+		if len(results) < len(request.urls) {
+			logging.FromContext(request.ctx).Error().Msgf("storage reported %d results of deletion of %d urls", len(results), len(request.urls))
+			service.deletions.jobs.finish(request.job, models.DeleteJobFailed, nil, time.Now())
+			results = nil
+			continue
+		}
+		jobResults := make(map[string]models.DeleteResult, len(request.urls))
+		for i, url := range request.urls {
+			if _, ok := jobResults[url.ID]; !ok {
+				jobResults[url.ID] = results[i]
+			}
+		}
+		results = results[len(request.urls):]
+		service.deletions.jobs.finish(request.job, models.DeleteJobDone, jobResults, time.Now())
+	}
+}
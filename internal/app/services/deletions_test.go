@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortener_DeleteUrlsDoesntBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{DeleteQueueSize: 1})
+
+	// nothing processes the queue yet, the second request doesn't fit in it
+	_, err := service.DeleteUrls(context.Background(), []string{"id1"}, "userID")
+	require.NoError(t, err)
+	_, err = service.DeleteUrls(context.Background(), []string{"id2"}, "userID")
+	assert.ErrorIs(t, err, ErrDeleteQueueFull)
+	assert.Len(t, service.deletions.jobs.jobs, 1, "job of rejected request isn't kept")
+
+	mockRepo.EXPECT().DeleteUrls(gomock.Any(), []models.ShortURL{{ID: "id1", CreatedByID: "userID"}}).Return([]models.DeleteResult{models.DeleteResultDeleted}, nil)
+	service.StopDeletions()
+	_, err = service.DeleteUrls(context.Background(), []string{"id3"}, "userID")
+	assert.ErrorIs(t, err, ErrDeletionsStopped)
+
+	// queued request is deleted after stopping
+	service.ProcessDeletions(context.Background())
+}
+
+func TestShortener_DeleteUrlsBatchesRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().DeleteUrls(gomock.Any(), []models.ShortURL{
+		{ID: "id1", CreatedByID: "user1"},
+		{ID: "id2", CreatedByID: "user1"},
+		{ID: "id3", CreatedByID: "user2"},
+	}).Return([]models.DeleteResult{models.DeleteResultDeleted, models.DeleteResultAlreadyDeleted, models.DeleteResultNotOwned}, nil)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{DeleteWorkers: 1})
+
+	first, err := service.DeleteUrls(context.Background(), []string{"id1", "id2"}, "user1")
+	require.NoError(t, err)
+	second, err := service.DeleteUrls(context.Background(), []string{"id3"}, "user2")
+	require.NoError(t, err)
+
+	service.StopDeletions()
+	service.ProcessDeletions(context.Background())
+
+	// every request of batch gets its own results
+	job, err := service.GetDeleteJob(context.Background(), first.ID, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]models.DeleteResult{"id1": models.DeleteResultDeleted, "id2": models.DeleteResultAlreadyDeleted}, job.Results)
+	job, err = service.GetDeleteJob(context.Background(), second.ID, "user2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]models.DeleteResult{"id3": models.DeleteResultNotOwned}, job.Results)
+}
+
+func TestShortener_DeleteUrlsRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urls := []models.ShortURL{{ID: "id", CreatedByID: "userID"}}
+	mockRepo := mocks.NewMockRepository(ctrl)
+	gomock.InOrder(
+		mockRepo.EXPECT().DeleteUrls(gomock.Any(), urls).Return(nil, errors.New("storage is down")).Times(2),
+		mockRepo.EXPECT().DeleteUrls(gomock.Any(), urls).Return([]models.DeleteResult{models.DeleteResultDeleted}, nil),
+	)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+	service.deletions.backoff = time.Millisecond
+
+	job, err := service.DeleteUrls(context.Background(), []string{"id"}, "userID")
+	require.NoError(t, err)
+	service.StopDeletions()
+	service.ProcessDeletions(context.Background())
+
+	job, err = service.GetDeleteJob(context.Background(), job.ID, "userID")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobDone, job.Status)
+}
+
+func TestShortener_DeleteUrlsGivesUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().DeleteUrls(gomock.Any(), gomock.Any()).Return(nil, errors.New("storage is down")).Times(deleteMaxAttempts)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+	service.deletions.backoff = time.Millisecond
+
+	job, err := service.DeleteUrls(context.Background(), []string{"id"}, "userID")
+	require.NoError(t, err)
+	service.StopDeletions()
+	service.ProcessDeletions(context.Background())
+
+	job, err = service.GetDeleteJob(context.Background(), job.ID, "userID")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+	assert.Empty(t, job.Results)
+}
+
+func TestDeleteJobs(t *testing.T) {
+	jobs := &deleteJobs{jobs: make(map[string]deleteJobEntry)}
+	now := time.Now()
+
+	jobs.put(models.DeleteJob{ID: "pending", Status: models.DeleteJobPending}, now.Add(-2*deleteJobTTL))
+	jobs.put(models.DeleteJob{ID: "done", Status: models.DeleteJobDone}, now.Add(-2*deleteJobTTL))
+	jobs.put(models.DeleteJob{ID: "recent", Status: models.DeleteJobPending}, now)
+	jobs.finish("recent", models.DeleteJobDone, map[string]models.DeleteResult{"id": models.DeleteResultDeleted}, now)
+
+	_, ok := jobs.get("pending", now)
+	assert.True(t, ok, "pending jobs are kept until they are finished")
+	_, ok = jobs.get("done", now)
+	assert.False(t, ok, "outdated jobs are not returned")
+	job, ok := jobs.get("recent", now)
+	require.True(t, ok)
+	assert.Equal(t, models.DeleteJobDone, job.Status)
+	assert.Equal(t, map[string]models.DeleteResult{"id": models.DeleteResultDeleted}, job.Results)
+}
+
+func TestShortener_DeleteUrlsStopsRetryingWhenContextIsDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().DeleteUrls(gomock.Any(), []models.ShortURL{{ID: "id1", CreatedByID: "userID"}}).DoAndReturn(
+		func(_ context.Context, _ []models.ShortURL) ([]models.DeleteResult, error) {
+			cancel()
+			return nil, errors.New("storage is down")
+		})
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{DeleteWorkers: 1})
+	service.deletions.backoff = time.Hour
+
+	first, err := service.DeleteUrls(context.Background(), []string{"id1"}, "userID")
+	require.NoError(t, err)
+	service.StopDeletions()
+
+	done := make(chan struct{})
+	go func() {
+		service.ProcessDeletions(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deletions are still retried after context is done")
+	}
+
+	job, err := service.GetDeleteJob(context.Background(), first.ID, "userID")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+}
+
+func TestShortener_DeleteUrlsFailsQueuedRequestsWhenContextIsDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// storage isn't called at all
+	service := New(mocks.NewMockRepository(ctrl), mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	job, err := service.DeleteUrls(context.Background(), []string{"id"}, "userID")
+	require.NoError(t, err)
+	service.StopDeletions()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.ProcessDeletions(ctx)
+
+	job, err = service.GetDeleteJob(context.Background(), job.ID, "userID")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+}
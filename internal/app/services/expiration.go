@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidExpiration is returned when expiration of url can't be used.
+var ErrInvalidExpiration = errors.New("invalid expiration")
+
+// Expiration returns moment when url expires: absolute expiresAt or ttl counted from now.
+// Zero time is returned when neither of them is set, which means that url never expires.
+// Returned error wraps ErrInvalidExpiration.
+func Expiration(expiresAt time.Time, ttl time.Duration) (time.Time, error) {
+	if !expiresAt.IsZero() && ttl != 0 {
+		return time.Time{}, fmt.Errorf("%w: only one of expiration time and ttl can be set", ErrInvalidExpiration)
+	}
+	if ttl < 0 {
+		return time.Time{}, fmt.Errorf("%w: ttl must be positive", ErrInvalidExpiration)
+	}
+
+	now := time.Now()
+	if ttl > 0 {
+		return now.Add(ttl), nil
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		return time.Time{}, fmt.Errorf("%w: %s is in the past", ErrInvalidExpiration, expiresAt.Format(time.RFC3339))
+	}
+
+	return expiresAt, nil
+}
+
+// SweepExpired deletes expired urls every interval until ctx is done.
+func (service *Shortener) SweepExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			service.deleteExpired(ctx, now)
+		}
+	}
+}
+
+// deleteExpired deletes urls that are expired at the moment now.
+func (service *Shortener) deleteExpired(ctx context.Context, now time.Time) {
+	count, err := service.repository.DeleteExpired(ctx, now)
+	if err != nil {
+		log.Error().Err(err).Msg("couldn't delete expired urls")
+		return
+	}
+	if count > 0 {
+		log.Info().Msgf("deleted %d expired urls", count)
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiration(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		expiresAt time.Time
+		name      string
+		ttl       time.Duration
+		wantErr   bool
+	}{
+		{name: "without expiration"},
+		{name: "absolute expiration", expiresAt: future},
+		{name: "ttl", ttl: time.Minute},
+		{name: "expiration in the past", expiresAt: time.Now().Add(-time.Second), wantErr: true},
+		{name: "negative ttl", ttl: -time.Minute, wantErr: true},
+		{name: "both expiration and ttl", expiresAt: future, ttl: time.Minute, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			got, err := Expiration(tt.expiresAt, tt.ttl)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidExpiration)
+				return
+			}
+			require.NoError(t, err)
+			if tt.ttl > 0 {
+				assert.WithinDuration(t, before.Add(tt.ttl), got, time.Second)
+				return
+			}
+			assert.Equal(t, tt.expiresAt, got)
+		})
+	}
+}
+
+func TestShortener_ShortenWithTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var saved models.ShortURL
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), gomock.Any()).DoAndReturn(func(_ context.Context, url models.ShortURL) error {
+		saved = url
+		return nil
+	})
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{TTL: time.Hour})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), got.ExpiresAt, time.Second)
+	assert.Equal(t, got, saved)
+
+	_, err = service.Shorten(context.Background(), "url", "user", models.ShortenOptions{ExpiresAt: time.Now().Add(-time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidExpiration)
+
+	_, err = service.ShortenBatch(context.Background(), []models.ShortenBatchItem{{OriginalURL: "url", Options: models.ShortenOptions{ExpiresAt: time.Now().Add(-time.Hour)}}}, "user")
+	assert.ErrorIs(t, err, ErrInvalidExpiration)
+}
+
+func TestShortener_SweepExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().DeleteExpired(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, _ time.Time) (int, error) {
+		cancel()
+		return 1, nil
+	})
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	done := make(chan struct{})
+	go func() {
+		service.SweepExpired(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper didn't stop after context was canceled")
+	}
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultAlphabet is alphabet of base62 ids.
+	DefaultAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// NoLookAlikeAlphabet is base62 alphabet without characters that are easily confused: 0, O, o, 1, l and I.
+	NoLookAlikeAlphabet = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	// urlSafeCharacters are characters that can be used in url path without escaping.
+	urlSafeCharacters = DefaultAlphabet + "-._~"
+)
+
+// checkAlphabet checks that alphabet has at least two characters, all of them are unique and url safe.
+func checkAlphabet(alphabet string) error {
+	if len(alphabet) < 2 { //nolint:gomnd
+		return fmt.Errorf("alphabet %q must contain at least 2 characters", alphabet)
+	}
+
+	seen := make(map[rune]bool, len(alphabet))
+	for _, char := range alphabet {
+		if !strings.ContainsRune(urlSafeCharacters, char) {
+			return fmt.Errorf("alphabet %q contains character %q that is not url safe", alphabet, char)
+		}
+		if seen[char] {
+			return fmt.Errorf("alphabet %q contains character %q twice", alphabet, char)
+		}
+		seen[char] = true
+	}
+
+	return nil
+}
+
+// alphabetOrDefault returns DefaultAlphabet for empty alphabet.
+func alphabetOrDefault(alphabet string) string {
+	if alphabet == "" {
+		return DefaultAlphabet
+	}
+	return alphabet
+}
+
+// encodeNumber encodes n in positional numeral system with digits from alphabet,
+// left padded with the zero digit to minLength.
+func encodeNumber(n uint64, alphabet string, minLength int) string {
+	base := uint64(len(alphabet))
+
+	var encoded []byte
+	for {
+		encoded = append(encoded, alphabet[n%base])
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+	for len(encoded) < minLength {
+		encoded = append(encoded, alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+// shuffle permutes alphabet deterministically by salt.
+func shuffle(alphabet []byte, salt []byte) {
+	if len(salt) == 0 {
+		return
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		p += int(salt[v])
+		j := (int(salt[v]) + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+		v++
+	}
+}
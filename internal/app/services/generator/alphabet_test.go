@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkAlphabet(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		wantErr  bool
+	}{
+		{name: "default alphabet", alphabet: DefaultAlphabet},
+		{name: "alphabet without look-alike characters", alphabet: NoLookAlikeAlphabet},
+		{name: "url safe symbols", alphabet: "ab-_.~"},
+		{name: "too short alphabet", alphabet: "a", wantErr: true},
+		{name: "repeated characters", alphabet: "abca", wantErr: true},
+		{name: "not url safe characters", alphabet: "ab/c", wantErr: true},
+		{name: "not ascii characters", alphabet: "abя", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAlphabet(tt.alphabet)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_encodeNumber(t *testing.T) {
+	assert.Equal(t, "0", encodeNumber(0, DefaultAlphabet, 0))
+	assert.Equal(t, "Z", encodeNumber(61, DefaultAlphabet, 0))
+	assert.Equal(t, "10", encodeNumber(62, DefaultAlphabet, 0))
+	assert.Equal(t, "00010", encodeNumber(62, DefaultAlphabet, 5))
+	assert.Equal(t, "babb", encodeNumber(0b1011, "ab", 0))
+}
+
+func Test_shuffle(t *testing.T) {
+	alphabet := []byte(DefaultAlphabet)
+	shuffle(alphabet, []byte("salt"))
+	assert.NotEqual(t, DefaultAlphabet, string(alphabet))
+	assert.ElementsMatch(t, []byte(DefaultAlphabet), alphabet, "shuffle must only permute characters")
+
+	again := []byte(DefaultAlphabet)
+	shuffle(again, []byte("salt"))
+	assert.Equal(t, alphabet, again, "shuffle must be deterministic")
+
+	unsalted := []byte(DefaultAlphabet)
+	shuffle(unsalted, nil)
+	assert.Equal(t, DefaultAlphabet, string(unsalted))
+}
@@ -1,6 +1,7 @@
 package generator // => ./internal/app/services/generator
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"hash/fnv"
@@ -12,7 +13,7 @@ import (
 type HashGenerator struct{}
 
 // GenerateIDFromString создает ID (shortURL) из url.
-func (HashGenerator) GenerateIDFromString(str string) (string, error) {
+func (HashGenerator) GenerateIDFromString(_ context.Context, str string) (string, error) {
 	if str == "" {
 		return "", errors.New("empty string to generate id from")
 	}
@@ -26,6 +27,13 @@ func (HashGenerator) GenerateIDFromString(str string) (string, error) {
 	return result, nil
 }
 
+// EncodeBase62 encodes bytes as a base 62 string. It's used to make ids from random bytes.
+func EncodeBase62(b []byte) string {
+	var i big.Int
+	i.SetBytes(b)
+	return i.Text(62) //nolint:gomnd
+}
+
 // toBase62 converts a 32-bit integer to a base 62 string.
 func toBase62(id uint32) string {
 	var i big.Int
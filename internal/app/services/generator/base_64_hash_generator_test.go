@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -47,7 +48,7 @@ func Test_hashToBase62Generator_GenerateIDdFromString(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ha := HashGenerator{}
-			got, err := ha.GenerateIDFromString(tt.args.str)
+			got, err := ha.GenerateIDFromString(context.Background(), tt.args.str)
 			if !tt.wantErr {
 				require.NoError(t, err)
 			}
@@ -60,9 +61,16 @@ func ExampleHashGenerator_GenerateIDFromString() {
 	hg := HashGenerator{}
 	url := "https://practicum.yandex.ru/"
 
-	id, _ := hg.GenerateIDFromString(url)
+	id, _ := hg.GenerateIDFromString(context.Background(), url)
 	fmt.Println(id)
 
 	// Output:
 	// hWMghaHItOw
 }
+
+func TestEncodeBase62(t *testing.T) {
+	assert.Equal(t, "0", EncodeBase62(nil))
+	assert.Equal(t, "Z", EncodeBase62([]byte{61}))
+	assert.Equal(t, "10", EncodeBase62([]byte{62}))
+	assert.Regexp(t, "^[0-9a-zA-Z]+$", EncodeBase62([]byte{255, 255, 255, 255, 255, 255, 255, 255}))
+}
@@ -0,0 +1,38 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/services/random"
+)
+
+// Names of id generators for config.Config.IDGenerator.
+const (
+	StrategyHash       = "hash"       // HashGenerator
+	StrategyRandom     = "random"     // RandomGenerator
+	StrategySequential = "sequential" // SequentialGenerator
+	StrategyObfuscated = "obfuscated" // ObfuscatedGenerator
+)
+
+// NewFromConfig returns id generator chosen in config.
+// Counter is required by sequential and obfuscated generators, random is required by random generator.
+func NewFromConfig(cfg *config.Config, counter Counter, random random.Generator) (URLGenerator, error) {
+	switch cfg.IDGenerator {
+	case StrategyHash, "":
+		return &HashGenerator{}, nil
+	case StrategyRandom:
+		return NewRandomGenerator(random, cfg.IDAlphabet, cfg.IDLength)
+	case StrategySequential, StrategyObfuscated:
+		if counter == nil {
+			return nil, errors.New("storage doesn't support sequential ids")
+		}
+		if cfg.IDGenerator == StrategySequential {
+			return NewSequentialGenerator(counter, cfg.IDAlphabet)
+		}
+		return NewObfuscatedGenerator(counter, cfg.IDAlphabet, cfg.IDSalt, cfg.IDLength)
+	default:
+		return nil, fmt.Errorf("unknown id generator %q", cfg.IDGenerator)
+	}
+}
@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	tests := []struct {
+		counter Counter
+		want    URLGenerator
+		name    string
+		cfg     config.Config
+		wantErr bool
+	}{
+		{name: "hash by default", cfg: config.Config{}, want: &HashGenerator{}},
+		{name: "hash", cfg: config.Config{IDGenerator: StrategyHash}, want: &HashGenerator{}},
+		{name: "random", cfg: config.Config{IDGenerator: StrategyRandom, IDLength: 8}, want: &RandomGenerator{}},
+		{name: "random with invalid length", cfg: config.Config{IDGenerator: StrategyRandom}, wantErr: true},
+		{name: "sequential", cfg: config.Config{IDGenerator: StrategySequential}, counter: &fakeCounter{}, want: &SequentialGenerator{}},
+		{name: "sequential without counter", cfg: config.Config{IDGenerator: StrategySequential}, wantErr: true},
+		{name: "obfuscated", cfg: config.Config{IDGenerator: StrategyObfuscated, IDSalt: "salt"}, counter: &fakeCounter{}, want: &ObfuscatedGenerator{}},
+		{name: "obfuscated without counter", cfg: config.Config{IDGenerator: StrategyObfuscated}, wantErr: true},
+		{name: "invalid alphabet", cfg: config.Config{IDGenerator: StrategySequential, IDAlphabet: "a"}, counter: &fakeCounter{}, wantErr: true},
+		{name: "unknown generator", cfg: config.Config{IDGenerator: "unknown"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFromConfig(&tt.cfg, tt.counter, &fakeRandom{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, got)
+		})
+	}
+}
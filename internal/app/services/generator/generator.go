@@ -1,7 +1,10 @@
 // Package generator is used for generating hash from string.
 package generator // => ./internal/app/services/generator
 
+import "context"
+
 // interface URLGenerator,поведение (метод)- (base_64_hash_generator)generator.GenerateIDFromString
+// ctx is the context of request, generators that use storage pass it to the storage.
 type URLGenerator interface {
-	GenerateIDFromString(url string) (string, error)
+	GenerateIDFromString(ctx context.Context, url string) (string, error)
 }
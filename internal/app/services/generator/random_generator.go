@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/services/random"
+)
+
+// RandomGenerator generates ids of random characters, so ids don't reveal
+// whether the url was already shortened.
+type RandomGenerator struct {
+	random   random.Generator
+	alphabet string
+	length   int
+}
+
+// NewRandomGenerator creates generator of random ids with length characters from alphabet.
+// Empty alphabet means DefaultAlphabet.
+func NewRandomGenerator(random random.Generator, alphabet string, length int) (*RandomGenerator, error) {
+	alphabet = alphabetOrDefault(alphabet)
+	if err := checkAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	if length <= 0 {
+		return nil, fmt.Errorf("length of random ids must be positive, got %d", length)
+	}
+
+	return &RandomGenerator{
+		random:   random,
+		alphabet: alphabet,
+		length:   length,
+	}, nil
+}
+
+// GenerateIDFromString generates random id, str is used only for validation.
+func (g *RandomGenerator) GenerateIDFromString(_ context.Context, str string) (string, error) {
+	if str == "" {
+		return "", errors.New("empty string to generate id from")
+	}
+
+	// bytes that are not less than limit are skipped, so every character is equally likely
+	limit := 256 - 256%len(g.alphabet)
+	id := make([]byte, 0, g.length)
+	for len(id) < g.length {
+		randomBytes, err := g.random.GenerateRandomBytes(g.length - len(id))
+		if err != nil {
+			return "", err
+		}
+		for _, b := range randomBytes {
+			if int(b) < limit {
+				id = append(id, g.alphabet[int(b)%len(g.alphabet)])
+			}
+		}
+	}
+
+	return string(id), nil
+}
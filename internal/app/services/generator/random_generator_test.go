@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRandom returns predefined bytes one by one.
+type fakeRandom struct {
+	err   error
+	bytes []byte
+}
+
+func (r *fakeRandom) GenerateRandomBytes(size int) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if size > len(r.bytes) {
+		size = len(r.bytes)
+	}
+	b := r.bytes[:size]
+	r.bytes = r.bytes[size:]
+	return b, nil
+}
+
+func (r *fakeRandom) GenerateNewUserID() string {
+	return ""
+}
+
+func TestRandomGenerator_GenerateIDFromString(t *testing.T) {
+	g, err := NewRandomGenerator(&fakeRandom{bytes: []byte{0, 1, 2, 3, 4, 5}}, "abcd", 6)
+	require.NoError(t, err)
+
+	id, err := g.GenerateIDFromString(context.Background(), "url")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdab", id)
+
+	_, err = g.GenerateIDFromString(context.Background(), "")
+	assert.Error(t, err)
+}
+
+func TestRandomGenerator_SkipsBiasedBytes(t *testing.T) {
+	// 256 % 3 = 1, so byte 255 would make the first character more likely
+	g, err := NewRandomGenerator(&fakeRandom{bytes: []byte{255, 1, 255, 2}}, "abc", 2)
+	require.NoError(t, err)
+
+	id, err := g.GenerateIDFromString(context.Background(), "url")
+	require.NoError(t, err)
+	assert.Equal(t, "bc", id)
+}
+
+func TestRandomGenerator_FailsWhenRandomFails(t *testing.T) {
+	g, err := NewRandomGenerator(&fakeRandom{err: errors.New("no entropy")}, "", 8)
+	require.NoError(t, err)
+
+	_, err = g.GenerateIDFromString(context.Background(), "url")
+	assert.Error(t, err)
+}
+
+func TestNewRandomGenerator(t *testing.T) {
+	g, err := NewRandomGenerator(&fakeRandom{}, "", 8)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAlphabet, g.alphabet)
+
+	_, err = NewRandomGenerator(&fakeRandom{}, "a", 8)
+	assert.Error(t, err)
+
+	_, err = NewRandomGenerator(&fakeRandom{}, "", 0)
+	assert.Error(t, err)
+}
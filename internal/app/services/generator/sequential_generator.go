@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"context"
+	"errors"
+)
+
+// Counter returns unique increasing numbers. It's implemented by repositories, see storage.Sequencer.
+type Counter interface {
+	NextSequence(ctx context.Context) (uint64, error)
+}
+
+// SequentialGenerator generates the shortest possible ids by encoding values of the counter.
+type SequentialGenerator struct {
+	counter  Counter
+	alphabet string
+}
+
+// NewSequentialGenerator creates generator that encodes values of counter with characters from alphabet.
+// Empty alphabet means DefaultAlphabet.
+func NewSequentialGenerator(counter Counter, alphabet string) (*SequentialGenerator, error) {
+	alphabet = alphabetOrDefault(alphabet)
+	if err := checkAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+
+	return &SequentialGenerator{
+		counter:  counter,
+		alphabet: alphabet,
+	}, nil
+}
+
+// GenerateIDFromString returns the next value of the counter, str is used only for validation.
+func (g *SequentialGenerator) GenerateIDFromString(ctx context.Context, str string) (string, error) {
+	if str == "" {
+		return "", errors.New("empty string to generate id from")
+	}
+
+	n, err := g.counter.NextSequence(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeNumber(n, g.alphabet, 0), nil
+}
+
+// ObfuscatedGenerator encodes values of the counter like hashids do:
+// every id starts with a lottery character that selects permutation of the salted alphabet
+// which the value is encoded with. Consecutive values get unrelated ids,
+// that can't be enumerated without knowing the salt.
+type ObfuscatedGenerator struct {
+	counter   Counter
+	alphabet  string // alphabet shuffled by salt
+	salt      string
+	minLength int
+}
+
+// NewObfuscatedGenerator creates generator of obfuscated ids that are at least minLength long.
+// Empty alphabet means DefaultAlphabet.
+func NewObfuscatedGenerator(counter Counter, alphabet string, salt string, minLength int) (*ObfuscatedGenerator, error) {
+	alphabet = alphabetOrDefault(alphabet)
+	if err := checkAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+
+	shuffled := []byte(alphabet)
+	shuffle(shuffled, []byte(salt))
+
+	return &ObfuscatedGenerator{
+		counter:   counter,
+		alphabet:  string(shuffled),
+		salt:      salt,
+		minLength: minLength,
+	}, nil
+}
+
+// GenerateIDFromString returns obfuscated next value of the counter, str is used only for validation.
+func (g *ObfuscatedGenerator) GenerateIDFromString(ctx context.Context, str string) (string, error) {
+	if str == "" {
+		return "", errors.New("empty string to generate id from")
+	}
+
+	n, err := g.counter.NextSequence(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return g.encode(n), nil
+}
+
+// encode encodes n with the alphabet permuted by lottery character.
+// Ids of different values differ either in lottery character or in encoded value.
+func (g *ObfuscatedGenerator) encode(n uint64) string {
+	lottery := g.alphabet[n%uint64(len(g.alphabet))]
+
+	alphabet := []byte(g.alphabet)
+	shuffle(alphabet, append([]byte{lottery}, g.salt...))
+
+	return string(lottery) + encodeNumber(n, string(alphabet), g.minLength-1)
+}
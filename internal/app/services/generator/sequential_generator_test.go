@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCounter counts from 1 and fails with err of ctx when ctx is done.
+type fakeCounter struct {
+	err   error
+	value uint64
+}
+
+func (c *fakeCounter) NextSequence(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	c.value++
+	return c.value, c.err
+}
+
+func TestSequentialGenerator_GenerateIDFromString(t *testing.T) {
+	g, err := NewSequentialGenerator(&fakeCounter{value: 59}, "")
+	require.NoError(t, err)
+
+	for _, want := range []string{"Y", "Z", "10", "11"} {
+		id, errGenerate := g.GenerateIDFromString(context.Background(), "url")
+		require.NoError(t, errGenerate)
+		assert.Equal(t, want, id)
+	}
+
+	_, err = g.GenerateIDFromString(context.Background(), "")
+	assert.Error(t, err)
+
+	g, err = NewSequentialGenerator(&fakeCounter{err: errors.New("")}, "")
+	require.NoError(t, err)
+	_, err = g.GenerateIDFromString(context.Background(), "url")
+	assert.Error(t, err)
+}
+
+func TestSequentialGenerator_PassesContextToCounter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g, err := NewSequentialGenerator(&fakeCounter{}, "")
+	require.NoError(t, err)
+	_, err = g.GenerateIDFromString(ctx, "url")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	o, err := NewObfuscatedGenerator(&fakeCounter{}, "", "salt", 0)
+	require.NoError(t, err)
+	_, err = o.GenerateIDFromString(ctx, "url")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSequentialGenerator_CustomAlphabet(t *testing.T) {
+	g, err := NewSequentialGenerator(&fakeCounter{}, NoLookAlikeAlphabet)
+	require.NoError(t, err)
+
+	id, err := g.GenerateIDFromString(context.Background(), "url")
+	require.NoError(t, err)
+	assert.Equal(t, "3", id)
+
+	_, err = NewSequentialGenerator(&fakeCounter{}, "aa")
+	assert.Error(t, err)
+}
+
+func TestObfuscatedGenerator_GenerateIDFromString(t *testing.T) {
+	g, err := NewObfuscatedGenerator(&fakeCounter{}, NoLookAlikeAlphabet, "salt", 6)
+	require.NoError(t, err)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		id, errGenerate := g.GenerateIDFromString(context.Background(), "url")
+		require.NoError(t, errGenerate)
+		require.False(t, seen[id], "id %s is generated twice", id)
+		require.GreaterOrEqual(t, len(id), 6)
+		require.Regexp(t, "^["+NoLookAlikeAlphabet+"]+$", id)
+		seen[id] = true
+	}
+
+	_, err = g.GenerateIDFromString(context.Background(), "")
+	assert.Error(t, err)
+}
+
+func TestObfuscatedGenerator_DependsOnSalt(t *testing.T) {
+	g, err := NewObfuscatedGenerator(&fakeCounter{}, "", "salt", 6)
+	require.NoError(t, err)
+	g2, err := NewObfuscatedGenerator(&fakeCounter{}, "", "another salt", 6)
+	require.NoError(t, err)
+
+	id, err := g.GenerateIDFromString(context.Background(), "url")
+	require.NoError(t, err)
+	id2, err := g2.GenerateIDFromString(context.Background(), "url")
+	require.NoError(t, err)
+	assert.NotEqual(t, id, id2)
+
+	again, err := NewObfuscatedGenerator(&fakeCounter{}, "", "salt", 6)
+	require.NoError(t, err)
+	id3, err := again.GenerateIDFromString(context.Background(), "url")
+	require.NoError(t, err)
+	assert.Equal(t, id, id3, "ids must be deterministic for the same salt")
+}
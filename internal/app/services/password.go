@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MaxPasswordAttempts is count of failed password attempts of one client for one url
+	// after which the client has to wait till the end of PasswordAttemptsWindow.
+	MaxPasswordAttempts = 5
+	// PasswordAttemptsWindow is period in which failed password attempts are counted.
+	PasswordAttemptsWindow = time.Minute
+	// attemptsCleanupSize is count of tracked clients after which outdated attempts are removed.
+	attemptsCleanupSize = 1024
+)
+
+var (
+	// ErrPasswordRequired is returned along with the url when url is protected by password.
+	ErrPasswordRequired = errors.New("password is required")
+	// ErrWrongPassword is returned when password of url doesn't match.
+	ErrWrongPassword = errors.New("wrong password")
+	// ErrTooManyAttempts is returned when client has used up its failed password attempts.
+	ErrTooManyAttempts = errors.New("too many failed password attempts, try again later")
+)
+
+// HashPassword returns salted bcrypt hash of password. Empty password means no protection, its hash is empty too.
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches bcrypt hash.
+func checkPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// attempts counts failed password attempts in fixed windows of PasswordAttemptsWindow.
+type attempts struct {
+	windows map[string]attemptsWindow // failed attempts by key of client and url
+	mutex   sync.Mutex
+}
+
+// attemptsWindow is count of failed attempts since start of the window.
+type attemptsWindow struct {
+	start time.Time
+	count int
+}
+
+func newAttempts() *attempts {
+	return &attempts{windows: make(map[string]attemptsWindow)}
+}
+
+// allowed reports whether key hasn't used up its failed attempts at the moment now.
+func (a *attempts) allowed(key string, now time.Time) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	window, ok := a.windows[key]
+	if !ok {
+		return true
+	}
+	if now.Sub(window.start) >= PasswordAttemptsWindow {
+		delete(a.windows, key)
+		return true
+	}
+	return window.count < MaxPasswordAttempts
+}
+
+// fail counts failed attempt of key at the moment now.
+func (a *attempts) fail(key string, now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if len(a.windows) >= attemptsCleanupSize {
+		for k, window := range a.windows {
+			if now.Sub(window.start) >= PasswordAttemptsWindow {
+				delete(a.windows, k)
+			}
+		}
+	}
+
+	window, ok := a.windows[key]
+	if !ok || now.Sub(window.start) >= PasswordAttemptsWindow {
+		window = attemptsWindow{start: now}
+	}
+	window.count++
+	a.windows[key] = window
+}
+
+// reset forgets failed attempts of key.
+func (a *attempts) reset(key string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	delete(a.windows, key)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	require.NoError(t, err)
+	assert.NotContains(t, hash, "secret")
+	assert.True(t, checkPassword(hash, "secret"))
+	assert.False(t, checkPassword(hash, "Secret"))
+
+	another, err := HashPassword("secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, another, "hashes must be salted")
+
+	empty, err := HashPassword("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestAttempts(t *testing.T) {
+	a := newAttempts()
+	now := time.Now()
+
+	for i := 0; i < MaxPasswordAttempts; i++ {
+		assert.True(t, a.allowed("key", now))
+		a.fail("key", now)
+	}
+	assert.False(t, a.allowed("key", now))
+	assert.True(t, a.allowed("another key", now), "attempts are counted per key")
+	assert.True(t, a.allowed("key", now.Add(PasswordAttemptsWindow)), "attempts are forgotten after window")
+
+	a.fail("key", now)
+	a.reset("key")
+	assert.True(t, a.allowed("key", now))
+}
+
+func TestShortener_ExpandWithPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, err := HashPassword("secret")
+	require.NoError(t, err)
+	protected := models.ShortURL{OriginalURL: "url", ID: "protected", PasswordHash: hash, MaxClicks: 5}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetByID(context.Background(), "protected").Return(protected, nil).AnyTimes()
+	mockRepo.EXPECT().GetByID(context.Background(), "deleted").Return(models.ShortURL{ID: "deleted"}, storage.ErrDeleted).AnyTimes()
+	clicked := protected
+	clicked.Clicks = 1
+	mockRepo.EXPECT().Click(context.Background(), "protected").Return(clicked, nil).Times(1)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.Expand(context.Background(), "protected")
+	assert.ErrorIs(t, err, ErrPasswordRequired, "protected url must not be expanded without password")
+	assert.Equal(t, "protected", got.ID)
+
+	_, err = service.ExpandWithPassword(context.Background(), "protected", "wrong", "client")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	got, err = service.ExpandWithPassword(context.Background(), "protected", "secret", "client")
+	require.NoError(t, err)
+	assert.Equal(t, clicked, got)
+
+	_, err = service.ExpandWithPassword(context.Background(), "deleted", "secret", "client")
+	assert.ErrorIs(t, err, storage.ErrDeleted)
+
+	for i := 0; i < MaxPasswordAttempts; i++ {
+		_, err = service.ExpandWithPassword(context.Background(), "protected", "wrong", "client")
+		assert.ErrorIs(t, err, ErrWrongPassword)
+	}
+	_, err = service.ExpandWithPassword(context.Background(), "protected", "secret", "client")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	_, err = service.ExpandWithPassword(context.Background(), "protected", "wrong", "another client")
+	assert.ErrorIs(t, err, ErrWrongPassword, "attempts of another client must be counted separately")
+}
+
+func TestShortener_ShortenWithPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var saved models.ShortURL
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), gomock.Any()).DoAndReturn(func(_ context.Context, url models.ShortURL) error {
+		saved = url
+		return nil
+	})
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	_, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{Password: "secret"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, saved.PasswordHash)
+	assert.NotEqual(t, "secret", saved.PasswordHash, "password must not be stored in plaintext")
+	assert.True(t, checkPassword(saved.PasswordHash, "secret"))
+}
+
+func TestShortener_ShortenBatchWithPasswordAndTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var saved []models.ShortURL
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().SaveBatch(context.Background(), gomock.Any()).DoAndReturn(func(_ context.Context, batch []models.ShortURL) error {
+		saved = batch
+		return nil
+	})
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url2").Return("id2", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	_, err := service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{OriginalURL: "url", Options: models.ShortenOptions{Password: "secret", TTL: time.Hour}},
+		{OriginalURL: "url2"},
+	}, "user")
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	assert.True(t, checkPassword(saved[0].PasswordHash, "secret"), "password must be stored as hash")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), saved[0].ExpiresAt, time.Second)
+	assert.Empty(t, saved[1].PasswordHash)
+	assert.True(t, saved[1].ExpiresAt.IsZero())
+
+	_, err = service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{OriginalURL: "url", Options: models.ShortenOptions{ExpiresAt: time.Now().Add(time.Hour), TTL: time.Hour}},
+	}, "user")
+	assert.ErrorIs(t, err, ErrInvalidExpiration)
+}
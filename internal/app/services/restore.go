@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/logging"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/rs/zerolog/log"
+)
+
+// RestoreUrls restores urls of user that were deleted within restore grace period.
+// Ids of restored urls are returned, urls of other users, urls that aren't deleted
+// and urls deleted before grace period are skipped.
+func (service *Shortener) RestoreUrls(ctx context.Context, ids []string, userID string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+	urls := make([]models.ShortURL, 0, len(ids))
+	for _, id := range ids {
+		urls = append(urls, models.ShortURL{ID: id, CreatedByID: userID})
+	}
+
+	deletedSince := time.Now().Add(-service.restoreGracePeriod())
+	restored, err := service.repository.RestoreUrls(ctx, urls, deletedSince)
+	if err != nil {
+		return nil, err
+	}
+	if len(restored) > 0 {
+		logging.FromContext(ctx).Info().Msgf("restored %d urls", len(restored))
+	}
+
+	return restored, nil
+}
+
+// PurgeDeleted permanently removes urls deleted before retention window every interval until ctx is done.
+func (service *Shortener) PurgeDeleted(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			service.purgeDeleted(ctx, now)
+		}
+	}
+}
+
+// purgeDeleted permanently removes urls that were deleted earlier than retention window before now.
+func (service *Shortener) purgeDeleted(ctx context.Context, now time.Time) {
+	retention := time.Duration(service.config.DeletedRetention) * time.Second
+	count, err := service.repository.PurgeDeleted(ctx, now.Add(-retention))
+	if err != nil {
+		log.Error().Err(err).Msg("couldn't purge deleted urls")
+		return
+	}
+	if count > 0 {
+		log.Info().Msgf("purged %d deleted urls", count)
+	}
+}
+
+// restoreGracePeriod is how long deleted url can be restored.
+func (service *Shortener) restoreGracePeriod() time.Duration {
+	return time.Duration(service.config.RestoreGracePeriod) * time.Second
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortener_RestoreUrls(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{RestoreGracePeriod: 3600})
+
+	urls := []models.ShortURL{{ID: "a", CreatedByID: "user"}, {ID: "b", CreatedByID: "user"}}
+	mockRepo.EXPECT().RestoreUrls(gomock.Any(), urls, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ []models.ShortURL, deletedSince time.Time) ([]string, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Hour), deletedSince, time.Second)
+			return []string{"b"}, nil
+		})
+
+	restored, err := service.RestoreUrls(context.Background(), []string{"a", "b"}, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, restored)
+
+	restored, err = service.RestoreUrls(context.Background(), nil, "user")
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+
+	mockRepo.EXPECT().RestoreUrls(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("unexpected error"))
+	_, err = service.RestoreUrls(context.Background(), []string{"a"}, "user")
+	assert.Error(t, err)
+}
+
+func TestShortener_PurgeDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().PurgeDeleted(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, deletedBefore time.Time) (int, error) {
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), deletedBefore, time.Second)
+		cancel()
+		return 1, nil
+	})
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{DeletedRetention: 24 * 3600})
+
+	done := make(chan struct{})
+	go func() {
+		service.PurgeDeleted(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purge didn't stop after context was canceled")
+	}
+}
@@ -6,29 +6,48 @@ import (
 	"errors"
 	"fmt"
 
-	"runtime"
-	"sync"
+	"sort"
 	"time"
 
-	"github.com/rs/zerolog/log"
-
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/logging"
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services/generator"
 	"github.com/belamov/ypgo-url-shortener/internal/app/services/random"
 	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
 )
 
+const (
+	// saltedIDAttempts is count of ids generated from salted url when id of url collides with id of another url.
+	saltedIDAttempts = 3
+	// maxIDAttempts is count of attempts to find free id, the attempts after salted ones use random ids.
+	maxIDAttempts = 5
+	// randomIDSize is count of random bytes in random id.
+	randomIDSize = 8
+)
+
+var (
+	// ErrNoFreeID is returned when all the generated ids of url are taken by other urls.
+	ErrNoFreeID = errors.New("can't generate unique id for url")
+	// ErrInvalidMaxClicks is returned when click limit of url is negative.
+	ErrInvalidMaxClicks = errors.New("max clicks must not be negative")
+)
+
 type ShortenerInterface interface {
-	Shorten(ctx context.Context, url string, userID string) (models.ShortURL, error)
+	Shorten(ctx context.Context, url string, userID string, options models.ShortenOptions) (models.ShortURL, error)
 	Expand(ctx context.Context, id string) (models.ShortURL, error)
+	ExpandWithPassword(ctx context.Context, id string, password string, client string) (models.ShortURL, error)
 	FormatShortURL(urlID string) string
-	GetUrlsCreatedBy(ctx context.Context, userID string) ([]models.ShortURL, error)
-	HealthCheck(ctx context.Context) error
-	ShortenBatch(ctx context.Context, batch []models.ShortURL, userID string) ([]models.ShortURL, error)
+	GetUrlsCreatedBy(ctx context.Context, userID string, query models.UsersURLsQuery) (models.UsersURLsPage, error)
+	HealthCheck(ctx context.Context) (models.Health, error)
+	ShortenBatch(ctx context.Context, items []models.ShortenBatchItem, userID string) ([]models.ShortURL, error)
 	GenerateNewUserID() string
-	DeleteUrls(ctx context.Context, ids []string, userID string)
-	GetStats(ctx context.Context) (models.Stats, error)
+	DeleteUrls(ctx context.Context, ids []string, userID string) (models.DeleteJob, error)
+	GetDeleteJob(ctx context.Context, id string, userID string) (models.DeleteJob, error)
+	RestoreUrls(ctx context.Context, ids []string, userID string) ([]string, error)
+	GetStats(ctx context.Context, query models.StatsQuery) (models.Stats, error)
+	RecordClick(event models.ClickEvent)
+	GetClickStats(ctx context.Context, id string, userID string) (models.ClickStats, error)
 }
 
 // Shortener is the main service of the application
@@ -43,8 +62,12 @@ type Shortener struct {
 	// "создает" ID из строки URL
 	generator generator.URLGenerator
 	//
-	Random random.Generator
-	config *config.Config
+	Random        random.Generator
+	config        *config.Config
+	attempts      *attempts              // failed password attempts of clients
+	clicks        chan models.ClickEvent // click events waiting for saving, see SaveClicks
+	droppedClicks uint64                 // count of click events dropped since the last saving, accessed atomically
+	deletions     *deleteQueue           // delete requests waiting for workers, see ProcessDeletions
 }
 
 // New creates new service.
@@ -59,173 +82,335 @@ func New(
 		generator:  generator,
 		config:     config,
 		Random:     random,
+		attempts:   newAttempts(),
+		clicks:     make(chan models.ClickEvent, config.ClickBufferSize),
+		deletions:  newDeleteQueue(config.DeleteQueueSize, config.DeleteWorkers),
 	}
 }
 
-// ShortenBatch добавляет ID в массив URL-адресов.
+// ShortenBatch сокращает пакет URL и возвращает сохраненные записи в порядке пакета.
 // Все записи пакета должны содержать OriginalURL.
-func (service *Shortener) ShortenBatch(ctx context.Context, batch []models.ShortURL, userID string) ([]models.ShortURL, error) {
+// Если часть URL уже существует, они заменяются сохраненными ранее записями
+// и вместе с пакетом возвращается *storage.NotUniqueBatchError.
+// URL, чей ID совпал с ID другого URL, сохраняются повторно с новым ID.
+// Options записей обрабатываются как в Shorten: алиас проверяется ValidateAlias,
+// а если он занят другим URL, возвращается ошибка ErrAliasTaken (остальные записи пакета при этом могут быть сохранены).
+// Если URL с алиасом уже сокращен с другим ID, алиас не создается и возвращается ошибка ErrAliasConflict.
+// Срок действия считается Expiration и не должен быть в прошлом (ErrInvalidExpiration),
+// MaxClicks не может быть отрицательным (ErrInvalidMaxClicks), пароли хранятся только в виде хеша.
+// Пароли хешируются только после проверки всего пакета.
+func (service *Shortener) ShortenBatch(ctx context.Context, items []models.ShortenBatchItem, userID string) ([]models.ShortURL, error) {
+	batch := make([]models.ShortURL, len(items))
+	aliases := make(map[string]int)
+	for i, item := range items {
+		expiresAt, err := Expiration(item.Options.ExpiresAt, item.Options.TTL)
+		if err != nil {
+			return nil, err
+		}
+		if item.Options.MaxClicks < 0 {
+			return nil, ErrInvalidMaxClicks
+		}
+		batch[i] = models.ShortURL{
+			OriginalURL:   item.OriginalURL,
+			CorrelationID: item.CorrelationID,
+			ID:            item.Options.Alias,
+			ExpiresAt:     expiresAt,
+			MaxClicks:     item.Options.MaxClicks,
+		}
+		if item.Options.Alias == "" {
+			continue
+		}
+		if err = ValidateAlias(item.Options.Alias); err != nil {
+			return nil, err
+		}
+		if _, ok := aliases[item.Options.Alias]; ok {
+			return nil, fmt.Errorf("%w: %q is used twice in batch", ErrAliasTaken, item.Options.Alias)
+		}
+		aliases[item.Options.Alias] = i
+	}
+	for i, item := range items {
+		passwordHash, err := HashPassword(item.Options.Password)
+		if err != nil {
+			return nil, err
+		}
+		batch[i].PasswordHash = passwordHash
+	}
+
+	pending := make([]int, 0, len(batch))
 	for i, URL := range batch {
+		batch[i].CreatedByID = userID
+		batch[i].UniqueScope = service.uniqueScope(userID)
+		pending = append(pending, i)
+		if URL.ID != "" {
+			continue
+		}
 		// поле generator (структуры Shortener) типа interface generator.URLGenerator, с поведением GenerateIDFromString
-		urlID, err := service.generator.GenerateIDFromString(URL.OriginalURL)
+		urlID, err := service.generator.GenerateIDFromString(ctx, idSource(batch[i]))
 		if err != nil {
 			return nil, err
 		}
 		batch[i].ID = urlID
-		batch[i].CreatedByID = userID
 	}
 
-	// поле repository (структуры Shortener) типа interface storage.Repository, с поведением SaveBatch
-	if err := service.repository.SaveBatch(ctx, batch); err != nil {
-		return nil, err
+	duplicates := make(map[int]models.ShortURL)
+	for attempt := 1; len(pending) > 0; attempt++ {
+		toSave := make([]models.ShortURL, 0, len(pending))
+		for _, i := range pending {
+			toSave = append(toSave, batch[i])
+		}
+
+		// поле repository (структуры Shortener) типа interface storage.Repository, с поведением SaveBatch
+		err := service.repository.SaveBatch(ctx, toSave)
+		var notUniqueErr *storage.NotUniqueBatchError
+		if err != nil && !errors.As(err, &notUniqueErr) {
+			return nil, err
+		}
+		if err == nil {
+			break
+		}
+
+		var collided []int
+		for j, existing := range notUniqueErr.Existing {
+			i := pending[j]
+			if storage.IsCollision(batch[i], existing) {
+				collided = append(collided, i)
+				continue
+			}
+			if aliasIndex, ok := aliases[batch[i].ID]; ok && aliasIndex == i && existing.ID != batch[i].ID {
+				return nil, fmt.Errorf("%w: %q, url is shortened as %q", ErrAliasConflict, batch[i].ID, existing.ID)
+			}
+			existing.CorrelationID = batch[i].CorrelationID
+			duplicates[i] = existing
+		}
+		sort.Ints(collided)
+
+		for _, i := range collided {
+			if aliasIndex, ok := aliases[batch[i].ID]; ok && aliasIndex == i {
+				return nil, fmt.Errorf("%w: %q", ErrAliasTaken, batch[i].ID)
+			}
+			urlID, errID := service.collisionFreeID(ctx, batch[i], attempt)
+			if errID != nil {
+				return nil, errID
+			}
+			batch[i].ID = urlID
+		}
+		pending = collided
+	}
+
+	if len(duplicates) > 0 {
+		for i, existing := range duplicates {
+			batch[i] = existing
+		}
+		return batch, storage.NewNotUniqueBatchError(duplicates)
 	}
 
 	return batch, nil
 }
 
 // Shorten shortens full url and returns filled struct ShortURL.
-func (service *Shortener) Shorten(ctx context.Context, url string, userID string) (models.ShortURL, error) {
-	urlID, err := service.generator.GenerateIDFromString(url)
+// If the url is already shortened, the stored url is returned along with *storage.NotUniqueURLError.
+// If generated id is taken by another url, the url is saved with another id.
+// Custom alias from options is used as id instead of generated one, it must pass ValidateAlias,
+// ErrAliasTaken is returned when the alias is used by another url, and ErrAliasConflict is returned
+// along with the stored url when the url is already shortened with another id.
+// Url expires at moment calculated by Expiration from options.ExpiresAt and options.TTL
+// and stops working after options.MaxClicks redirects. Url with options.Password is protected by it,
+// only the hash of password is stored.
+func (service *Shortener) Shorten(ctx context.Context, url string, userID string, options models.ShortenOptions) (models.ShortURL, error) {
+	expiresAt, err := Expiration(options.ExpiresAt, options.TTL)
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+	if options.MaxClicks < 0 {
+		return models.ShortURL{}, ErrInvalidMaxClicks
+	}
+	passwordHash, err := HashPassword(options.Password)
 	if err != nil {
 		return models.ShortURL{}, err
 	}
 
 	shortURL := models.ShortURL{
-		OriginalURL: url,
-		ID:          urlID,
-		CreatedByID: userID,
+		OriginalURL:  url,
+		ID:           options.Alias,
+		CreatedByID:  userID,
+		UniqueScope:  service.uniqueScope(userID),
+		ExpiresAt:    expiresAt,
+		MaxClicks:    options.MaxClicks,
+		PasswordHash: passwordHash,
 	}
 
-	err = service.repository.Save(ctx, shortURL)
-	var notUniqueErr *storage.NotUniqueURLError
-	if errors.As(err, &notUniqueErr) {
-		return shortURL, NewShorteningError(shortURL, err)
+	if options.Alias != "" {
+		err = ValidateAlias(options.Alias)
+	} else {
+		shortURL.ID, err = service.generator.GenerateIDFromString(ctx, idSource(shortURL))
 	}
 	if err != nil {
 		return models.ShortURL{}, err
 	}
 
-	return shortURL, nil
-}
+	for attempt := 1; ; attempt++ {
+		err = service.repository.Save(ctx, shortURL)
+		var notUniqueErr *storage.NotUniqueURLError
+		if errors.As(err, &notUniqueErr) && notUniqueErr.IsCollision() && options.Alias != "" {
+			return models.ShortURL{}, fmt.Errorf("%w: %q", ErrAliasTaken, options.Alias)
+		}
+		if errors.As(err, &notUniqueErr) && notUniqueErr.IsCollision() {
+			if shortURL.ID, err = service.collisionFreeID(ctx, shortURL, attempt); err != nil {
+				return models.ShortURL{}, err
+			}
+			continue
+		}
+		if errors.As(err, &notUniqueErr) && options.Alias != "" && notUniqueErr.Existing.ID != options.Alias {
+			return notUniqueErr.Existing, fmt.Errorf("%w: %q, url is shortened as %q", ErrAliasConflict, options.Alias, notUniqueErr.Existing.ID)
+		}
+		if errors.As(err, &notUniqueErr) {
+			if notUniqueErr.Existing.ID != "" {
+				shortURL = notUniqueErr.Existing
+			}
+			return shortURL, NewShorteningError(shortURL, err)
+		}
+		if err != nil {
+			return models.ShortURL{}, err
+		}
 
-// Expand expands full url from given id. Returns filled ShortURL struct.
-func (service *Shortener) Expand(ctx context.Context, id string) (models.ShortURL, error) {
-	origURL, err := service.repository.GetByID(ctx, id)
-	if err != nil {
-		return models.ShortURL{}, err
+		return shortURL, nil
 	}
-	return origURL, nil
 }
 
-// FormatShortURL formats url id to full url.
-func (service *Shortener) FormatShortURL(urlID string) string {
-	return fmt.Sprintf("%s/%s", service.config.BaseURL, urlID)
-}
+// collisionFreeID returns another id for url whose id is taken by another url.
+// First ids are generated from url salted with attempt number, then random ids are used.
+func (service *Shortener) collisionFreeID(ctx context.Context, url models.ShortURL, attempt int) (string, error) {
+	if attempt > maxIDAttempts {
+		return "", ErrNoFreeID
+	}
 
-// GetUrlsCreatedBy returns array of all urs that was shortened by given userID.
-// It's just a wrapper for repository.GetUsersUrls.
-func (service *Shortener) GetUrlsCreatedBy(ctx context.Context, userID string) ([]models.ShortURL, error) {
-	return service.repository.GetUsersUrls(ctx, userID)
+	logging.FromContext(ctx).Warn().Msgf("id of url %s collides with id of another url, attempt %d", url.OriginalURL, attempt)
+
+	if attempt <= saltedIDAttempts {
+		return service.generator.GenerateIDFromString(ctx, fmt.Sprintf("%s#%d", idSource(url), attempt))
+	}
+
+	randomBytes, err := service.Random.GenerateRandomBytes(randomIDSize)
+	if err != nil {
+		return "", err
+	}
+	return generator.EncodeBase62(randomBytes), nil
 }
 
-// HealthCheck checks if service is working correctly
-func (service *Shortener) HealthCheck(ctx context.Context) error {
-	timeout := 5 * time.Second //nolint:gomnd
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-	return service.repository.Check(ctx)
+// uniqueScope returns scope in which urls shortened by user must be unique.
+// Urls are unique globally unless uniqueness is configured per user.
+func (service *Shortener) uniqueScope(userID string) string {
+	if service.config.URLUniqueness == config.UniquenessPerUser {
+		return userID
+	}
+	return ""
 }
 
-// GenerateNewUserID generates new user id.
-// It's just a wrapper for random.GenerateNewUserID().
-func (service *Shortener) GenerateNewUserID() string {
-	return service.Random.GenerateNewUserID()
+// idSource returns string that id of url is generated from.
+// Urls of different scopes get different ids, so the same url shortened by different users doesn't collide.
+func idSource(url models.ShortURL) string {
+	if url.UniqueScope == "" {
+		return url.OriginalURL
+	}
+	return url.UniqueScope + " " + url.OriginalURL
 }
 
-// DeleteUrls deletes all urls with given ids that was created by userID.
-// Implements fan-in and fan-out pattern for learning purposes.
-func (service *Shortener) DeleteUrls(ctx context.Context, ids []string, userID string) {
-	done := make(chan struct{})
-	defer close(done)
+// Expand expands full url from given id and counts the redirect. Returns filled ShortURL struct.
+// Returns storage.ErrNotFound for unknown id and the url along with storage.ErrDeleted,
+// storage.ErrExpired or storage.ErrClickLimitReached for url that doesn't work anymore.
+// Url protected by password is returned along with ErrPasswordRequired, see ExpandWithPassword.
+func (service *Shortener) Expand(ctx context.Context, id string) (models.ShortURL, error) {
+	origURL, err := service.get(ctx, id)
+	if err != nil {
+		return origURL, err
+	}
+	if origURL.PasswordHash != "" {
+		return origURL, ErrPasswordRequired
+	}
+	return service.click(ctx, origURL)
+}
 
-	workersCount := runtime.NumCPU()
-	inputCh := make(chan string)
-	modelsToDelete := make([]models.ShortURL, 0, len(ids))
+// ExpandWithPassword expands url like Expand, but also opens url protected by password.
+// ErrWrongPassword is returned when password doesn't match. Failed attempts are counted for client and url,
+// after MaxPasswordAttempts of them ErrTooManyAttempts is returned without checking password
+// till the end of PasswordAttemptsWindow.
+func (service *Shortener) ExpandWithPassword(ctx context.Context, id string, password string, client string) (models.ShortURL, error) {
+	key := client + " " + id
+	if !service.attempts.allowed(key, time.Now()) {
+		return models.ShortURL{}, ErrTooManyAttempts
+	}
 
-	go func() {
-		for _, id := range ids {
-			inputCh <- id
+	origURL, err := service.get(ctx, id)
+	if err != nil {
+		return origURL, err
+	}
+	if origURL.PasswordHash != "" {
+		if !checkPassword(origURL.PasswordHash, password) {
+			service.attempts.fail(key, time.Now())
+			return models.ShortURL{}, ErrWrongPassword
 		}
-
-		close(inputCh)
-	}()
-
-	workerChs := make([]chan models.ShortURL, 0, workersCount)
-	for urlID := range inputCh {
-		workerCh := make(chan models.ShortURL)
-		newWorker(urlID, userID, workerCh)
-		workerChs = append(workerChs, workerCh)
+		service.attempts.reset(key)
 	}
+	return service.click(ctx, origURL)
+}
 
-	for v := range fanIn(done, workerChs...) {
-		modelsToDelete = append(modelsToDelete, v)
+// get gets url by id. Url that doesn't work anymore is returned along with error.
+func (service *Shortener) get(ctx context.Context, id string) (models.ShortURL, error) {
+	origURL, err := service.repository.GetByID(ctx, id)
+	if errors.Is(err, storage.ErrDeleted) || errors.Is(err, storage.ErrExpired) {
+		return origURL, err
 	}
-
-	err := service.repository.DeleteUrls(ctx, modelsToDelete)
 	if err != nil {
-		fmt.Printf("couldn't delete urls: %v\n", err)
+		return models.ShortURL{}, err
 	}
+	return origURL, nil
 }
 
-func (service *Shortener) GetStats(ctx context.Context) (models.Stats, error) {
-	usersCount, urlsCount, err := service.repository.GetUsersAndUrlsCount(ctx)
+// click counts redirect to url with click limit. Url with used up clicks is returned along with storage.ErrClickLimitReached.
+func (service *Shortener) click(ctx context.Context, origURL models.ShortURL) (models.ShortURL, error) {
+	if origURL.MaxClicks == 0 {
+		return origURL, nil
+	}
+	clicked, err := service.repository.Click(ctx, origURL.ID)
+	if errors.Is(err, storage.ErrDeleted) || errors.Is(err, storage.ErrExpired) || errors.Is(err, storage.ErrClickLimitReached) {
+		return clicked, err
+	}
 	if err != nil {
-		return models.Stats{}, err
+		return models.ShortURL{}, err
 	}
-
-	return models.Stats{UsersCount: usersCount, UrlsCount: urlsCount}, nil
+	return clicked, nil
 }
 
-func newWorker(urlID string, userID string, out chan models.ShortURL) {
-	go func() {
-		defer func() {
-			if x := recover(); x != nil {
-				newWorker(urlID, userID, out)
-				log.Printf("run time panic: %v, %v", x, out)
-			}
-		}()
-
-		out <- models.ShortURL{ID: urlID, CreatedByID: userID}
-		close(out)
-	}()
+// FormatShortURL formats url id to full url.
+func (service *Shortener) FormatShortURL(urlID string) string {
+	return fmt.Sprintf("%s/%s", service.config.BaseURL, urlID)
 }
 
-func fanIn(done <-chan struct{}, channels ...chan models.ShortURL) chan models.ShortURL {
-	var wg sync.WaitGroup
-	multiplexedStream := make(chan models.ShortURL)
+// HealthCheck checks if service is working correctly.
+// Returned health contains pool statistics if repository uses connection pool.
+func (service *Shortener) HealthCheck(ctx context.Context) (models.Health, error) {
+	timeout := 5 * time.Second //nolint:gomnd
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	multiplex := func(c <-chan models.ShortURL) {
-		defer wg.Done()
-		for v := range c {
-			select {
-			case <-done:
-				return
-			case multiplexedStream <- v:
-			}
-		}
+	health := models.Health{Status: "ok"}
+	if provider, ok := storage.Unwrap(service.repository).(storage.PoolStatsProvider); ok {
+		stats := provider.PoolStats()
+		health.Pool = &stats
 	}
 
-	wg.Add(len(channels))
-	for _, c := range channels {
-		go multiplex(c)
+	if err := service.repository.Check(ctx); err != nil {
+		health.Status = "unavailable"
+		return health, err
 	}
 
-	go func() {
-		wg.Wait()
-		close(multiplexedStream)
-	}()
+	return health, nil
+}
 
-	return multiplexedStream
+// GenerateNewUserID generates new user id.
+// It's just a wrapper for random.GenerateNewUserID().
+func (service *Shortener) GenerateNewUserID() string {
+	return service.Random.GenerateNewUserID()
 }
 
 // shorteningError is error wrapper of any error occurred in service.
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
 	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
@@ -23,11 +24,12 @@ func TestShortener_Expand(t *testing.T) {
 	type args struct {
 		id string
 	}
+	deletedAt := time.Now()
 	tests := []struct {
+		wantErr error
 		name    string
 		args    args
 		want    models.ShortURL
-		wantErr bool
 	}{
 		{
 			name: "get full url from id",
@@ -36,13 +38,34 @@ func TestShortener_Expand(t *testing.T) {
 				OriginalURL: "url",
 				ID:          "id",
 			},
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:    "get full url from missing id",
 			args:    args{id: "missing"},
 			want:    models.ShortURL{},
-			wantErr: true,
+			wantErr: storage.ErrNotFound,
+		},
+		{
+			name: "get full url from deleted id",
+			args: args{id: "deleted"},
+			want: models.ShortURL{
+				OriginalURL: "url",
+				ID:          "deleted",
+				DeletedAt:   deletedAt,
+			},
+			wantErr: storage.ErrDeleted,
+		},
+		{
+			name: "get full url from url with used up clicks",
+			args: args{id: "clicked"},
+			want: models.ShortURL{
+				OriginalURL: "url",
+				ID:          "clicked",
+				MaxClicks:   1,
+				Clicks:      1,
+			},
+			wantErr: storage.ErrClickLimitReached,
 		},
 	}
 
@@ -53,7 +76,11 @@ func TestShortener_Expand(t *testing.T) {
 
 			mockRepo := mocks.NewMockRepository(ctrl)
 			mockRepo.EXPECT().GetByID(context.Background(), "id").Return(models.ShortURL{OriginalURL: "url", ID: "id"}, nil).AnyTimes()
-			mockRepo.EXPECT().GetByID(context.Background(), "missing").Return(models.ShortURL{}, errors.New("")).AnyTimes()
+			clicked := models.ShortURL{OriginalURL: "url", ID: "clicked", MaxClicks: 1, Clicks: 1}
+			mockRepo.EXPECT().GetByID(context.Background(), "clicked").Return(clicked, nil).AnyTimes()
+			mockRepo.EXPECT().Click(context.Background(), "clicked").Return(clicked, storage.ErrClickLimitReached).AnyTimes()
+			mockRepo.EXPECT().GetByID(context.Background(), "missing").Return(models.ShortURL{}, storage.ErrNotFound).AnyTimes()
+			mockRepo.EXPECT().GetByID(context.Background(), "deleted").Return(models.ShortURL{OriginalURL: "url", ID: "deleted", DeletedAt: deletedAt}, storage.ErrDeleted).AnyTimes()
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
 
@@ -67,9 +94,7 @@ func TestShortener_Expand(t *testing.T) {
 			service := New(mockRepo, mockGen, mockRandom, cfg)
 
 			got, err := service.Expand(context.Background(), tt.args.id)
-			if !tt.wantErr {
-				assert.NoError(t, err)
-			}
+			assert.ErrorIs(t, err, tt.wantErr)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -131,9 +156,9 @@ func TestShortener_Shorten(t *testing.T) {
 			}).Return(storage.ErrNotUnique).AnyTimes()
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
-			mockGen.EXPECT().GenerateIDFromString("url").Return("id", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("fail").Return("id", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("").Return("", errors.New("")).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "fail").Return("id", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "").Return("", errors.New("")).AnyTimes()
 
 			mockRandom := mocks.NewMockGenerator(ctrl)
 
@@ -144,7 +169,7 @@ func TestShortener_Shorten(t *testing.T) {
 
 			service := New(mockRepo, mockGen, mockRandom, cfg)
 
-			got, err := service.Shorten(context.Background(), tt.args.url, "")
+			got, err := service.Shorten(context.Background(), tt.args.url, "", models.ShortenOptions{})
 			if !tt.wantErr {
 				assert.NoError(t, err)
 			} else {
@@ -161,7 +186,7 @@ func TestShortener_Shorten(t *testing.T) {
 func TestShortener_ShortenBatch(t *testing.T) {
 	type args struct {
 		userID string
-		batch  []models.ShortURL
+		batch  []models.ShortenBatchItem
 	}
 	tests := []struct {
 		name    string
@@ -172,7 +197,7 @@ func TestShortener_ShortenBatch(t *testing.T) {
 		{
 			name: "short batch urls",
 			args: args{
-				batch: []models.ShortURL{
+				batch: []models.ShortenBatchItem{
 					{CorrelationID: "corID", OriginalURL: "origURL"},
 					{CorrelationID: "corID2", OriginalURL: "origURL2"},
 				},
@@ -186,7 +211,7 @@ func TestShortener_ShortenBatch(t *testing.T) {
 		{
 			name: "short batch urls failed on saving",
 			args: args{
-				batch: []models.ShortURL{
+				batch: []models.ShortenBatchItem{
 					{CorrelationID: "corID", OriginalURL: "errorURL"},
 				},
 			},
@@ -210,9 +235,9 @@ func TestShortener_ShortenBatch(t *testing.T) {
 			}).Return(errors.New("")).AnyTimes()
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
-			mockGen.EXPECT().GenerateIDFromString("origURL").Return("id", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("origURL2").Return("id2", nil).AnyTimes()
-			mockGen.EXPECT().GenerateIDFromString("errorURL").Return("", errors.New("")).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL").Return("id", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL2").Return("id2", nil).AnyTimes()
+			mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "errorURL").Return("", errors.New("")).AnyTimes()
 
 			mockRandom := mocks.NewMockGenerator(ctrl)
 
@@ -233,60 +258,238 @@ func TestShortener_ShortenBatch(t *testing.T) {
 	}
 }
 
-func TestShortener_DeleteUrls(t *testing.T) {
-	type args struct {
-		userID  string
-		urlsIDS []string
-	}
-	tests := []struct {
-		name        string
-		args        args
-		wantDeleted []models.ShortURL
-		wantErr     bool
-	}{
-		{
-			name: "it deletes correct urls",
-			args: args{
-				urlsIDS: []string{"id1", "id2"},
-				userID:  "userID",
-			},
-			wantDeleted: []models.ShortURL{
-				{ID: "id2", CreatedByID: "userID"},
-				{ID: "id1", CreatedByID: "userID"},
-			},
-			wantErr: false,
-		},
+func TestShortener_ShortenBatchWithExistingUrls(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	existing := models.ShortURL{OriginalURL: "origURL", ID: "id", CreatedByID: "another user"}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().SaveBatch(context.Background(), []models.ShortURL{
+		{CorrelationID: "corID", OriginalURL: "origURL", ID: "id", CreatedByID: "user"},
+		{CorrelationID: "corID2", OriginalURL: "origURL2", ID: "id2", CreatedByID: "user"},
+	}).Return(storage.NewNotUniqueBatchError(map[int]models.ShortURL{0: existing}))
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL").Return("id", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL2").Return("id2", nil)
+
+	cfg := &config.Config{
+		BaseURL:       "http://localhost:8080",
+		ServerAddress: ":8080",
+		EncryptionKey: make([]byte, 2*aes.BlockSize),
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
 
-			mockRepo := mocks.NewMockRepository(ctrl)
-			mockRepo.EXPECT().DeleteUrls(context.Background(), []models.ShortURL{
-				{ID: "id2", CreatedByID: "userID"},
-				{ID: "id1", CreatedByID: "userID"},
-			}).Return(nil).AnyTimes()
-			mockRepo.EXPECT().DeleteUrls(context.Background(), []models.ShortURL{
-				{ID: "id1", CreatedByID: "userID"},
-				{ID: "id2", CreatedByID: "userID"},
-			}).Return(nil).AnyTimes()
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), cfg)
 
-			mockGen := mocks.NewMockURLGenerator(ctrl)
+	got, err := service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{CorrelationID: "corID", OriginalURL: "origURL"},
+		{CorrelationID: "corID2", OriginalURL: "origURL2"},
+	}, "user")
 
-			mockRandom := mocks.NewMockGenerator(ctrl)
+	var notUniqueErr *storage.NotUniqueBatchError
+	require.ErrorAs(t, err, &notUniqueErr)
+	assert.Equal(t, []models.ShortURL{
+		{CorrelationID: "corID", OriginalURL: "origURL", ID: "id", CreatedByID: "another user"},
+		{CorrelationID: "corID2", OriginalURL: "origURL2", ID: "id2", CreatedByID: "user"},
+	}, got)
+}
 
-			cfg := &config.Config{
-				BaseURL:       "http://localhost:8080",
-				ServerAddress: ":8080",
-				EncryptionKey: make([]byte, 2*aes.BlockSize),
-			}
+func TestShortener_ShortenRetriesOnIDCollision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-			service := New(mockRepo, mockGen, mockRandom, cfg)
+	taken := models.ShortURL{OriginalURL: "another url", ID: "id", CreatedByID: "another user"}
 
-			service.DeleteUrls(context.Background(), tt.args.urlsIDS, tt.args.userID)
-		})
+	mockRepo := mocks.NewMockRepository(ctrl)
+	gomock.InOrder(
+		mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}).
+			Return(storage.NewNotUniqueURLError(models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}, taken, nil)),
+		mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "salted id", CreatedByID: "user"}).
+			Return(nil),
+	)
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url#1").Return("salted id", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, models.ShortURL{OriginalURL: "url", ID: "salted id", CreatedByID: "user"}, got)
+}
+
+func TestShortener_ShortenFallsBackToRandomID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taken := models.ShortURL{OriginalURL: "another url", ID: "id", CreatedByID: "another user"}
+	randomID := generator.EncodeBase62([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), gomock.Any()).DoAndReturn(func(_ context.Context, shortURL models.ShortURL) error {
+		if shortURL.ID == randomID {
+			return nil
+		}
+		return storage.NewNotUniqueURLError(shortURL, taken, nil)
+	}).Times(saltedIDAttempts + 2)
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), gomock.Any()).Return("id", nil).Times(saltedIDAttempts + 1)
+
+	mockRandom := mocks.NewMockGenerator(ctrl)
+	mockRandom.EXPECT().GenerateRandomBytes(randomIDSize).Return([]byte{1, 2, 3, 4, 5, 6, 7, 8}, nil)
+
+	service := New(mockRepo, mockGen, mockRandom, &config.Config{})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, randomID, got.ID)
+}
+
+func TestShortener_ShortenGivesUpOnCollisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taken := models.ShortURL{OriginalURL: "another url", ID: "id", CreatedByID: "another user"}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), gomock.Any()).DoAndReturn(func(_ context.Context, shortURL models.ShortURL) error {
+		return storage.NewNotUniqueURLError(shortURL, taken, nil)
+	}).Times(maxIDAttempts + 1)
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), gomock.Any()).Return("id", nil).AnyTimes()
+
+	mockRandom := mocks.NewMockGenerator(ctrl)
+	mockRandom.EXPECT().GenerateRandomBytes(randomIDSize).Return([]byte{1}, nil).AnyTimes()
+
+	service := New(mockRepo, mockGen, mockRandom, &config.Config{})
+
+	_, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{})
+	assert.ErrorIs(t, err, ErrNoFreeID)
+}
+
+func TestShortener_ShortenReturnsStoredDuplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := models.ShortURL{OriginalURL: "url", ID: "stored id", CreatedByID: "another user"}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}).
+		Return(storage.NewNotUniqueURLError(models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}, stored, nil))
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{})
+
+	var notUniqueErr *storage.NotUniqueURLError
+	require.ErrorAs(t, err, &notUniqueErr)
+	assert.Equal(t, stored, got, "duplicate must point to the stored url")
+}
+
+func TestShortener_ShortenBatchRetriesOnIDCollision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taken := models.ShortURL{OriginalURL: "another url", ID: "id", CreatedByID: "another user"}
+	stored := models.ShortURL{OriginalURL: "origURL3", ID: "id3", CreatedByID: "another user"}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	gomock.InOrder(
+		mockRepo.EXPECT().SaveBatch(context.Background(), []models.ShortURL{
+			{CorrelationID: "corID", OriginalURL: "origURL", ID: "id", CreatedByID: "user"},
+			{CorrelationID: "corID2", OriginalURL: "origURL2", ID: "id2", CreatedByID: "user"},
+			{CorrelationID: "corID3", OriginalURL: "origURL3", ID: "id3", CreatedByID: "user"},
+		}).Return(storage.NewNotUniqueBatchError(map[int]models.ShortURL{0: taken, 2: stored})),
+		mockRepo.EXPECT().SaveBatch(context.Background(), []models.ShortURL{
+			{CorrelationID: "corID", OriginalURL: "origURL", ID: "salted id", CreatedByID: "user"},
+		}).Return(nil),
+	)
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL").Return("id", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL2").Return("id2", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL3").Return("id3", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL#1").Return("salted id", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{CorrelationID: "corID", OriginalURL: "origURL"},
+		{CorrelationID: "corID2", OriginalURL: "origURL2"},
+		{CorrelationID: "corID3", OriginalURL: "origURL3"},
+	}, "user")
+
+	var notUniqueErr *storage.NotUniqueBatchError
+	require.ErrorAs(t, err, &notUniqueErr)
+	assert.Len(t, notUniqueErr.Existing, 1, "only real duplicates must be reported")
+	assert.Equal(t, []models.ShortURL{
+		{CorrelationID: "corID", OriginalURL: "origURL", ID: "salted id", CreatedByID: "user"},
+		{CorrelationID: "corID2", OriginalURL: "origURL2", ID: "id2", CreatedByID: "user"},
+		{CorrelationID: "corID3", OriginalURL: "origURL3", ID: "id3", CreatedByID: "another user"},
+	}, got)
+}
+
+func TestShortener_DeleteUrls(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().DeleteUrls(gomock.Any(), []models.ShortURL{
+		{ID: "id1", CreatedByID: "userID"},
+		{ID: "id2", CreatedByID: "userID"},
+		{ID: "id3", CreatedByID: "userID"},
+		{ID: "id1", CreatedByID: "userID"},
+	}).Return([]models.DeleteResult{
+		models.DeleteResultDeleted,
+		models.DeleteResultNotOwned,
+		models.DeleteResultNotFound,
+		models.DeleteResultAlreadyDeleted,
+	}, nil)
+
+	cfg := &config.Config{
+		BaseURL:       "http://localhost:8080",
+		ServerAddress: ":8080",
+		EncryptionKey: make([]byte, 2*aes.BlockSize),
 	}
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), cfg)
+
+	job, err := service.DeleteUrls(context.Background(), []string{"id1", "id2", "id3", "id1"}, "userID")
+	require.NoError(t, err)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, models.DeleteJobPending, job.Status)
+
+	got, err := service.GetDeleteJob(context.Background(), job.ID, "userID")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobPending, got.Status)
+
+	_, err = service.GetDeleteJob(context.Background(), job.ID, "another user")
+	assert.ErrorIs(t, err, ErrDeleteJobNotFound, "jobs of other users are not shown")
+	_, err = service.GetDeleteJob(context.Background(), "missing", "userID")
+	assert.ErrorIs(t, err, ErrDeleteJobNotFound)
+
+	empty, err := service.DeleteUrls(context.Background(), nil, "userID")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobDone, empty.Status, "nothing is queued without ids")
+
+	service.StopDeletions()
+	service.ProcessDeletions(context.Background())
+
+	got, err = service.GetDeleteJob(context.Background(), job.ID, "userID")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobDone, got.Status)
+	assert.Equal(t, map[string]models.DeleteResult{
+		"id1": models.DeleteResultDeleted,
+		"id2": models.DeleteResultNotOwned,
+		"id3": models.DeleteResultNotFound,
+	}, got.Results, "the first outcome of repeated id is kept")
 }
 
 func TestShortener_GetShortURL(t *testing.T) {
@@ -359,7 +562,7 @@ func BenchmarkShortener(b *testing.B) {
 
 	b.Run("shorten", func(b *testing.B) {
 		for i := 0; i < urlsToShortenCount; i++ {
-			_, _ = service.Shorten(context.Background(), urlsToShorten[i], "")
+			_, _ = service.Shorten(context.Background(), urlsToShorten[i], "", models.ShortenOptions{})
 		}
 	})
 }
@@ -376,28 +579,27 @@ func randStringBytes(n int) string {
 
 func TestShortener_GetStats(t *testing.T) {
 	tests := []struct {
-		name       string
-		wantStats  models.Stats
-		usersCount int
-		urlsCount  int
-		wantErr    bool
+		name      string
+		wantStats models.Stats
+		repoStats models.Stats
+		wantErr   bool
 	}{
 		{
-			name: "it deletes correct urls",
+			name: "it returns stats of repository",
 			wantStats: models.Stats{
 				UrlsCount:  20,
 				UsersCount: 10,
 			},
-			usersCount: 10,
-			urlsCount:  20,
-			wantErr:    false,
+			repoStats: models.Stats{
+				UrlsCount:  20,
+				UsersCount: 10,
+			},
+			wantErr: false,
 		},
 		{
-			name:       "it deletes correct urls",
-			wantStats:  models.Stats{},
-			usersCount: 0,
-			urlsCount:  0,
-			wantErr:    true,
+			name:      "it returns error of repository",
+			wantStats: models.Stats{},
+			wantErr:   true,
 		},
 	}
 	for _, tt := range tests {
@@ -407,9 +609,9 @@ func TestShortener_GetStats(t *testing.T) {
 
 			mockRepo := mocks.NewMockRepository(ctrl)
 			if tt.wantErr {
-				mockRepo.EXPECT().GetUsersAndUrlsCount(context.Background()).Return(0, 0, errors.New("")).AnyTimes()
+				mockRepo.EXPECT().GetStats(context.Background(), gomock.Any()).Return(models.Stats{}, errors.New("")).AnyTimes()
 			} else {
-				mockRepo.EXPECT().GetUsersAndUrlsCount(context.Background()).Return(tt.usersCount, tt.urlsCount, nil).AnyTimes()
+				mockRepo.EXPECT().GetStats(context.Background(), gomock.Any()).Return(tt.repoStats, nil).AnyTimes()
 			}
 
 			mockGen := mocks.NewMockURLGenerator(ctrl)
@@ -418,13 +620,213 @@ func TestShortener_GetStats(t *testing.T) {
 
 			service := New(mockRepo, mockGen, mockRandom, cfg)
 
-			stats, err := service.GetStats(context.Background())
+			stats, err := service.GetStats(context.Background(), models.StatsQuery{})
 			if tt.wantErr {
 				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
+				assert.Equal(t, tt.wantStats, stats)
+				return
 			}
+			require.NoError(t, err)
+			assert.Len(t, stats.Created, 7, "a week of days is returned by default")
+			stats.Created = nil
 			assert.Equal(t, tt.wantStats, stats)
 		})
 	}
 }
+
+func TestShortener_ShortenWithAlias(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "spring-sale", CreatedByID: "user"}).
+		Return(nil)
+	mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "taken", CreatedByID: "user"}).
+		Return(storage.NewNotUniqueURLError(
+			models.ShortURL{OriginalURL: "url", ID: "taken", CreatedByID: "user"},
+			models.ShortURL{OriginalURL: "another url", ID: "taken", CreatedByID: "another user"},
+			nil,
+		))
+
+	// generator must not be used for aliases
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{Alias: "spring-sale"})
+	require.NoError(t, err)
+	assert.Equal(t, models.ShortURL{OriginalURL: "url", ID: "spring-sale", CreatedByID: "user"}, got)
+
+	_, err = service.Shorten(context.Background(), "url", "user", models.ShortenOptions{Alias: "taken"})
+	assert.ErrorIs(t, err, ErrAliasTaken)
+
+	_, err = service.Shorten(context.Background(), "url", "user", models.ShortenOptions{Alias: "api"})
+	assert.ErrorIs(t, err, ErrInvalidAlias)
+}
+
+func TestShortener_ShortenWithAliasOfShortenedURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := models.ShortURL{OriginalURL: "url", ID: "old-sale", CreatedByID: "user"}
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "sale", CreatedByID: "user"}).
+		Return(storage.NewNotUniqueURLError(models.ShortURL{OriginalURL: "url", ID: "sale", CreatedByID: "user"}, stored, nil))
+	mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "old-sale", CreatedByID: "user"}).
+		Return(storage.NewNotUniqueURLError(models.ShortURL{OriginalURL: "url", ID: "old-sale", CreatedByID: "user"}, stored, nil))
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{Alias: "sale"})
+	assert.ErrorIs(t, err, ErrAliasConflict, "alias must not be dropped silently")
+	var notUniqueErr *storage.NotUniqueURLError
+	assert.False(t, errors.As(err, &notUniqueErr), "alias conflict must not be reported as plain duplicate")
+	assert.Equal(t, stored, got)
+
+	// url shortened with the same alias before is plain duplicate
+	got, err = service.Shorten(context.Background(), "url", "user", models.ShortenOptions{Alias: "old-sale"})
+	assert.ErrorAs(t, err, &notUniqueErr)
+	assert.Equal(t, stored, got)
+}
+
+func TestShortener_ShortenBatchWithAliasOfShortenedURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().SaveBatch(context.Background(), []models.ShortURL{
+		{CorrelationID: "corID", OriginalURL: "url", ID: "sale", CreatedByID: "user"},
+	}).Return(storage.NewNotUniqueBatchError(map[int]models.ShortURL{
+		0: {OriginalURL: "url", ID: "id", CreatedByID: "user"},
+	}))
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	_, err := service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{CorrelationID: "corID", OriginalURL: "url", Options: models.ShortenOptions{Alias: "sale"}},
+	}, "user")
+	assert.ErrorIs(t, err, ErrAliasConflict)
+}
+
+func TestShortener_ShortenBatchWithAliases(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	gomock.InOrder(
+		mockRepo.EXPECT().SaveBatch(context.Background(), []models.ShortURL{
+			{CorrelationID: "corID", OriginalURL: "origURL", ID: "sale", CreatedByID: "user"},
+			{CorrelationID: "corID2", OriginalURL: "origURL2", ID: "id2", CreatedByID: "user"},
+		}).Return(nil),
+		mockRepo.EXPECT().SaveBatch(context.Background(), []models.ShortURL{
+			{CorrelationID: "corID", OriginalURL: "origURL", ID: "taken", CreatedByID: "user"},
+		}).Return(storage.NewNotUniqueBatchError(map[int]models.ShortURL{
+			0: {OriginalURL: "another url", ID: "taken", CreatedByID: "another user"},
+		})),
+	)
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "origURL2").Return("id2", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{CorrelationID: "corID", OriginalURL: "origURL", Options: models.ShortenOptions{Alias: "sale"}},
+		{CorrelationID: "corID2", OriginalURL: "origURL2"},
+	}, "user")
+	require.NoError(t, err)
+	assert.Equal(t, "sale", got[0].ID)
+	assert.Equal(t, "id2", got[1].ID)
+
+	_, err = service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{CorrelationID: "corID", OriginalURL: "origURL", Options: models.ShortenOptions{Alias: "taken"}},
+	}, "user")
+	assert.ErrorIs(t, err, ErrAliasTaken)
+
+	_, err = service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{CorrelationID: "corID", OriginalURL: "origURL", Options: models.ShortenOptions{Alias: "sale"}},
+		{CorrelationID: "corID2", OriginalURL: "origURL2", Options: models.ShortenOptions{Alias: "sale"}},
+	}, "user")
+	assert.ErrorIs(t, err, ErrAliasTaken, "the same alias must not be used twice in batch")
+
+	_, err = service.ShortenBatch(context.Background(), []models.ShortenBatchItem{
+		{CorrelationID: "corID", OriginalURL: "origURL", Options: models.ShortenOptions{Alias: "a/b"}},
+	}, "user")
+	assert.ErrorIs(t, err, ErrInvalidAlias)
+}
+
+func TestShortener_ShortenWithUniquenessPerUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), models.ShortURL{
+		OriginalURL: "url", ID: "user id", CreatedByID: "user", UniqueScope: "user",
+	}).Return(nil)
+	mockRepo.EXPECT().SaveBatch(context.Background(), []models.ShortURL{
+		{OriginalURL: "url", ID: "user2 id", CreatedByID: "user2", UniqueScope: "user2", CorrelationID: "corID"},
+	}).Return(nil)
+
+	// ids of the same url shortened by different users are generated from different strings
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "user url").Return("user id", nil)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "user2 url").Return("user2 id", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{URLUniqueness: config.UniquenessPerUser})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "user id", got.ID)
+
+	batch, err := service.ShortenBatch(context.Background(), []models.ShortenBatchItem{{OriginalURL: "url", CorrelationID: "corID"}}, "user2")
+	require.NoError(t, err)
+	assert.Equal(t, "user2 id", batch[0].ID)
+}
+
+func TestShortener_ShortenWithMaxClicks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user", MaxClicks: 3}).Return(nil)
+
+	mockGen := mocks.NewMockURLGenerator(ctrl)
+	mockGen.EXPECT().GenerateIDFromString(gomock.Any(), "url").Return("id", nil)
+
+	service := New(mockRepo, mockGen, mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	got, err := service.Shorten(context.Background(), "url", "user", models.ShortenOptions{MaxClicks: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.MaxClicks)
+
+	_, err = service.Shorten(context.Background(), "url", "user", models.ShortenOptions{MaxClicks: -1})
+	assert.ErrorIs(t, err, ErrInvalidMaxClicks)
+
+	_, err = service.ShortenBatch(context.Background(), []models.ShortenBatchItem{{OriginalURL: "url", Options: models.ShortenOptions{MaxClicks: -1}}}, "user")
+	assert.ErrorIs(t, err, ErrInvalidMaxClicks)
+}
+
+// pooledRepository is repository with connection pool like storage.PgRepository.
+type pooledRepository struct {
+	*mocks.MockRepository
+	stats models.PoolStats
+}
+
+func (repo pooledRepository) PoolStats() models.PoolStats {
+	return repo.stats
+}
+
+func TestShortener_HealthCheckOfInstrumentedRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Check(gomock.Any()).Return(nil)
+	repo := pooledRepository{MockRepository: mockRepo, stats: models.PoolStats{TotalConns: 3, MaxConns: 10}}
+
+	service := New(storage.NewInstrumentedRepository(repo, "postgres"), mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	health, err := service.HealthCheck(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ok", health.Status)
+	require.NotNil(t, health.Pool, "pool stats of wrapped repository must be reported")
+	assert.Equal(t, repo.stats, *health.Pool)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+)
+
+const (
+	// defaultStatsTop is size of top lists when query doesn't set it.
+	defaultStatsTop = 10
+	// maxStatsTop is maximum size of top lists.
+	maxStatsTop = 100
+	// maxStatsPeriods is maximum count of periods in window of created urls.
+	maxStatsPeriods = 1000
+)
+
+// defaultStatsPeriods is count of periods in window of created urls when query doesn't set start of it.
+var defaultStatsPeriods = map[models.StatsGranularity]int{ //nolint:gochecknoglobals
+	models.StatsByDay:  7,
+	models.StatsByHour: 24,
+}
+
+// ErrInvalidStatsQuery is returned when stats query has unknown granularity, wrong window or size of top lists.
+var ErrInvalidStatsQuery = errors.New("invalid stats query")
+
+// GetStats returns stats of the service.
+// Zero fields of query get defaults: daily periods, window that ends now and spans a week of days or a day of hours,
+// and top lists of 10 entries. Start of window is aligned to the start of its period,
+// periods of window without created urls are returned with zero count.
+func (service *Shortener) GetStats(ctx context.Context, query models.StatsQuery) (models.Stats, error) {
+	query, err := statsQueryWithDefaults(query, time.Now())
+	if err != nil {
+		return models.Stats{}, err
+	}
+
+	stats, err := service.repository.GetStats(ctx, query)
+	if err != nil {
+		return models.Stats{}, err
+	}
+	stats.Created = fillPeriods(stats.Created, query)
+
+	return stats, nil
+}
+
+// statsQueryWithDefaults fills zero fields of query and validates it.
+func statsQueryWithDefaults(query models.StatsQuery, now time.Time) (models.StatsQuery, error) {
+	if query.Granularity == "" {
+		query.Granularity = models.StatsByDay
+	}
+	period := query.Granularity.Duration()
+	if period == 0 {
+		return query, fmt.Errorf("%w: unknown granularity %q", ErrInvalidStatsQuery, query.Granularity)
+	}
+
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		// the last period of default window contains the last moment before its end
+		query.From = query.Granularity.PeriodStart(query.To.Add(-1)).Add(-time.Duration(defaultStatsPeriods[query.Granularity]-1) * period)
+	}
+	query.From = query.Granularity.PeriodStart(query.From)
+	query.To = query.To.UTC()
+	if !query.From.Before(query.To) {
+		return query, fmt.Errorf("%w: window must end after its start", ErrInvalidStatsQuery)
+	}
+	if query.To.Sub(query.From) > maxStatsPeriods*period {
+		return query, fmt.Errorf("%w: window can't be longer than %d periods", ErrInvalidStatsQuery, maxStatsPeriods)
+	}
+
+	if query.Top == 0 {
+		query.Top = defaultStatsTop
+	}
+	if query.Top < 0 || query.Top > maxStatsTop {
+		return query, fmt.Errorf("%w: size of top lists must be from 1 to %d", ErrInvalidStatsQuery, maxStatsTop)
+	}
+
+	return query, nil
+}
+
+// fillPeriods returns counts of all the periods of query window, periods missing from created get zero count.
+func fillPeriods(created []models.PeriodCount, query models.StatsQuery) []models.PeriodCount {
+	counts := make(map[int64]int, len(created))
+	for _, period := range created {
+		counts[period.Start.Unix()] = period.Count
+	}
+
+	filled := make([]models.PeriodCount, 0)
+	for start := query.From; start.Before(query.To); start = start.Add(query.Granularity.Duration()) {
+		filled = append(filled, models.PeriodCount{Start: start, Count: counts[start.Unix()]})
+	}
+	return filled
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsQueryWithDefaults(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		query   models.StatsQuery
+		want    models.StatsQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: models.StatsQuery{},
+			want: models.StatsQuery{
+				From:        time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+				To:          now,
+				Granularity: models.StatsByDay,
+				Top:         defaultStatsTop,
+			},
+		},
+		{
+			name:  "hourly defaults",
+			query: models.StatsQuery{Granularity: models.StatsByHour},
+			want: models.StatsQuery{
+				From:        time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC),
+				To:          now,
+				Granularity: models.StatsByHour,
+				Top:         defaultStatsTop,
+			},
+		},
+		{
+			name: "start of window is aligned to period",
+			query: models.StatsQuery{
+				From: time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60)),
+				To:   time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+				Top:  5,
+			},
+			want: models.StatsQuery{
+				From:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:          time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+				Granularity: models.StatsByDay,
+				Top:         5,
+			},
+		},
+		{name: "unknown granularity", query: models.StatsQuery{Granularity: "week"}, wantErr: true},
+		{name: "window ends before start", query: models.StatsQuery{From: now, To: now.Add(-24 * time.Hour)}, wantErr: true},
+		{name: "too long window", query: models.StatsQuery{From: now.Add(-maxStatsPeriods * time.Hour), To: now, Granularity: models.StatsByHour}, wantErr: true},
+		{name: "negative top", query: models.StatsQuery{Top: -1}, wantErr: true},
+		{name: "too long top", query: models.StatsQuery{Top: maxStatsTop + 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := statsQueryWithDefaults(tt.query, now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidStatsQuery)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.From.Equal(got.From), "from: expected %v, actual %v", tt.want.From, got.From)
+			assert.True(t, tt.want.To.Equal(got.To), "to: expected %v, actual %v", tt.want.To, got.To)
+			assert.Equal(t, tt.want.Granularity, got.Granularity)
+			assert.Equal(t, tt.want.Top, got.Top)
+		})
+	}
+}
+
+func TestShortener_GetStatsFillsPeriods(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	query := models.StatsQuery{From: day, To: day.Add(4 * 24 * time.Hour), Granularity: models.StatsByDay, Top: 3}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetStats(context.Background(), query).Return(models.Stats{
+		Created: []models.PeriodCount{
+			{Start: day.Add(24 * time.Hour), Count: 2},
+			{Start: day.Add(3 * 24 * time.Hour), Count: 1},
+		},
+	}, nil)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	stats, err := service.GetStats(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []models.PeriodCount{
+		{Start: day, Count: 0},
+		{Start: day.Add(24 * time.Hour), Count: 2},
+		{Start: day.Add(2 * 24 * time.Hour), Count: 0},
+		{Start: day.Add(3 * 24 * time.Hour), Count: 1},
+	}, stats.Created)
+}
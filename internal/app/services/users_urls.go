@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+)
+
+const (
+	// defaultUsersURLsLimit is size of page of urls of user when query doesn't set it.
+	defaultUsersURLsLimit = 100
+	// maxUsersURLsLimit is maximum size of page of urls of user.
+	maxUsersURLsLimit = 1000
+)
+
+// ErrInvalidUsersURLsQuery is returned when query of urls of user has unknown status or order, wrong window or limit.
+var ErrInvalidUsersURLsQuery = errors.New("invalid users urls query")
+
+// GetUrlsCreatedBy returns page of urls that were shortened by given userID and match the query.
+// Zero fields of query get defaults: urls that aren't deleted, oldest first, in pages of 100 urls.
+// Next cursor of page is set when there are more urls after it.
+func (service *Shortener) GetUrlsCreatedBy(ctx context.Context, userID string, query models.UsersURLsQuery) (models.UsersURLsPage, error) {
+	query, err := usersURLsQueryWithDefaults(query)
+	if err != nil {
+		return models.UsersURLsPage{}, err
+	}
+
+	// one more url tells whether there is the next page
+	limit := query.Limit
+	query.Limit++
+	urls, err := service.repository.GetUsersUrls(ctx, userID, query)
+	if err != nil {
+		return models.UsersURLsPage{}, err
+	}
+
+	page := models.UsersURLsPage{URLs: urls}
+	if len(urls) > limit {
+		page.URLs = urls[:limit]
+		next := models.CursorOf(page.URLs[limit-1])
+		page.Next = &next
+	}
+
+	return page, nil
+}
+
+// usersURLsQueryWithDefaults fills zero fields of query and validates it.
+func usersURLsQueryWithDefaults(query models.UsersURLsQuery) (models.UsersURLsQuery, error) {
+	if query.Status == "" {
+		query.Status = models.URLsActive
+	}
+	if query.Status != models.URLsActive && query.Status != models.URLsDeleted && query.Status != models.URLsAll {
+		return query, fmt.Errorf("%w: unknown status %q", ErrInvalidUsersURLsQuery, query.Status)
+	}
+
+	if query.Order == "" {
+		query.Order = models.SortOldestFirst
+	}
+	if query.Order != models.SortOldestFirst && query.Order != models.SortNewestFirst {
+		return query, fmt.Errorf("%w: unknown order %q", ErrInvalidUsersURLsQuery, query.Order)
+	}
+
+	if !query.CreatedFrom.IsZero() && !query.CreatedTo.IsZero() && !query.CreatedFrom.Before(query.CreatedTo) {
+		return query, fmt.Errorf("%w: creation window must end after its start", ErrInvalidUsersURLsQuery)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultUsersURLsLimit
+	}
+	if query.Limit < 0 || query.Limit > maxUsersURLsLimit {
+		return query, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidUsersURLsQuery, maxUsersURLsLimit)
+	}
+
+	return query, nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/mocks"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsersURLsQueryWithDefaults(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		query   models.UsersURLsQuery
+		want    models.UsersURLsQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: models.UsersURLsQuery{},
+			want:  models.UsersURLsQuery{Status: models.URLsActive, Order: models.SortOldestFirst, Limit: defaultUsersURLsLimit},
+		},
+		{
+			name:  "set fields are kept",
+			query: models.UsersURLsQuery{CreatedFrom: now.Add(-time.Hour), CreatedTo: now, Status: models.URLsAll, Search: "example", Order: models.SortNewestFirst, Limit: 10},
+			want:  models.UsersURLsQuery{CreatedFrom: now.Add(-time.Hour), CreatedTo: now, Status: models.URLsAll, Search: "example", Order: models.SortNewestFirst, Limit: 10},
+		},
+		{name: "unknown status", query: models.UsersURLsQuery{Status: "archived"}, wantErr: true},
+		{name: "unknown order", query: models.UsersURLsQuery{Order: "random"}, wantErr: true},
+		{name: "window ends before start", query: models.UsersURLsQuery{CreatedFrom: now, CreatedTo: now.Add(-time.Hour)}, wantErr: true},
+		{name: "negative limit", query: models.UsersURLsQuery{Limit: -1}, wantErr: true},
+		{name: "too big limit", query: models.UsersURLsQuery{Limit: maxUsersURLsLimit + 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := usersURLsQueryWithDefaults(tt.query)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidUsersURLsQuery)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShortener_GetUrlsCreatedBy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	createdAt := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	urls := []models.ShortURL{
+		{ID: "a", CreatedAt: createdAt},
+		{ID: "b", CreatedAt: createdAt},
+		{ID: "c", CreatedAt: createdAt},
+	}
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user", models.UsersURLsQuery{Status: models.URLsActive, Order: models.SortOldestFirst, Limit: 3}).Return(urls, nil)
+	mockRepo.EXPECT().GetUsersUrls(gomock.Any(), "user", models.UsersURLsQuery{Status: models.URLsActive, Order: models.SortOldestFirst, Limit: 4}).Return(urls, nil)
+
+	service := New(mockRepo, mocks.NewMockURLGenerator(ctrl), mocks.NewMockGenerator(ctrl), &config.Config{})
+
+	page, err := service.GetUrlsCreatedBy(context.Background(), "user", models.UsersURLsQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, urls[:2], page.URLs)
+	require.NotNil(t, page.Next, "page followed by more urls must have next cursor")
+	assert.Equal(t, models.URLCursor{CreatedAt: createdAt, ID: "b"}, *page.Next)
+
+	page, err = service.GetUrlsCreatedBy(context.Background(), "user", models.UsersURLsQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, urls, page.URLs)
+	assert.Nil(t, page.Next, "the last page must not have next cursor")
+
+	_, err = service.GetUrlsCreatedBy(context.Background(), "user", models.UsersURLsQuery{Status: "archived"})
+	assert.ErrorIs(t, err, ErrInvalidUsersURLsQuery)
+}
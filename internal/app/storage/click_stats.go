@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+)
+
+// clickStats aggregates click events of one url for repositories that keep them in memory.
+type clickStats struct {
+	visitors map[string]struct{} // unique visitors, see models.ClickEvent.VisitorKey
+	daily    map[string]int      // count of clicks by day in models.ClickDayLayout
+	total    int                 // count of all clicks
+}
+
+// addClickEvent adds event to stats of its url, stats are created on the first event.
+func addClickEvent(stats map[string]*clickStats, event models.ClickEvent) {
+	urlStats, ok := stats[event.URLID]
+	if !ok {
+		urlStats = &clickStats{
+			visitors: make(map[string]struct{}),
+			daily:    make(map[string]int),
+		}
+		stats[event.URLID] = urlStats
+	}
+
+	urlStats.total++
+	urlStats.visitors[event.VisitorKey()] = struct{}{}
+	urlStats.daily[event.Time.UTC().Format(models.ClickDayLayout)]++
+}
+
+// model returns stats with days in order. Nil stats are zero stats.
+func (stats *clickStats) model() models.ClickStats {
+	if stats == nil {
+		return models.ClickStats{Daily: []models.DailyClicks{}}
+	}
+
+	daily := make([]models.DailyClicks, 0, len(stats.daily))
+	for day, clicks := range stats.daily {
+		daily = append(daily, models.DailyClicks{Date: day, Clicks: clicks})
+	}
+	sort.Slice(daily, func(i, j int) bool {
+		return daily[i].Date < daily[j].Date
+	})
+
+	return models.ClickStats{Daily: daily, Total: stats.total, UniqueVisitors: len(stats.visitors)}
+}
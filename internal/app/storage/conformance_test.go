@@ -0,0 +1,82 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage/storagetest"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepositoryConformance(t *testing.T) {
+	storagetest.RunRepositoryTests(t, func(t *testing.T) storage.Repository {
+		return closeOnCleanup(t, storage.NewInMemoryRepository())
+	})
+}
+
+func TestInstrumentedRepositoryConformance(t *testing.T) {
+	storagetest.RunRepositoryTests(t, func(t *testing.T) storage.Repository {
+		return closeOnCleanup(t, storage.NewInstrumentedRepository(storage.NewInMemoryRepository(), storage.BackendMemory))
+	})
+}
+
+func TestFileRepositoryConformance(t *testing.T) {
+	storagetest.RunRepositoryTests(t, func(t *testing.T) storage.Repository {
+		repo, err := storage.NewFileRepository(filepath.Join(t.TempDir(), "urls.json"))
+		require.NoError(t, err)
+		return closeOnCleanup(t, repo)
+	})
+}
+
+func TestRedisRepositoryConformance(t *testing.T) {
+	storagetest.RunRepositoryTests(t, func(t *testing.T) storage.Repository {
+		server := miniredis.RunT(t)
+		repo, err := storage.NewRedisRepository("redis://" + server.Addr())
+		require.NoError(t, err)
+		return closeOnCleanup(t, repo)
+	})
+}
+
+func TestSqliteRepositoryConformance(t *testing.T) {
+	storagetest.RunRepositoryTests(t, func(t *testing.T) storage.Repository {
+		repo, err := storage.NewSqliteRepository(storage.SqliteScheme+filepath.Join(t.TempDir(), "urls.db"), "file://migrations/")
+		require.NoError(t, err)
+		return closeOnCleanup(t, repo)
+	})
+}
+
+// TestPgRepositoryConformance needs running postgres, so it's skipped when DATABASE_DSN is not set.
+func TestPgRepositoryConformance(t *testing.T) {
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN is not set")
+	}
+
+	storagetest.RunRepositoryTests(t, func(t *testing.T) storage.Repository {
+		conn, err := pgx.Connect(context.Background(), dsn)
+		require.NoError(t, err)
+		defer conn.Close(context.Background())
+
+		repo, err := storage.NewPgRepository(dsn, "file://migrations/", storage.PgPoolConfig{MaxConns: 4})
+		require.NoError(t, err)
+
+		_, err = conn.Exec(context.Background(), "truncate table urls, click_events")
+		require.NoError(t, err)
+
+		return closeOnCleanup(t, repo)
+	})
+}
+
+func closeOnCleanup(t *testing.T, repo storage.Repository) storage.Repository {
+	t.Helper()
+
+	t.Cleanup(func() {
+		require.NoError(t, repo.Close(context.Background()))
+	})
+	return repo
+}
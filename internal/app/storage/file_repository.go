@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -17,274 +18,1126 @@ import (
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 )
 
-// Структура такая. Тип FileRepository и 10 его методов
+// Структура такая. Тип FileRepository: индексы в памяти, записи операций (op*) в конце файла
+// и компакция, которая убирает устаревшие записи, подробнее в описании типа.
+
+const (
+	// opDelete marks tombstone record of deleted url.
+	opDelete = "delete"
+	// opClick marks record with updated count of clicks of url.
+	opClick = "click"
+	// opRestore marks record of restored url.
+	opRestore = "restore"
+	// opPurge marks record of url removed from storage.
+	opPurge = "purge"
+	// compactionSuffix is appended to the storage file path to get path of the file being compacted.
+	compactionSuffix = ".compact"
+	// eventsSuffix is appended to the storage file path to get path of the file with click events.
+	eventsSuffix = ".events"
+	// compactionMinGarbage is the minimum count of outdated records that triggers background compaction.
+	compactionMinGarbage = 1024
+)
+
+// SyncMode defines when FileRepository syncs written data to disk.
+type SyncMode string
+
+const (
+	// SyncAlways syncs file after every write.
+	SyncAlways SyncMode = "always"
+	// SyncInterval syncs file periodically if something was written.
+	SyncInterval SyncMode = "interval"
+	// SyncNever leaves syncing to operating system.
+	SyncNever SyncMode = "never"
+)
+
+// FileRepositoryOption configures FileRepository.
+type FileRepositoryOption func(repo *FileRepository)
+
+// WithSync sets sync mode of FileRepository. Interval is used only with SyncInterval mode.
+func WithSync(mode SyncMode, interval time.Duration) FileRepositoryOption {
+	return func(repo *FileRepository) {
+		repo.syncMode = mode
+		repo.syncInterval = interval
+	}
+}
 
 // FileRepository is repository that uses files for storage.
+// File is read once on creation into in-memory indexes, all reads are served from them,
+// and every write is appended to the end of the file.
+// Deletes and clicks are appended as separate records, outdated records are removed by compaction.
+// Click events are appended to separate file, which is created on the first event
+// and rewritten only when urls with click events are purged.
 type FileRepository struct {
-	file   *os.File      // file that we will be writing to
-	writer *bufio.Writer // buffered writer that will write to the file
-	mutex  sync.RWMutex  // mutex that will be used to synchronize access to the file
+	file                *os.File                   // file that we will be writing to
+	writer              *bufio.Writer              // buffered writer that will write to the file
+	eventsFile          *os.File                   // file with click events, nil until the first event is saved
+	eventsWriter        *bufio.Writer              // buffered writer that will write to the events file
+	clicks              map[string]*clickStats     // click stats by url id
+	byID                map[string]models.ShortURL // index of urls by their id
+	byURL               map[string]string          // index of url ids by unique key, see UniqueKey
+	byUser              map[string][]string        // index of url ids by user id, in order of creation
+	stopSync            chan struct{}              // closed to stop periodic syncing
+	path                string                     // path of the storage file
+	syncMode            SyncMode                   // when written data is synced to disk
+	ordered             []string                   // url ids in order of creation
+	compactions         sync.WaitGroup             // background compactions that are in progress
+	syncer              sync.WaitGroup             // periodic syncing goroutine
+	stopSyncOnce        sync.Once                  // stops periodic syncing on the first Close
+	mutex               sync.RWMutex               // mutex that will be used to synchronize access to the file and indexes
+	syncInterval        time.Duration              // how often data is synced in SyncInterval mode
+	garbage             int                        // count of records in file that are outdated by later records
+	sequence            uint64                     // last value of sequence, starts from count of stored urls
+	compactionThreshold int                        // count of outdated records that triggers background compaction
+	compacting          bool                       // whether background compaction is in progress
+	closed              bool                       // whether file is closed
+	dirty               bool                       // whether there are writes that are not synced yet
+	eventsUnterminated  bool                       // whether the last event in events file lacks line separator
+}
+
+// fileRecord is a line of the storage file.
+// Lines without op are urls, lines with op "delete" are tombstones, lines with op "click" are updated counts of clicks,
+// lines with op "restore" clear deletion of url and lines with op "purge" remove url.
+type fileRecord struct {
+	Op string `json:"op,omitempty"`
+	models.ShortURL
+}
+
+// tombstone is a record that marks url as deleted.
+type tombstone struct {
+	DeletedAt time.Time `json:"deleted_at"`
+	Op        string    `json:"op"`
+	ID        string    `json:"id"`
+}
+
+// idRecord is a record that refers to url only by id, like restoration or removal of url.
+type idRecord struct {
+	Op string `json:"op"`
+	ID string `json:"id"`
+}
+
+// clickRecord is a record that updates count of clicks of url.
+type clickRecord struct {
+	Op     string `json:"op"`
+	ID     string `json:"id"`
+	Clicks int    `json:"clicks"`
 }
 
 // Конструктор NewFileRepository creates new file repository.
 // Creates file at filePath if it doesn't exist.
-// It opens a file, creates a buffered writer, and returns a pointer to a FileRepository.
-func NewFileRepository(filePath string) (*FileRepository, error) {
-	//log.Info().Msgf()
-	log.Info().Msgf("конструктор NewFileRepository, проверяем/создаем файл")
-	//fmt.Println("конструктор NewFileRepository, проверяем/создаем файл")
+// It opens a file, loads its content to indexes, creates a buffered writer, and returns a pointer to a FileRepository.
+// Torn or corrupted records at the end of file, left by crash, are truncated.
+// By default, written data is synced to disk only by operating system.
+func NewFileRepository(filePath string, opts ...FileRepositoryOption) (*FileRepository, error) {
+	// file left by compaction that was interrupted before renaming is incomplete,
+	// the storage file itself is still intact
+	for _, tmpPath := range []string{filePath + compactionSuffix, filePath + eventsSuffix + compactionSuffix} {
+		if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
 	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o777) //nolint:gomnd
 	if err != nil {
 		return nil, err
 	}
 
-	return &FileRepository{
-		mutex:  sync.RWMutex{},
-		file:   file,
-		writer: bufio.NewWriter(file),
-	}, nil
-}
+	repo := &FileRepository{
+		mutex:               sync.RWMutex{},
+		file:                file,
+		writer:              bufio.NewWriter(file),
+		path:                filePath,
+		byID:                make(map[string]models.ShortURL),
+		byURL:               make(map[string]string),
+		byUser:              make(map[string][]string),
+		clicks:              make(map[string]*clickStats),
+		compactionThreshold: compactionMinGarbage,
+		syncMode:            SyncNever,
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
 
-// SaveBatch saves multiple urls.
-// Checks if the urls are unique and then saving them.
-func (repo *FileRepository) SaveBatch(ctx context.Context, batch []models.ShortURL) error {
-	log.Info().Msgf("метод (типа FileRepository) SaveBatch")
-	for _, shortURL := range batch {
-		fmt.Println("GetByID вызывается из SaveBatch")
-		_, err := repo.GetByID(ctx, shortURL.ID)
-		if err == nil {
-			return NewNotUniqueURLError(shortURL, nil)
-		}
+	switch {
+	case repo.syncMode == SyncInterval && repo.syncInterval <= 0:
+		err = fmt.Errorf("sync interval must be positive, got %s", repo.syncInterval)
+	case repo.syncMode != SyncAlways && repo.syncMode != SyncInterval && repo.syncMode != SyncNever:
+		err = fmt.Errorf("unknown sync mode %q", repo.syncMode)
+	default:
+		err = repo.loadIndexes()
+	}
+	if err == nil {
+		err = repo.loadClickEvents()
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
 	}
 
+	if repo.syncMode == SyncInterval {
+		repo.stopSync = make(chan struct{})
+		repo.syncer.Add(1)
+		go repo.syncPeriodically()
+	}
+
+	return repo, nil
+}
+
+// SaveBatch saves multiple urls.
+// Urls that are already exist are skipped and reported with NotUniqueBatchError,
+// all other urls of the batch are saved.
+func (repo *FileRepository) SaveBatch(_ context.Context, batch []models.ShortURL) error {
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
 
-	for _, shortURL := range batch {
-
-		data, err := json.Marshal(shortURL)
-		if err != nil {
-			return err
+	existing := make(map[int]models.ShortURL)
+	toSave := make([]models.ShortURL, 0, len(batch))
+	savedIDs := make(map[string]int, len(batch))
+	savedURLs := make(map[string]int, len(batch))
+	for i, shortURL := range batch {
+		if stored, ok := repo.lookup(shortURL); ok {
+			existing[i] = stored
+			continue
 		}
-
-		if _, errWrite := repo.writer.Write(data); errWrite != nil {
-			return errWrite
+		if j, ok := savedURLs[UniqueKey(shortURL)]; ok {
+			existing[i] = toSave[j]
+			continue
 		}
+		if j, ok := savedIDs[shortURL.ID]; ok {
+			existing[i] = toSave[j]
+			continue
+		}
+		savedIDs[shortURL.ID] = len(toSave)
+		savedURLs[UniqueKey(shortURL)] = len(toSave)
+		shortURL.CreatedAt = creationTime(shortURL)
+		toSave = append(toSave, shortURL)
+	}
 
-		if errWriteByte := repo.writer.WriteByte('\n'); errWriteByte != nil {
+	for _, shortURL := range toSave {
+		if err := writeJSONLine(repo.writer, shortURL); err != nil {
 			return err
 		}
-
 	}
-	if err := repo.writer.Flush(); err != nil {
+	if err := repo.commit(); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// Save checks if the url is unique and then saving it to the file.
-func (repo *FileRepository) Save(ctx context.Context, shortURL models.ShortURL) error {
-	_, err := repo.GetByID(ctx, shortURL.ID)
-	if err == nil {
-		return NewNotUniqueURLError(shortURL, nil)
+	for _, shortURL := range toSave {
+		repo.index(shortURL)
 	}
 
-	data, err := json.Marshal(shortURL)
-	if err != nil {
-		return err
+	if len(existing) > 0 {
+		return NewNotUniqueBatchError(existing)
 	}
 
+	return nil
+}
+
+// Save checks if the url is unique and then saving it to the file.
+func (repo *FileRepository) Save(_ context.Context, shortURL models.ShortURL) error {
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
 
-	if _, errWrite := repo.writer.Write(data); errWrite != nil {
-		return errWrite
+	if stored, ok := repo.lookup(shortURL); ok {
+		return NewNotUniqueURLError(shortURL, stored, nil)
 	}
 
-	if errWriteByte := repo.writer.WriteByte('\n'); errWriteByte != nil {
-		return errWriteByte
+	shortURL.CreatedAt = creationTime(shortURL)
+	if err := writeJSONLine(repo.writer, shortURL); err != nil {
+		return err
 	}
 
-	if errFlush := repo.writer.Flush(); errFlush != nil {
-		return errFlush
+	if err := repo.commit(); err != nil {
+		return err
 	}
 
+	repo.index(shortURL)
+
 	return nil
 }
 
-// GetByID gets url by id.
-// Reads the file line by line and returns url that matches given id.
+// GetByID gets url by id from index.
 func (repo *FileRepository) GetByID(_ context.Context, id string) (models.ShortURL, error) {
-	log.Info().Msgf("метод (типа FileRepository) GetByID")
 	repo.mutex.RLock()
 	defer repo.mutex.RUnlock()
 
-	if _, err := repo.file.Seek(0, io.SeekStart); err != nil {
-		return models.ShortURL{}, err
+	shortURL, ok := repo.byID[id]
+	if !ok {
+		return models.ShortURL{}, ErrNotFound
 	}
 
-	var entry models.ShortURL
+	return checkAvailable(shortURL)
+}
 
-	scanner := bufio.NewScanner(repo.file)
+// Click gets url by id from index and counts the redirect if url has click limit.
+// Every counted redirect is appended to the file as click record.
+func (repo *FileRepository) Click(_ context.Context, id string) (models.ShortURL, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if err := json.NewDecoder(bytes.NewReader(line)).Decode(&entry); err != nil {
-			return models.ShortURL{}, err
-		}
-		if entry.ID == id {
-			return entry, nil
-		}
+	shortURL, ok := repo.byID[id]
+	if !ok {
+		return models.ShortURL{}, ErrNotFound
+	}
+	if _, err := checkAvailable(shortURL); err != nil {
+		return shortURL, err
+	}
+	if shortURL.MaxClicks == 0 {
+		return shortURL, nil
+	}
+
+	shortURL, err := countClick(shortURL)
+	if err != nil {
+		return shortURL, err
 	}
 
-	return models.ShortURL{}, errors.New("can't find full url by id")
+	if err = writeJSONLine(repo.writer, clickRecord{Op: opClick, ID: id, Clicks: shortURL.Clicks}); err != nil {
+		return models.ShortURL{}, err
+	}
+	if err = repo.commit(); err != nil {
+		return models.ShortURL{}, err
+	}
+
+	repo.byID[id] = shortURL
+	repo.garbage++
+	repo.compactInBackgroundIfNeeded()
+
+	return shortURL, nil
 }
 
-// GetUsersUrls reads the file line by line and returning all the urls
-// that were created by user with id userID.
-func (repo *FileRepository) GetUsersUrls(_ context.Context, userID string) ([]models.ShortURL, error) {
+// GetUsersUrls returns the urls that were created by user with id userID and match the query.
+func (repo *FileRepository) GetUsersUrls(_ context.Context, userID string, query models.UsersURLsQuery) ([]models.ShortURL, error) {
 	repo.mutex.RLock()
 	defer repo.mutex.RUnlock()
 
-	if _, err := repo.file.Seek(0, io.SeekStart); err != nil {
-		return nil, err
+	URLs := make([]models.ShortURL, 0, len(repo.byUser[userID]))
+	for _, id := range repo.byUser[userID] {
+		URLs = append(URLs, repo.byID[id])
 	}
 
-	var entry models.ShortURL
-	var URLs []models.ShortURL
-
-	scanner := bufio.NewScanner(repo.file)
+	return selectUsersURLs(URLs, query), nil
+}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if err := json.NewDecoder(bytes.NewReader(line)).Decode(&entry); err != nil {
-			return nil, err
+// Close stops background work, flushes and syncs buffered data and closes file.
+func (repo *FileRepository) Close(_ context.Context) error {
+	repo.stopSyncOnce.Do(func() {
+		if repo.stopSync != nil {
+			close(repo.stopSync)
+			repo.syncer.Wait()
 		}
-		if entry.CreatedByID == userID {
-			URLs = append(URLs, entry)
+	})
+	repo.compactions.Wait()
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if repo.closed {
+		return nil
+	}
+	repo.closed = true
+	if err := repo.closeEvents(); err != nil {
+		return err
+	}
+	if err := repo.writer.Flush(); err != nil {
+		return err
+	}
+	if repo.syncMode != SyncNever {
+		if err := repo.file.Sync(); err != nil {
+			return err
 		}
 	}
-
-	return URLs, nil
+	return repo.file.Close()
 }
 
-// Close closes file.
-func (repo *FileRepository) Close(_ context.Context) error {
-	log.Info().Msgf("метод (типа FileRepository) Close")
-	return repo.file.Close()
+// closeEvents flushes, syncs and closes events file if it was opened.
+// Must be called with write lock held.
+func (repo *FileRepository) closeEvents() error {
+	if repo.eventsFile == nil {
+		return nil
+	}
+	if err := repo.eventsWriter.Flush(); err != nil {
+		return err
+	}
+	if repo.syncMode != SyncNever {
+		if err := repo.eventsFile.Sync(); err != nil {
+			return err
+		}
+	}
+	return repo.eventsFile.Close()
 }
 
 // Check checks if file is ok.
 func (repo *FileRepository) Check(_ context.Context) error {
-	log.Info().Msgf("метод (типа FileRepository) Check")
 	_, err := repo.file.Stat()
 	return err
 }
 
-// DeleteUrls deletes all given urls.
-func (repo *FileRepository) DeleteUrls(_ context.Context, urls []models.ShortURL) error {
-	log.Info().Msgf("метод (типа FileRepository) Delete Urls")
+// DeleteUrls marks all given urls as deleted and returns outcome of deletion of every url.
+// Every deletion is appended to the file as tombstone record.
+func (repo *FileRepository) DeleteUrls(_ context.Context, urls []models.ShortURL) ([]models.DeleteResult, error) {
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
 
-	existingURLs, err := repo.readFileToMap()
-	if err != nil {
-		return err
-	}
-
-	// mark deleted urls in memory
 	now := time.Now()
+	results := make([]models.DeleteResult, 0, len(urls))
+	deleted := make([]models.ShortURL, 0, len(urls))
+	deletedIDs := make(map[string]bool, len(urls))
 	for _, urlToDelete := range urls {
-		foundURL, ok := existingURLs[urlToDelete.ID]
-		if ok && foundURL.CreatedByID == urlToDelete.CreatedByID {
+		foundURL, ok := repo.byID[urlToDelete.ID]
+		result := deleteOutcome(foundURL, ok, urlToDelete.CreatedByID)
+		if result == models.DeleteResultDeleted && deletedIDs[foundURL.ID] {
+			result = models.DeleteResultAlreadyDeleted
+		}
+		if result == models.DeleteResultDeleted {
 			foundURL.DeletedAt = now
-			existingURLs[urlToDelete.ID] = foundURL
+			deleted = append(deleted, foundURL)
+			deletedIDs[foundURL.ID] = true
 		}
+		results = append(results, result)
 	}
 
-	// write back in memory map to file
-	err = repo.writeMapToFile(existingURLs)
-	if err != nil {
+	if err := repo.markDeleted(deleted); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DeleteExpired marks urls that are expired at the moment now as deleted.
+// Every deletion is appended to the file as tombstone record.
+func (repo *FileRepository) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	var expired []models.ShortURL
+	for _, url := range repo.byID {
+		if url.DeletedAt.IsZero() && isExpired(url, now) {
+			url.DeletedAt = now
+			expired = append(expired, url)
+		}
+	}
+
+	if err := repo.markDeleted(expired); err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+// markDeleted appends tombstone records of deleted urls to the file and updates index.
+// Must be called with write lock held.
+func (repo *FileRepository) markDeleted(deleted []models.ShortURL) error {
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	for _, shortURL := range deleted {
+		if err := writeJSONLine(repo.writer, tombstone{Op: opDelete, ID: shortURL.ID, DeletedAt: shortURL.DeletedAt}); err != nil {
+			return err
+		}
+	}
+	if err := repo.commit(); err != nil {
 		return err
 	}
 
+	for _, shortURL := range deleted {
+		repo.byID[shortURL.ID] = shortURL
+	}
+	repo.garbage += len(deleted)
+	repo.compactInBackgroundIfNeeded()
+
 	return nil
 }
 
-// readFileToMap reads the file and returns a map of all the urls in the file.
-func (repo *FileRepository) readFileToMap() (map[string]models.ShortURL, error) {
-	log.Info().Msgf("метод (типа FileRepository) readFileToMap")
-	if _, err := repo.file.Seek(0, io.SeekStart); err != nil {
+// RestoreUrls clears deletion time of given urls deleted at deletedSince or later by their creators.
+// Every restoration is appended to the file as restore record.
+func (repo *FileRepository) RestoreUrls(_ context.Context, urls []models.ShortURL, deletedSince time.Time) ([]string, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	var restored []string
+	for _, urlToRestore := range urls {
+		foundURL, ok := repo.byID[urlToRestore.ID]
+		if !ok || !isRestorable(foundURL, urlToRestore.CreatedByID, deletedSince) {
+			continue
+		}
+		if err := writeJSONLine(repo.writer, idRecord{Op: opRestore, ID: foundURL.ID}); err != nil {
+			return nil, err
+		}
+		restored = append(restored, foundURL.ID)
+	}
+	if len(restored) == 0 {
+		return nil, nil
+	}
+	if err := repo.commit(); err != nil {
 		return nil, err
 	}
-	var entry models.ShortURL
-	existingURLs := make(map[string]models.ShortURL)
 
-	scanner := bufio.NewScanner(repo.file)
+	for _, id := range restored {
+		url := repo.byID[id]
+		url.DeletedAt = time.Time{}
+		repo.byID[id] = url
+	}
+	repo.garbage += len(restored)
+	repo.compactInBackgroundIfNeeded()
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if err := json.NewDecoder(bytes.NewReader(line)).Decode(&entry); err != nil {
-			return nil, err
+	return restored, nil
+}
+
+// PurgeDeleted removes urls deleted before deletedBefore.
+// Every removal is appended to the file as purge record, events file is rewritten without events of removed urls.
+func (repo *FileRepository) PurgeDeleted(_ context.Context, deletedBefore time.Time) (int, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	purged := make(map[string]bool)
+	for id, url := range repo.byID {
+		if !isPurgeable(url, deletedBefore) {
+			continue
+		}
+		if err := writeJSONLine(repo.writer, idRecord{Op: opPurge, ID: id}); err != nil {
+			return 0, err
+		}
+		purged[id] = true
+	}
+	if len(purged) == 0 {
+		return 0, nil
+	}
+	if err := repo.commit(); err != nil {
+		return 0, err
+	}
+
+	withClicks := false
+	for id := range purged {
+		repo.unindex(id)
+		if _, ok := repo.clicks[id]; ok {
+			withClicks = true
+			delete(repo.clicks, id)
+		}
+	}
+	repo.dropUnindexed()
+	// url record and purge record are outdated, tombstone was counted on deletion
+	repo.garbage += 2 * len(purged)
+	repo.compactInBackgroundIfNeeded()
+
+	if withClicks {
+		if err := repo.rewriteEvents(purged); err != nil {
+			return 0, err
 		}
-		existingURLs[entry.ID] = entry
 	}
-	return existingURLs, nil
+
+	return len(purged), nil
+}
+
+// SaveClickEvents appends events to events file and adds them to click stats of their urls.
+func (repo *FileRepository) SaveClickEvents(_ context.Context, events []models.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if err := repo.openEvents(); err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := writeJSONLine(repo.eventsWriter, event); err != nil {
+			return err
+		}
+	}
+	if err := repo.commitEvents(); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		addClickEvent(repo.clicks, event)
+	}
+
+	return nil
+}
+
+// GetClickStats returns click stats of url with id.
+func (repo *FileRepository) GetClickStats(_ context.Context, id string) (models.ClickStats, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	return repo.clicks[id].model(), nil
+}
+
+// GetStats aggregates stats of all the stored urls.
+func (repo *FileRepository) GetStats(_ context.Context, query models.StatsQuery) (models.Stats, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	return collectStats(repo.byID, repo.clicks, query), nil
+}
+
+// Compact rewrites the file so that it contains only actual records, in order of their creation.
+// Records are written to a temporary file which is synced and renamed over the storage file,
+// so the storage file is complete at any moment.
+func (repo *FileRepository) Compact(_ context.Context) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	return repo.compact()
+}
+
+// Export calls fn for every stored url with id greater than afterID in order of ids.
+// fn is called without lock held, so it can use the repository.
+func (repo *FileRepository) Export(_ context.Context, afterID string, fn func(models.ShortURL) error) error {
+	repo.mutex.RLock()
+	urls := make([]models.ShortURL, 0, len(repo.byID))
+	for _, url := range repo.byID {
+		urls = append(urls, url)
+	}
+	repo.mutex.RUnlock()
+
+	return exportSorted(urls, afterID, fn)
+}
+
+// NextSequence returns the next value of the counter.
+// Counter isn't stored in file, after reopening it continues from count of stored urls,
+// so after purging of urls values may repeat and ids made of them are resolved as collisions.
+func (repo *FileRepository) NextSequence(_ context.Context) (uint64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if repo.sequence < uint64(len(repo.ordered)) {
+		repo.sequence = uint64(len(repo.ordered))
+	}
+	repo.sequence++
+
+	return repo.sequence, nil
 }
 
-// writeMapToFile writes the map to the file.
-func (repo *FileRepository) writeMapToFile(existingURLs map[string]models.ShortURL) error {
-	log.Info().Msgf("метод (типа FileRepository) writeMapToFile")
-	if err := repo.file.Truncate(0); err != nil {
+// SeedSequence advances the counter so that the next value is greater than value.
+// Like the counter itself, seeded value isn't stored in file.
+func (repo *FileRepository) SeedSequence(_ context.Context, value uint64) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if repo.sequence < value {
+		repo.sequence = value
+	}
+
+	return nil
+}
+
+// ExportClickEvents reads events file and calls fn for every event of urls with given ids.
+func (repo *FileRepository) ExportClickEvents(_ context.Context, ids []string, fn func(models.ClickEvent) error) error {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	file, err := os.Open(repo.path + eventsSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
-	if _, err := repo.file.Seek(0, 0); err != nil {
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if event, ok := decodeClickEvent(line); ok && wanted[event.URLID] {
+			if err = fn(event); err != nil {
+				return err
+			}
+		}
+		if errors.Is(errRead, io.EOF) {
+			return nil
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+}
+
+func (repo *FileRepository) GetUsersAndUrlsCount(_ context.Context) (int, int, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	return len(repo.byUser), len(repo.byID), nil
+}
+
+// loadIndexes reads the file line by line and fills indexes.
+// When file contains several records with the same id, the latest one wins.
+// Records that can't be decoded are skipped, and if they are at the end of file,
+// they are considered torn by crash and truncated.
+func (repo *FileRepository) loadIndexes() error {
+	if _, err := repo.file.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
 
-	for _, url := range existingURLs {
+	reader := bufio.NewReader(repo.file)
+	var size, validSize int64
+	terminated := true
 
-		data, err := json.Marshal(url)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			size += int64(len(line))
+			if entry, ok := decodeRecord(line); ok {
+				if entry != nil {
+					repo.apply(*entry)
+				}
+				validSize = size
+				terminated = line[len(line)-1] == '\n'
+			} else {
+				log.Warn().Msgf("file storage %s: skipping corrupted record at offset %d", repo.path, size-int64(len(line)))
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
 		if err != nil {
 			return err
 		}
+	}
+
+	repo.dropUnindexed()
+
+	if validSize < size {
+		log.Warn().Msgf("file storage %s: truncating %d bytes of torn records at the end of file", repo.path, size-validSize)
+		if err := repo.file.Truncate(validSize); err != nil {
+			return err
+		}
+	}
+
+	if !terminated {
+		// last record is complete, but the line separator was not written
+		if err := repo.writer.WriteByte('\n'); err != nil {
+			return err
+		}
+		return repo.commit()
+	}
+
+	return nil
+}
+
+// loadClickEvents reads events file, if it exists, into click stats.
+// Events that can't be decoded are skipped.
+func (repo *FileRepository) loadClickEvents() error {
+	file, err := os.Open(repo.path + eventsSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			repo.eventsUnterminated = line[len(line)-1] != '\n'
+			if event, ok := decodeClickEvent(line); ok {
+				addClickEvent(repo.clicks, event)
+			} else if len(bytes.TrimSpace(line)) > 0 {
+				log.Warn().Msgf("file storage %s: skipping corrupted click event", repo.path+eventsSuffix)
+			}
+		}
+		if errors.Is(errRead, io.EOF) {
+			return nil
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+}
+
+// decodeClickEvent decodes line of events file. Returns false if line is blank or corrupted.
+func decodeClickEvent(line []byte) (models.ClickEvent, bool) {
+	var event models.ClickEvent
+	if err := json.Unmarshal(line, &event); err != nil || event.URLID == "" {
+		return models.ClickEvent{}, false
+	}
+	return event, true
+}
+
+// openEvents opens events file for appending if it isn't opened yet.
+// Must be called with write lock held.
+func (repo *FileRepository) openEvents() error {
+	if repo.eventsFile != nil {
+		return nil
+	}
+
+	file, err := os.OpenFile(repo.path+eventsSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o777) //nolint:gomnd
+	if err != nil {
+		return err
+	}
+	repo.eventsFile = file
+	repo.eventsWriter = bufio.NewWriter(file)
+
+	if repo.eventsUnterminated {
+		// event torn by crash must not be glued to the next one
+		repo.eventsUnterminated = false
+		return repo.eventsWriter.WriteByte('\n')
+	}
+	return nil
+}
+
+// decodeRecord decodes line of storage file.
+// Returns false if line is corrupted, nil record for blank lines.
+func decodeRecord(line []byte) (*fileRecord, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil, true
+	}
+
+	var entry fileRecord
+	if err := json.Unmarshal(line, &entry); err != nil || entry.ID == "" {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+// apply applies record read from file to indexes.
+func (repo *FileRepository) apply(entry fileRecord) {
+	existing, ok := repo.byID[entry.ID]
+	switch {
+	case entry.Op == opDelete:
+		repo.garbage++
+		if ok {
+			existing.DeletedAt = entry.DeletedAt
+			repo.byID[entry.ID] = existing
+		}
+	case entry.Op == opClick:
+		repo.garbage++
+		if ok {
+			existing.Clicks = entry.Clicks
+			repo.byID[entry.ID] = existing
+		}
+	case entry.Op == opRestore:
+		repo.garbage++
+		if ok {
+			existing.DeletedAt = time.Time{}
+			repo.byID[entry.ID] = existing
+		}
+	case entry.Op == opPurge:
+		repo.garbage++
+		if ok {
+			repo.garbage++
+			repo.unindex(entry.ID)
+			delete(repo.clicks, entry.ID)
+		}
+	case ok:
+		repo.garbage++
+		repo.byID[entry.ID] = entry.ShortURL
+	default:
+		repo.index(entry.ShortURL)
+	}
+}
+
+// lookup returns stored url with the same original url in the same unique scope or the same id.
+func (repo *FileRepository) lookup(shortURL models.ShortURL) (models.ShortURL, bool) {
+	if id, ok := repo.byURL[UniqueKey(shortURL)]; ok {
+		return repo.byID[id], true
+	}
+	if stored, ok := repo.byID[shortURL.ID]; ok {
+		return stored, true
+	}
+	return models.ShortURL{}, false
+}
+
+// index adds new url to indexes.
+func (repo *FileRepository) index(shortURL models.ShortURL) {
+	repo.byID[shortURL.ID] = shortURL
+	repo.byURL[UniqueKey(shortURL)] = shortURL.ID
+	repo.byUser[shortURL.CreatedByID] = append(repo.byUser[shortURL.CreatedByID], shortURL.ID)
+	repo.ordered = append(repo.ordered, shortURL.ID)
+}
+
+// unindex removes url with id from indexes by id and by unique key.
+// Ids in ordered indexes are left until dropUnindexed, so removal of many urls doesn't rescan them for every url.
+func (repo *FileRepository) unindex(id string) {
+	url := repo.byID[id]
+	delete(repo.byID, id)
+	if repo.byURL[UniqueKey(url)] == id {
+		delete(repo.byURL, UniqueKey(url))
+	}
+}
+
+// dropUnindexed removes ids of unindexed urls from ordered indexes.
+// Id of url that was removed and saved again is kept once, in the place of the new url.
+func (repo *FileRepository) dropUnindexed() {
+	if len(repo.ordered) == len(repo.byID) {
+		return
+	}
+
+	seen := make(map[string]bool, len(repo.byID))
+	ordered := repo.ordered[:0]
+	for i := len(repo.ordered) - 1; i >= 0; i-- {
+		id := repo.ordered[i]
+		if _, ok := repo.byID[id]; ok && !seen[id] {
+			seen[id] = true
+			ordered = append(ordered, id)
+		}
+	}
+	for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	}
+	repo.ordered = ordered
 
-		if _, errWrite := repo.writer.Write(data); errWrite != nil {
-			return errWrite
+	for userID, ids := range repo.byUser {
+		owned := ids[:0]
+		for _, id := range ids {
+			if url, ok := repo.byID[id]; ok && url.CreatedByID == userID {
+				owned = append(owned, id)
+			}
 		}
+		if len(owned) == 0 {
+			delete(repo.byUser, userID)
+			continue
+		}
+		repo.byUser[userID] = owned
+	}
+}
 
-		if errWriteByte := repo.writer.WriteByte('\n'); errWriteByte != nil {
-			return errWriteByte
+// rewriteEvents replaces events file with file without events of purged urls.
+// Corrupted events are dropped too. Must be called with write lock held.
+func (repo *FileRepository) rewriteEvents(purged map[string]bool) error {
+	if repo.eventsFile != nil {
+		if err := repo.eventsWriter.Flush(); err != nil {
+			return err
 		}
+	}
 
+	path := repo.path + eventsSuffix
+	source, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
 	}
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	tmpPath := path + compactionSuffix
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o777) //nolint:gomnd
+	if err != nil {
+		return err
+	}
+	if err = writeEventsWithout(tmp, source, purged); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err = syncDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	// events file is reopened on the next event
+	if repo.eventsFile != nil {
+		_ = repo.eventsFile.Close()
+		repo.eventsFile = nil
+		repo.eventsWriter = nil
+	}
+	repo.eventsUnterminated = false
+
+	return nil
+}
+
+// writeEventsWithout copies events from source to file, except events of purged urls, and syncs file to disk.
+func writeEventsWithout(file *os.File, source io.Reader, purged map[string]bool) error {
+	reader := bufio.NewReader(source)
+	writer := bufio.NewWriter(file)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if event, ok := decodeClickEvent(line); ok && !purged[event.URLID] {
+			if err := writeJSONLine(writer, event); err != nil {
+				return err
+			}
+		}
+		if errors.Is(errRead, io.EOF) {
+			break
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// commit flushes buffered data to file and syncs it according to sync mode.
+// Must be called with write lock held.
+func (repo *FileRepository) commit() error {
 	if err := repo.writer.Flush(); err != nil {
 		return err
 	}
+
+	if repo.syncMode == SyncAlways {
+		return repo.file.Sync()
+	}
+	repo.dirty = true
+
+	return nil
+}
+
+// commitEvents flushes buffered events to events file and syncs it according to sync mode.
+// Must be called with write lock held.
+func (repo *FileRepository) commitEvents() error {
+	if err := repo.eventsWriter.Flush(); err != nil {
+		return err
+	}
+
+	if repo.syncMode == SyncAlways {
+		return repo.eventsFile.Sync()
+	}
+	repo.dirty = true
+
 	return nil
 }
 
-func (repo *FileRepository) GetUsersAndUrlsCount(_ context.Context) (int, int, error) {
-	log.Info().Msgf("метод (типа FileRepository) GetUsersAndUrlsCount")
-	if _, err := repo.file.Seek(0, io.SeekStart); err != nil {
-		return 0, 0, err
+// syncPeriodically syncs written data to disk every sync interval until stopped.
+func (repo *FileRepository) syncPeriodically() {
+	defer repo.syncer.Done()
+
+	ticker := time.NewTicker(repo.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-repo.stopSync:
+			return
+		case <-ticker.C:
+			repo.mutex.Lock()
+			if repo.dirty {
+				if err := repo.sync(); err != nil {
+					log.Error().Err(err).Msg("file storage sync failed")
+				} else {
+					repo.dirty = false
+				}
+			}
+			repo.mutex.Unlock()
+		}
 	}
+}
 
-	uniqueUsersIds := make(map[string]bool)
-	urlsCount := 0
+// sync syncs storage file and events file to disk. Must be called with write lock held.
+func (repo *FileRepository) sync() error {
+	if err := repo.file.Sync(); err != nil {
+		return err
+	}
+	if repo.eventsFile != nil {
+		return repo.eventsFile.Sync()
+	}
+	return nil
+}
+
+// compactInBackgroundIfNeeded starts compaction in background
+// when outdated records take up more than a half of file.
+// Must be called with write lock held.
+func (repo *FileRepository) compactInBackgroundIfNeeded() {
+	if repo.compacting || repo.garbage < repo.compactionThreshold || repo.garbage < len(repo.ordered) {
+		return
+	}
+
+	repo.compacting = true
+	repo.compactions.Add(1)
+	go func() {
+		defer repo.compactions.Done()
 
-	var entry models.ShortURL
-	scanner := bufio.NewScanner(repo.file)
+		repo.mutex.Lock()
+		defer repo.mutex.Unlock()
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if err := json.NewDecoder(bytes.NewReader(line)).Decode(&entry); err != nil {
-			return 0, 0, err
+		repo.compacting = false
+		if repo.closed {
+			return
+		}
+		if err := repo.compact(); err != nil {
+			log.Error().Err(err).Msg("file storage compaction failed")
 		}
-		urlsCount++
-		uniqueUsersIds[entry.CreatedByID] = true
+	}()
+}
+
+// compact writes actual records to temporary file and replaces storage file with it.
+// Must be called with write lock held.
+func (repo *FileRepository) compact() error {
+	if err := repo.writer.Flush(); err != nil {
+		return err
+	}
+
+	// compacted file is opened for appending before renaming, so once it replaces the storage file
+	// nothing can fail before later records are written to it instead of the unlinked old file
+	tmpPath := repo.path + compactionSuffix
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o777) //nolint:gomnd
+	if err != nil {
+		return err
+	}
+
+	if err = writeCompacted(tmp, repo.ordered, repo.byID); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err = os.Rename(tmpPath, repo.path); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	_ = repo.file.Close()
+	repo.file = tmp
+	repo.writer.Reset(tmp)
+	repo.garbage = 0
+	repo.dirty = false
+
+	return syncDir(filepath.Dir(repo.path))
+}
+
+// writeCompacted writes urls to file in given order and syncs file to disk.
+func writeCompacted(file *os.File, ids []string, urls map[string]models.ShortURL) error {
+	writer := bufio.NewWriter(file)
+	for _, id := range ids {
+		if err := writeJSONLine(writer, urls[id]); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// syncDir syncs directory to make renaming of file in it durable.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+// writeJSONLine writes value as json line to the buffered writer.
+func writeJSONLine(writer *bufio.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err = writer.Write(data); err != nil {
+		return err
 	}
 
-	return len(uniqueUsersIds), urlsCount, nil
+	return writer.WriteByte('\n')
 }
@@ -1,9 +1,11 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/stretchr/testify/assert"
@@ -63,7 +65,7 @@ func TestFileRepository_GetByID(t *testing.T) {
 			} else {
 				assert.Error(t, errGet)
 			}
-			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want, withoutCreatedAt(got)[0])
 		})
 	}
 }
@@ -130,18 +132,19 @@ func TestFileRepository_Save(t *testing.T) {
 			}
 			savedURL, errGet := repo.GetByID(context.Background(), tt.arg.ID)
 			assert.NoError(t, errGet)
-			assert.Equal(t, tt.wantSaved, savedURL)
+			assert.Equal(t, tt.wantSaved, withoutCreatedAt(savedURL)[0])
 		})
 	}
 }
 
 func TestFileRepository_SaveBatch(t *testing.T) {
 	tests := []struct {
-		name      string
-		userID    string
-		arg       []models.ShortURL
-		wantSaved []models.ShortURL
-		wantErr   bool
+		wantExisting map[int]models.ShortURL
+		name         string
+		userID       string
+		arg          []models.ShortURL
+		wantSaved    []models.ShortURL
+		wantErr      bool
 	}{
 		{
 			name: "save new urls",
@@ -190,6 +193,47 @@ func TestFileRepository_SaveBatch(t *testing.T) {
 			wantErr:   true,
 			userID:    "use2r",
 		},
+		{
+			name: "save batch with existing urls skips only them",
+			arg: []models.ShortURL{
+				{
+					OriginalURL: "existing url",
+					ID:          "another id",
+					CreatedByID: "user3",
+				},
+				{
+					OriginalURL: "new url3",
+					ID:          "new id3",
+					CreatedByID: "user3",
+				},
+				{
+					OriginalURL: "new url3",
+					ID:          "new id3",
+					CreatedByID: "user3",
+				},
+			},
+			wantSaved: []models.ShortURL{
+				{
+					OriginalURL: "new url3",
+					ID:          "new id3",
+					CreatedByID: "user3",
+				},
+			},
+			wantExisting: map[int]models.ShortURL{
+				0: {
+					OriginalURL: "existing url",
+					ID:          "existing id",
+					CreatedByID: "user existed",
+				},
+				2: {
+					OriginalURL: "new url3",
+					ID:          "new id3",
+					CreatedByID: "user3",
+				},
+			},
+			wantErr: true,
+			userID:  "user3",
+		},
 	}
 
 	filename := "./test_save_batch.json"
@@ -215,13 +259,17 @@ func TestFileRepository_SaveBatch(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			errSave := repo.SaveBatch(context.Background(), tt.arg)
 			if tt.wantErr {
-				assert.Error(t, errSave)
+				var notUniqueErr *NotUniqueBatchError
+				require.ErrorAs(t, errSave, &notUniqueErr)
+				if tt.wantExisting != nil {
+					assert.Equal(t, tt.wantExisting, mapWithoutCreatedAt(notUniqueErr.Existing))
+				}
 			} else {
 				assert.NoError(t, errSave)
 			}
-			savedURLs, errGet := repo.GetUsersUrls(context.Background(), tt.userID)
+			savedURLs, errGet := repo.GetUsersUrls(context.Background(), tt.userID, models.UsersURLsQuery{})
 			assert.NoError(t, errGet)
-			assert.Equal(t, tt.wantSaved, savedURLs)
+			assert.Equal(t, tt.wantSaved, withoutCreatedAt(savedURLs...))
 		})
 	}
 }
@@ -432,33 +480,29 @@ func TestFileRepository_DeleteUrls(t *testing.T) {
 		},
 	}
 
-	filename := "./test_delete.json"
-	repo, err := NewFileRepository(filename)
-	require.NoError(t, err)
-	defer func(repo *FileRepository) {
-		errClose := repo.Close(context.Background())
-		require.NoError(t, errClose)
-	}(repo)
-	defer func(name string) {
-		errRemove := os.Remove(name)
-		require.NoError(t, errRemove)
-	}(filename)
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err = repo.file.Truncate(0)
-			require.NoError(t, err)
-			_, err = repo.file.Seek(0, 0)
+			filename := "./test_delete.json"
+			repo, err := NewFileRepository(filename)
 			require.NoError(t, err)
+			defer func(name string) {
+				errRemove := os.Remove(name)
+				require.NoError(t, errRemove)
+			}(filename)
+			defer func(repo *FileRepository) {
+				errClose := repo.Close(context.Background())
+				require.NoError(t, errClose)
+			}(repo)
+
 			err = repo.SaveBatch(context.Background(), tt.fields.storage)
 			require.NoError(t, err)
 
-			err = repo.DeleteUrls(context.Background(), tt.args.urls)
+			_, err = repo.DeleteUrls(context.Background(), tt.args.urls)
 			assert.NoError(t, err)
 
 			for _, url := range tt.wantDeleted {
 				deleted, errGet := repo.GetByID(context.Background(), url.ID)
-				assert.NoError(t, errGet)
+				assert.ErrorIs(t, errGet, ErrDeleted)
 				assert.False(t, deleted.DeletedAt.IsZero())
 			}
 
@@ -571,9 +615,9 @@ func TestFileRepository_GetUsersUrls(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, errGet := repo.GetUsersUrls(context.Background(), tt.args.userID)
+			got, errGet := repo.GetUsersUrls(context.Background(), tt.args.userID, models.UsersURLsQuery{})
 			require.NoError(t, errGet)
-			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want, withoutCreatedAt(got...))
 		})
 	}
 }
@@ -619,3 +663,398 @@ func TestFileRepository_GetUsersAndUrlsCount(t *testing.T) {
 		assert.Equal(t, 3, urlsCount)
 	})
 }
+
+func TestFileRepository_LoadsIndexesOnOpen(t *testing.T) {
+	filename := "./test_load_indexes.json"
+	defer func(name string) {
+		errRemove := os.Remove(name)
+		require.NoError(t, errRemove)
+	}(filename)
+
+	repo, err := NewFileRepository(filename)
+	require.NoError(t, err)
+
+	err = repo.SaveBatch(context.Background(), []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user"},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user2"},
+	})
+	require.NoError(t, err)
+
+	_, err = repo.DeleteUrls(context.Background(), []models.ShortURL{{ID: "id2", CreatedByID: "user"}})
+	require.NoError(t, err)
+
+	err = repo.Close(context.Background())
+	require.NoError(t, err)
+
+	reopened, err := NewFileRepository(filename)
+	require.NoError(t, err)
+	defer func(repo *FileRepository) {
+		errClose := repo.Close(context.Background())
+		require.NoError(t, errClose)
+	}(reopened)
+
+	usersURLs, err := reopened.GetUsersUrls(context.Background(), "user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	require.Len(t, usersURLs, 2)
+	assert.Equal(t, "id", usersURLs[0].ID)
+	assert.True(t, usersURLs[0].DeletedAt.IsZero())
+	assert.Equal(t, "id2", usersURLs[1].ID)
+	assert.False(t, usersURLs[1].DeletedAt.IsZero())
+
+	usersCount, urlsCount, err := reopened.GetUsersAndUrlsCount(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, usersCount)
+	assert.Equal(t, 3, urlsCount)
+
+	err = reopened.Save(context.Background(), models.ShortURL{OriginalURL: "url3", ID: "other id", CreatedByID: "user3"})
+	var notUniqueErr *NotUniqueURLError
+	assert.ErrorAs(t, err, &notUniqueErr)
+}
+
+func TestFileRepository_Compact(t *testing.T) {
+	filename := "./test_compact.json"
+	defer func(name string) {
+		errRemove := os.Remove(name)
+		require.NoError(t, errRemove)
+	}(filename)
+
+	repo, err := NewFileRepository(filename)
+	require.NoError(t, err)
+
+	err = repo.SaveBatch(context.Background(), []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user"},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user2"},
+	})
+	require.NoError(t, err)
+
+	_, err = repo.DeleteUrls(context.Background(), []models.ShortURL{{ID: "id2", CreatedByID: "user"}})
+	require.NoError(t, err)
+	assert.Equal(t, 4, countLines(t, filename), "deletion must be appended as tombstone")
+
+	err = repo.Compact(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, countLines(t, filename))
+
+	err = repo.Save(context.Background(), models.ShortURL{OriginalURL: "url4", ID: "id4", CreatedByID: "user"})
+	require.NoError(t, err)
+
+	err = repo.Close(context.Background())
+	require.NoError(t, err)
+
+	reopened, err := NewFileRepository(filename)
+	require.NoError(t, err)
+	defer func(repo *FileRepository) {
+		errClose := repo.Close(context.Background())
+		require.NoError(t, errClose)
+	}(reopened)
+
+	usersURLs, err := reopened.GetUsersUrls(context.Background(), "user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	require.Len(t, usersURLs, 3)
+	assert.Equal(t, "id", usersURLs[0].ID)
+	assert.Equal(t, "id2", usersURLs[1].ID)
+	assert.False(t, usersURLs[1].DeletedAt.IsZero())
+	assert.Equal(t, "id4", usersURLs[2].ID)
+}
+
+func TestFileRepository_ClicksSurviveReopen(t *testing.T) {
+	filename := "./test_clicks.json"
+	defer func(name string) {
+		errRemove := os.Remove(name)
+		require.NoError(t, errRemove)
+	}(filename)
+
+	repo, err := NewFileRepository(filename)
+	require.NoError(t, err)
+
+	err = repo.Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user", MaxClicks: 3})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = repo.Click(context.Background(), "id")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, countLines(t, filename), "clicks must be appended as separate records")
+
+	err = repo.Close(context.Background())
+	require.NoError(t, err)
+
+	reopened, err := NewFileRepository(filename)
+	require.NoError(t, err)
+	defer func(repo *FileRepository) {
+		errClose := repo.Close(context.Background())
+		require.NoError(t, errClose)
+	}(reopened)
+
+	clicked, err := reopened.Click(context.Background(), "id")
+	require.NoError(t, err)
+	assert.Equal(t, 3, clicked.Clicks)
+
+	_, err = reopened.Click(context.Background(), "id")
+	assert.ErrorIs(t, err, ErrClickLimitReached)
+
+	err = reopened.Compact(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, countLines(t, filename))
+
+	fetched, err := reopened.GetByID(context.Background(), "id")
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetched.Clicks)
+}
+
+func TestFileRepository_ClickEventsSurviveReopen(t *testing.T) {
+	filename := "./test_click_events.json"
+	defer func(names ...string) {
+		for _, name := range names {
+			errRemove := os.Remove(name)
+			require.NoError(t, errRemove)
+		}
+	}(filename, filename+eventsSuffix)
+
+	repo, err := NewFileRepository(filename)
+	require.NoError(t, err)
+
+	event := models.ClickEvent{Time: time.Now(), URLID: "id", UserAgent: "firefox", IPPrefix: "203.0.113.0/24"}
+	err = repo.SaveClickEvents(context.Background(), []models.ClickEvent{event, event})
+	require.NoError(t, err)
+	assert.Equal(t, 0, countLines(t, filename), "click events must not be written to storage file")
+
+	err = repo.Close(context.Background())
+	require.NoError(t, err)
+
+	// event torn by crash
+	events, err := os.OpenFile(filename+eventsSuffix, os.O_WRONLY|os.O_APPEND, 0o777)
+	require.NoError(t, err)
+	_, err = events.WriteString(`{"time":"2024-03-01T12:00:00Z","url_id":"i`)
+	require.NoError(t, err)
+	require.NoError(t, events.Close())
+
+	reopened, err := NewFileRepository(filename)
+	require.NoError(t, err)
+	defer func(repo *FileRepository) {
+		errClose := repo.Close(context.Background())
+		require.NoError(t, errClose)
+	}(reopened)
+
+	err = reopened.SaveClickEvents(context.Background(), []models.ClickEvent{event})
+	require.NoError(t, err)
+
+	stats, err := reopened.GetClickStats(context.Background(), "id")
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total, "torn event must be skipped and must not corrupt the next one")
+	assert.Equal(t, 1, stats.UniqueVisitors)
+}
+
+func TestFileRepository_CompactsInBackground(t *testing.T) {
+	filename := "./test_background_compact.json"
+	defer func(name string) {
+		errRemove := os.Remove(name)
+		require.NoError(t, errRemove)
+	}(filename)
+
+	repo, err := NewFileRepository(filename)
+	require.NoError(t, err)
+	repo.compactionThreshold = 1
+
+	err = repo.SaveBatch(context.Background(), []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user"},
+	})
+	require.NoError(t, err)
+
+	_, err = repo.DeleteUrls(context.Background(), []models.ShortURL{{ID: "id", CreatedByID: "user"}, {ID: "id2", CreatedByID: "user"}})
+	require.NoError(t, err)
+
+	err = repo.Close(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, countLines(t, filename))
+
+	reopened, err := NewFileRepository(filename)
+	require.NoError(t, err)
+	defer func(repo *FileRepository) {
+		errClose := repo.Close(context.Background())
+		require.NoError(t, errClose)
+	}(reopened)
+
+	for _, id := range []string{"id", "id2"} {
+		found, errGet := reopened.GetByID(context.Background(), id)
+		require.ErrorIs(t, errGet, ErrDeleted)
+		assert.False(t, found.DeletedAt.IsZero())
+	}
+}
+
+func TestFileRepository_RecoversFromInterruptedCompaction(t *testing.T) {
+	filename := "./test_interrupted_compact.json"
+	defer func(name string) {
+		errRemove := os.Remove(name)
+		require.NoError(t, errRemove)
+	}(filename)
+
+	repo, err := NewFileRepository(filename)
+	require.NoError(t, err)
+
+	err = repo.SaveBatch(context.Background(), []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user"},
+	})
+	require.NoError(t, err)
+	_, err = repo.DeleteUrls(context.Background(), []models.ShortURL{{ID: "id", CreatedByID: "user"}})
+	require.NoError(t, err)
+	err = repo.Close(context.Background())
+	require.NoError(t, err)
+
+	// compaction crashed after writing part of temporary file
+	err = os.WriteFile(filename+compactionSuffix, []byte(`{"id":"id","url":"url","created_by":"user"}`), 0o600)
+	require.NoError(t, err)
+
+	reopened, err := NewFileRepository(filename)
+	require.NoError(t, err)
+	defer func(repo *FileRepository) {
+		errClose := repo.Close(context.Background())
+		require.NoError(t, errClose)
+	}(reopened)
+
+	_, err = os.Stat(filename + compactionSuffix)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	usersURLs, err := reopened.GetUsersUrls(context.Background(), "user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	require.Len(t, usersURLs, 2)
+	assert.False(t, usersURLs[0].DeletedAt.IsZero())
+	assert.True(t, usersURLs[1].DeletedAt.IsZero())
+}
+
+func countLines(t *testing.T, filename string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestFileRepository_RecoversFromTornTail(t *testing.T) {
+	validContent := `{"url":"url","id":"id","created_by":"user"}` + "\n" +
+		`{"url":"url2","id":"id2","created_by":"user"}` + "\n"
+
+	tests := []struct {
+		name        string
+		content     string
+		wantContent string
+		wantIDs     []string
+	}{
+		{
+			name:        "partial json at the end",
+			content:     validContent + `{"url":"url3","id":"i`,
+			wantContent: validContent,
+			wantIDs:     []string{"id", "id2"},
+		},
+		{
+			name:        "several corrupted lines at the end",
+			content:     validContent + "{\"url\":\n\x00\x00\x00\n",
+			wantContent: validContent,
+			wantIDs:     []string{"id", "id2"},
+		},
+		{
+			name:        "complete record without line separator",
+			content:     validContent + `{"url":"url3","id":"id3","created_by":"user"}`,
+			wantContent: validContent + `{"url":"url3","id":"id3","created_by":"user"}` + "\n",
+			wantIDs:     []string{"id", "id2", "id3"},
+		},
+		{
+			name: "corrupted record in the middle",
+			content: `{"url":"url","id":"id","created_by":"user"}` + "\n" +
+				`{"url":"ur` + "\n" +
+				`{"url":"url2","id":"id2","created_by":"user"}` + "\n",
+			wantContent: `{"url":"url","id":"id","created_by":"user"}` + "\n" +
+				`{"url":"ur` + "\n" +
+				`{"url":"url2","id":"id2","created_by":"user"}` + "\n",
+			wantIDs: []string{"id", "id2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := "./test_torn_tail.json"
+			defer func(name string) {
+				errRemove := os.Remove(name)
+				require.NoError(t, errRemove)
+			}(filename)
+
+			err := os.WriteFile(filename, []byte(tt.content), 0o600)
+			require.NoError(t, err)
+
+			repo, err := NewFileRepository(filename)
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(filename)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContent, string(data))
+
+			err = repo.Save(context.Background(), models.ShortURL{OriginalURL: "new url", ID: "new id", CreatedByID: "user"})
+			require.NoError(t, err)
+			err = repo.Close(context.Background())
+			require.NoError(t, err)
+
+			reopened, err := NewFileRepository(filename)
+			require.NoError(t, err)
+			defer func(repo *FileRepository) {
+				errClose := repo.Close(context.Background())
+				require.NoError(t, errClose)
+			}(reopened)
+
+			usersURLs, err := reopened.GetUsersUrls(context.Background(), "user", models.UsersURLsQuery{})
+			require.NoError(t, err)
+			ids := make([]string, 0, len(usersURLs))
+			for _, url := range usersURLs {
+				ids = append(ids, url.ID)
+			}
+			assert.Equal(t, append(tt.wantIDs, "new id"), ids)
+		})
+	}
+}
+
+func TestFileRepository_SyncModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     SyncMode
+		interval time.Duration
+		wantErr  bool
+	}{
+		{name: "always", mode: SyncAlways},
+		{name: "interval", mode: SyncInterval, interval: time.Millisecond},
+		{name: "never", mode: SyncNever},
+		{name: "interval without duration", mode: SyncInterval, wantErr: true},
+		{name: "unknown mode", mode: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := "./test_sync_modes.json"
+			defer func(name string) {
+				errRemove := os.Remove(name)
+				require.NoError(t, errRemove)
+			}(filename)
+
+			repo, err := NewFileRepository(filename, WithSync(tt.mode, tt.interval))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			err = repo.Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user", CreatedAt: createdAt})
+			require.NoError(t, err)
+			time.Sleep(5 * tt.interval)
+
+			err = repo.Close(context.Background())
+			require.NoError(t, err)
+			assert.NoError(t, repo.Close(context.Background()), "closing twice must be safe")
+
+			data, err := os.ReadFile(filename)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"url":"url","id":"id","created_by":"user","created_at":"2024-03-01T12:00:00Z","deleted_at":"0001-01-01T00:00:00Z","expires_at":"0001-01-01T00:00:00Z","correlation_id":""}`, string(data))
+		})
+	}
+}
@@ -2,9 +2,8 @@ package storage
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
@@ -12,33 +11,42 @@ import (
 
 // InMemoryRepository is repository that uses memory for storage.
 type InMemoryRepository struct {
-	storage map[string]models.ShortURL // map that will store urls
-	mutex   sync.RWMutex               // read-write mutex that will be used to synchronize access to the storage map
+	storage  map[string]models.ShortURL // map that will store urls
+	byURL    map[string]string          // ids of stored urls by unique key, see UniqueKey
+	clicks   map[string]*clickStats     // click stats by url id
+	mutex    sync.RWMutex               // read-write mutex that will be used to synchronize access to the storage map
+	sequence uint64                     // last value of sequence, accessed atomically
 }
 
 // NewInMemoryRepository creates a new InMemoryRepository and returns a pointer to it.
 func NewInMemoryRepository() *InMemoryRepository {
 	return &InMemoryRepository{
 		storage: make(map[string]models.ShortURL),
+		byURL:   make(map[string]string),
+		clicks:  make(map[string]*clickStats),
 		mutex:   sync.RWMutex{},
 	}
 }
 
 // SaveBatch saves multiple urls.
-// Checks if the urls are unique and then saving them.
+// Urls that are already exist are skipped and reported with NotUniqueBatchError,
+// all other urls of the batch are saved.
 func (repo *InMemoryRepository) SaveBatch(_ context.Context, batch []models.ShortURL) error {
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
 
-	for _, shortURL := range batch {
-		_, ok := repo.storage[shortURL.ID]
-		if ok {
-			return NewNotUniqueURLError(shortURL, nil)
+	existing := make(map[int]models.ShortURL)
+	for i, shortURL := range batch {
+		shortURL.CreatedAt = creationTime(shortURL)
+		if stored, ok := repo.lookup(shortURL); ok {
+			existing[i] = stored
+			continue
 		}
+		repo.store(shortURL)
 	}
 
-	for _, shortURL := range batch {
-		repo.storage[shortURL.ID] = shortURL
+	if len(existing) > 0 {
+		return NewNotUniqueBatchError(existing)
 	}
 
 	return nil
@@ -46,42 +54,79 @@ func (repo *InMemoryRepository) SaveBatch(_ context.Context, batch []models.Shor
 
 // Save checks if the url is unique and then saving it to the memory.
 func (repo *InMemoryRepository) Save(_ context.Context, shortURL models.ShortURL) error {
-	repo.mutex.RLock()
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
 	// "Comma-ok" idiom используется в Go везде, где операция может иметь два возможных исхода, которые невозможно однозначно интерпретировать,
 	// основываясь только на возвращаемом значении:
 	// 1. Поиск/Извлечение: (Map, Каналы, reflect). Проверка наличия элемента или того, что канал не закрыт.
 	// 2. Проверка соответствия: (Type Assertion). Проверка того, соответствует ли базовый тип интерфейса ожидаемому конкретному типу.
 	// 3...
-	_, ok := repo.storage[shortURL.ID]
-	repo.mutex.RUnlock()
-
-	if ok {
-		return NewNotUniqueURLError(shortURL, nil)
+	if stored, ok := repo.lookup(shortURL); ok {
+		return NewNotUniqueURLError(shortURL, stored, nil)
 	}
 
-	repo.mutex.Lock()
-	repo.storage[shortURL.ID] = shortURL
-	repo.mutex.Unlock()
+	shortURL.CreatedAt = creationTime(shortURL)
+	repo.store(shortURL)
 
 	return nil
 }
 
+// lookup returns stored url with the same original url in the same unique scope or the same id.
+// Must be called with lock held.
+func (repo *InMemoryRepository) lookup(shortURL models.ShortURL) (models.ShortURL, bool) {
+	if id, ok := repo.byURL[UniqueKey(shortURL)]; ok {
+		return repo.storage[id], true
+	}
+	if stored, ok := repo.storage[shortURL.ID]; ok {
+		return stored, true
+	}
+	return models.ShortURL{}, false
+}
+
+// store saves url to the map. Must be called with write lock held.
+func (repo *InMemoryRepository) store(shortURL models.ShortURL) {
+	repo.storage[shortURL.ID] = shortURL
+	repo.byURL[UniqueKey(shortURL)] = shortURL.ID
+}
+
 // GetByID gets the url by id.
 func (repo *InMemoryRepository) GetByID(_ context.Context, id string) (models.ShortURL, error) {
-	fmt.Println("или тут GetByID?")
 	repo.mutex.RLock()
 	url, ok := repo.storage[id]
 	repo.mutex.RUnlock()
 
 	if !ok {
-		return models.ShortURL{}, errors.New("can't find full url by id")
+		return models.ShortURL{}, ErrNotFound
+	}
+
+	return checkAvailable(url)
+}
+
+// Click gets the url by id and counts the redirect if url has click limit.
+func (repo *InMemoryRepository) Click(_ context.Context, id string) (models.ShortURL, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	url, ok := repo.storage[id]
+	if !ok {
+		return models.ShortURL{}, ErrNotFound
+	}
+	if _, err := checkAvailable(url); err != nil {
+		return url, err
 	}
 
+	url, err := countClick(url)
+	if err != nil {
+		return url, err
+	}
+	repo.storage[id] = url
+
 	return url, nil
 }
 
-// GetUsersUrls gets all the urls that were created by the user with the given id.
-func (repo *InMemoryRepository) GetUsersUrls(_ context.Context, userID string) ([]models.ShortURL, error) {
+// GetUsersUrls gets the urls that were created by the user with the given id and match the query.
+func (repo *InMemoryRepository) GetUsersUrls(_ context.Context, userID string, query models.UsersURLsQuery) ([]models.ShortURL, error) {
 	repo.mutex.RLock()
 	var URLs []models.ShortURL
 	for _, URL := range repo.storage {
@@ -90,12 +135,16 @@ func (repo *InMemoryRepository) GetUsersUrls(_ context.Context, userID string) (
 		}
 	}
 	repo.mutex.RUnlock()
-	return URLs, nil
+	return selectUsersURLs(URLs, query), nil
 }
 
 // Close clears map.
 func (repo *InMemoryRepository) Close(_ context.Context) error {
+	repo.mutex.Lock()
 	repo.storage = make(map[string]models.ShortURL)
+	repo.byURL = make(map[string]string)
+	repo.clicks = make(map[string]*clickStats)
+	repo.mutex.Unlock()
 	return nil
 }
 
@@ -104,23 +153,138 @@ func (repo *InMemoryRepository) Check(_ context.Context) error {
 	return nil
 }
 
-// DeleteUrls deletes all given urls.
-func (repo *InMemoryRepository) DeleteUrls(_ context.Context, urls []models.ShortURL) error {
+// DeleteUrls deletes all given urls and returns outcome of deletion of every url.
+func (repo *InMemoryRepository) DeleteUrls(_ context.Context, urls []models.ShortURL) ([]models.DeleteResult, error) {
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
 
 	now := time.Now()
+	results := make([]models.DeleteResult, 0, len(urls))
 	for _, urlToDelete := range urls {
 		foundURL, ok := repo.storage[urlToDelete.ID]
-		if ok && foundURL.CreatedByID == urlToDelete.CreatedByID {
+		result := deleteOutcome(foundURL, ok, urlToDelete.CreatedByID)
+		if result == models.DeleteResultDeleted {
 			foundURL.DeletedAt = now
 			repo.storage[urlToDelete.ID] = foundURL
 		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// DeleteExpired marks urls that are expired at the moment now as deleted.
+func (repo *InMemoryRepository) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	count := 0
+	for id, url := range repo.storage {
+		if url.DeletedAt.IsZero() && isExpired(url, now) {
+			url.DeletedAt = now
+			repo.storage[id] = url
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// RestoreUrls clears deletion time of given urls deleted at deletedSince or later by their creators.
+func (repo *InMemoryRepository) RestoreUrls(_ context.Context, urls []models.ShortURL, deletedSince time.Time) ([]string, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	var restored []string
+	for _, urlToRestore := range urls {
+		foundURL, ok := repo.storage[urlToRestore.ID]
+		if ok && isRestorable(foundURL, urlToRestore.CreatedByID, deletedSince) {
+			foundURL.DeletedAt = time.Time{}
+			repo.storage[urlToRestore.ID] = foundURL
+			restored = append(restored, urlToRestore.ID)
+		}
+	}
+
+	return restored, nil
+}
+
+// PurgeDeleted removes urls deleted before deletedBefore along with their click stats.
+func (repo *InMemoryRepository) PurgeDeleted(_ context.Context, deletedBefore time.Time) (int, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	count := 0
+	for id, url := range repo.storage {
+		if !isPurgeable(url, deletedBefore) {
+			continue
+		}
+		delete(repo.storage, id)
+		if repo.byURL[UniqueKey(url)] == id {
+			delete(repo.byURL, UniqueKey(url))
+		}
+		delete(repo.clicks, id)
+		count++
+	}
+
+	return count, nil
+}
+
+// SaveClickEvents adds events to click stats of their urls.
+func (repo *InMemoryRepository) SaveClickEvents(_ context.Context, events []models.ClickEvent) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for _, event := range events {
+		addClickEvent(repo.clicks, event)
 	}
 
 	return nil
 }
 
+// GetClickStats returns click stats of url with id.
+func (repo *InMemoryRepository) GetClickStats(_ context.Context, id string) (models.ClickStats, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	return repo.clicks[id].model(), nil
+}
+
+// GetStats aggregates stats of all the stored urls.
+func (repo *InMemoryRepository) GetStats(_ context.Context, query models.StatsQuery) (models.Stats, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	return collectStats(repo.storage, repo.clicks, query), nil
+}
+
+// Export calls fn for every stored url with id greater than afterID in order of ids.
+// fn is called without lock held, so it can use the repository.
+func (repo *InMemoryRepository) Export(_ context.Context, afterID string, fn func(models.ShortURL) error) error {
+	repo.mutex.RLock()
+	urls := make([]models.ShortURL, 0, len(repo.storage))
+	for _, url := range repo.storage {
+		urls = append(urls, url)
+	}
+	repo.mutex.RUnlock()
+
+	return exportSorted(urls, afterID, fn)
+}
+
+// NextSequence returns the next value of the counter.
+func (repo *InMemoryRepository) NextSequence(_ context.Context) (uint64, error) {
+	return atomic.AddUint64(&repo.sequence, 1), nil
+}
+
+// SeedSequence advances the counter so that the next value is greater than value.
+func (repo *InMemoryRepository) SeedSequence(_ context.Context, value uint64) error {
+	for {
+		current := atomic.LoadUint64(&repo.sequence)
+		if current >= value || atomic.CompareAndSwapUint64(&repo.sequence, current, value) {
+			return nil
+		}
+	}
+}
+
 func (repo *InMemoryRepository) GetUsersAndUrlsCount(_ context.Context) (int, int, error) {
 	uniqueUsersIds := make(map[string]bool)
 
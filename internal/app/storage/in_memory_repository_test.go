@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/stretchr/testify/assert"
@@ -13,11 +14,12 @@ func TestInMemoryRepository_GetByID(t *testing.T) {
 	type args struct {
 		id string
 	}
+	deletedAt := time.Now()
 	tests := []struct {
+		wantErr error
 		name    string
 		args    args
 		want    models.ShortURL
-		wantErr bool
 	}{
 		{
 			name: "get existing id",
@@ -26,31 +28,40 @@ func TestInMemoryRepository_GetByID(t *testing.T) {
 				OriginalURL: "some url",
 				ID:          "id",
 			},
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:    "get missing id",
 			args:    args{id: "missing"},
 			want:    models.ShortURL{},
-			wantErr: true,
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "get deleted id",
+			args: args{id: "deleted"},
+			want: models.ShortURL{
+				OriginalURL: "deleted url",
+				ID:          "deleted",
+				DeletedAt:   deletedAt,
+			},
+			wantErr: ErrDeleted,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := &InMemoryRepository{
-				storage: map[string]models.ShortURL{
-					"id": {
-						OriginalURL: "some url",
-						ID:          "id",
-					},
+			repo := newInMemoryRepositoryWith(map[string]models.ShortURL{
+				"id": {
+					OriginalURL: "some url",
+					ID:          "id",
 				},
-			}
+				"deleted": {
+					OriginalURL: "deleted url",
+					ID:          "deleted",
+					DeletedAt:   deletedAt,
+				},
+			})
 			got, err := repo.GetByID(context.Background(), tt.args.id)
-			if !tt.wantErr {
-				require.NoError(t, err)
-			} else {
-				assert.Error(t, err)
-			}
+			assert.ErrorIs(t, err, tt.wantErr)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -112,16 +123,14 @@ func TestInMemoryRepository_Save(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := &InMemoryRepository{
-				storage: tt.fields.storage,
-			}
+			repo := newInMemoryRepositoryWith(tt.fields.storage)
 			err := repo.Save(context.Background(), tt.arg)
 			if !tt.wantErr {
 				require.NoError(t, err)
 				assert.Equal(t, tt.arg.OriginalURL, repo.storage[tt.arg.ID].OriginalURL)
 				assert.Contains(t, repo.storage, tt.arg.ID)
 			}
-			assert.Equal(t, tt.wantStorage, repo.storage)
+			assert.Equal(t, tt.wantStorage, mapWithoutCreatedAt(repo.storage))
 		})
 	}
 }
@@ -131,11 +140,12 @@ func TestInMemoryRepository_SaveBatch(t *testing.T) {
 		storage map[string]models.ShortURL
 	}
 	tests := []struct {
-		fields      fields
-		wantStorage map[string]models.ShortURL
-		name        string
-		arg         []models.ShortURL
-		wantErr     bool
+		fields       fields
+		wantStorage  map[string]models.ShortURL
+		wantExisting map[int]models.ShortURL
+		name         string
+		arg          []models.ShortURL
+		wantErr      bool
 	}{
 		{
 			name:   "save new url with id",
@@ -195,20 +205,88 @@ func TestInMemoryRepository_SaveBatch(t *testing.T) {
 					ID:          "id",
 					CreatedByID: "1",
 				},
+				"id2": {
+					OriginalURL: "url2",
+					ID:          "id2",
+					CreatedByID: "2",
+				},
+			},
+			wantExisting: map[int]models.ShortURL{
+				0: {
+					OriginalURL: "some url",
+					ID:          "id",
+					CreatedByID: "1",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "save batch with existing url and duplicates inside",
+			fields: fields{
+				storage: map[string]models.ShortURL{
+					"id": {
+						OriginalURL: "url",
+						ID:          "id",
+						CreatedByID: "1",
+					},
+				},
+			},
+			arg: []models.ShortURL{
+				{
+					OriginalURL: "url",
+					ID:          "other id",
+					CreatedByID: "2",
+				},
+				{
+					OriginalURL: "url2",
+					ID:          "id2",
+					CreatedByID: "2",
+				},
+				{
+					OriginalURL: "url2",
+					ID:          "id2",
+					CreatedByID: "2",
+				},
+			},
+			wantStorage: map[string]models.ShortURL{
+				"id": {
+					OriginalURL: "url",
+					ID:          "id",
+					CreatedByID: "1",
+				},
+				"id2": {
+					OriginalURL: "url2",
+					ID:          "id2",
+					CreatedByID: "2",
+				},
+			},
+			wantExisting: map[int]models.ShortURL{
+				0: {
+					OriginalURL: "url",
+					ID:          "id",
+					CreatedByID: "1",
+				},
+				2: {
+					OriginalURL: "url2",
+					ID:          "id2",
+					CreatedByID: "2",
+				},
 			},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := &InMemoryRepository{
-				storage: tt.fields.storage,
-			}
+			repo := newInMemoryRepositoryWith(tt.fields.storage)
 			err := repo.SaveBatch(context.Background(), tt.arg)
 			if !tt.wantErr {
 				require.NoError(t, err)
+			} else {
+				var notUniqueErr *NotUniqueBatchError
+				require.ErrorAs(t, err, &notUniqueErr)
+				assert.Equal(t, tt.wantExisting, mapWithoutCreatedAt(notUniqueErr.Existing))
 			}
-			assert.ObjectsAreEqual(tt.wantStorage, repo.storage)
+			assert.Equal(t, tt.wantStorage, mapWithoutCreatedAt(repo.storage))
 		})
 	}
 }
@@ -216,7 +294,7 @@ func TestInMemoryRepository_SaveBatch(t *testing.T) {
 func TestNewInMemoryRepository(t *testing.T) {
 	t.Run("in memory repo init", func(t *testing.T) {
 		repo := NewInMemoryRepository()
-		assert.Equal(t, &InMemoryRepository{storage: map[string]models.ShortURL{}}, repo)
+		assert.Equal(t, &InMemoryRepository{storage: map[string]models.ShortURL{}, byURL: map[string]string{}, clicks: map[string]*clickStats{}}, repo)
 	})
 }
 
@@ -274,10 +352,8 @@ func TestInMemoryRepository_GetUsersUrls(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := &InMemoryRepository{
-				storage: tt.fields.storage,
-			}
-			URLs, _ := repo.GetUsersUrls(context.Background(), tt.args.id)
+			repo := newInMemoryRepositoryWith(tt.fields.storage)
+			URLs, _ := repo.GetUsersUrls(context.Background(), tt.args.id, models.UsersURLsQuery{})
 			assert.Equal(t, tt.want, URLs)
 			assert.Equal(t, len(tt.want), len(URLs))
 		})
@@ -475,10 +551,8 @@ func TestInMemoryRepository_DeleteUrls(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := &InMemoryRepository{
-				storage: tt.fields.storage,
-			}
-			err := repo.DeleteUrls(context.Background(), tt.args.urls)
+			repo := newInMemoryRepositoryWith(tt.fields.storage)
+			_, err := repo.DeleteUrls(context.Background(), tt.args.urls)
 			assert.NoError(t, err)
 
 			for _, id := range tt.wantDeleted {
@@ -490,3 +564,35 @@ func TestInMemoryRepository_DeleteUrls(t *testing.T) {
 		})
 	}
 }
+
+func newInMemoryRepositoryWith(storage map[string]models.ShortURL) *InMemoryRepository {
+	repo := NewInMemoryRepository()
+	for _, shortURL := range storage {
+		repo.store(shortURL)
+	}
+	return repo
+}
+
+// withoutCreatedAt clears creation time set by storage, so urls can be compared with fixtures.
+// Setting of creation time itself is checked by storagetest.
+func withoutCreatedAt(urls ...models.ShortURL) []models.ShortURL {
+	if urls == nil {
+		return nil
+	}
+	cleared := make([]models.ShortURL, len(urls))
+	for i, url := range urls {
+		url.CreatedAt = time.Time{}
+		cleared[i] = url
+	}
+	return cleared
+}
+
+// mapWithoutCreatedAt is withoutCreatedAt for urls stored in map.
+func mapWithoutCreatedAt[K comparable](urls map[K]models.ShortURL) map[K]models.ShortURL {
+	cleared := make(map[K]models.ShortURL, len(urls))
+	for key, url := range urls {
+		url.CreatedAt = time.Time{}
+		cleared[key] = url
+	}
+	return cleared
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/logging"
+	"github.com/belamov/ypgo-url-shortener/internal/app/metrics"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+)
+
+// InstrumentedRepository reports latency and failures of operations of wrapped repository to metrics
+// and logs them with logger of context, so storage log lines have id of request.
+// It implements Repository and Sequencer, whose methods fail if wrapped repository doesn't implement it,
+// so check Sequencer of the unwrapped repository before using it. Other optional interfaces
+// like PoolStatsProvider are implemented by wrapped repository, see Unwrap.
+type InstrumentedRepository struct {
+	repo    Repository
+	backend string
+}
+
+// NewInstrumentedRepository wraps repo of backend, see Backend.
+func NewInstrumentedRepository(repo Repository, backend string) *InstrumentedRepository {
+	return &InstrumentedRepository{repo: repo, backend: backend}
+}
+
+// Unwrap returns wrapped repository.
+func (r *InstrumentedRepository) Unwrap() Repository {
+	return r.repo
+}
+
+func (r *InstrumentedRepository) Save(ctx context.Context, shortURL models.ShortURL) error {
+	start := time.Now()
+	err := r.repo.Save(ctx, shortURL)
+	r.observe(ctx, "save", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) GetByID(ctx context.Context, id string) (models.ShortURL, error) {
+	start := time.Now()
+	shortURL, err := r.repo.GetByID(ctx, id)
+	r.observe(ctx, "get_by_id", start, err)
+	return shortURL, err
+}
+
+func (r *InstrumentedRepository) Click(ctx context.Context, id string) (models.ShortURL, error) {
+	start := time.Now()
+	shortURL, err := r.repo.Click(ctx, id)
+	r.observe(ctx, "click", start, err)
+	return shortURL, err
+}
+
+func (r *InstrumentedRepository) GetUsersUrls(ctx context.Context, userID string, query models.UsersURLsQuery) ([]models.ShortURL, error) {
+	start := time.Now()
+	urls, err := r.repo.GetUsersUrls(ctx, userID, query)
+	r.observe(ctx, "get_users_urls", start, err)
+	return urls, err
+}
+
+func (r *InstrumentedRepository) Close(ctx context.Context) error {
+	start := time.Now()
+	err := r.repo.Close(ctx)
+	r.observe(ctx, "close", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) Check(ctx context.Context) error {
+	start := time.Now()
+	err := r.repo.Check(ctx)
+	r.observe(ctx, "check", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) SaveBatch(ctx context.Context, batch []models.ShortURL) error {
+	start := time.Now()
+	err := r.repo.SaveBatch(ctx, batch)
+	r.observe(ctx, "save_batch", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) DeleteUrls(ctx context.Context, urls []models.ShortURL) ([]models.DeleteResult, error) {
+	start := time.Now()
+	results, err := r.repo.DeleteUrls(ctx, urls)
+	r.observe(ctx, "delete_urls", start, err)
+	return results, err
+}
+
+func (r *InstrumentedRepository) RestoreUrls(ctx context.Context, urls []models.ShortURL, deletedSince time.Time) ([]string, error) {
+	start := time.Now()
+	restored, err := r.repo.RestoreUrls(ctx, urls, deletedSince)
+	r.observe(ctx, "restore_urls", start, err)
+	return restored, err
+}
+
+func (r *InstrumentedRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	start := time.Now()
+	count, err := r.repo.PurgeDeleted(ctx, deletedBefore)
+	r.observe(ctx, "purge_deleted", start, err)
+	return count, err
+}
+
+func (r *InstrumentedRepository) GetUsersAndUrlsCount(ctx context.Context) (int, int, error) {
+	start := time.Now()
+	usersCount, urlsCount, err := r.repo.GetUsersAndUrlsCount(ctx)
+	r.observe(ctx, "get_users_and_urls_count", start, err)
+	return usersCount, urlsCount, err
+}
+
+func (r *InstrumentedRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	start := time.Now()
+	deleted, err := r.repo.DeleteExpired(ctx, now)
+	r.observe(ctx, "delete_expired", start, err)
+	return deleted, err
+}
+
+func (r *InstrumentedRepository) SaveClickEvents(ctx context.Context, events []models.ClickEvent) error {
+	start := time.Now()
+	err := r.repo.SaveClickEvents(ctx, events)
+	r.observe(ctx, "save_click_events", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) GetClickStats(ctx context.Context, id string) (models.ClickStats, error) {
+	start := time.Now()
+	stats, err := r.repo.GetClickStats(ctx, id)
+	r.observe(ctx, "get_click_stats", start, err)
+	return stats, err
+}
+
+func (r *InstrumentedRepository) GetStats(ctx context.Context, query models.StatsQuery) (models.Stats, error) {
+	start := time.Now()
+	stats, err := r.repo.GetStats(ctx, query)
+	r.observe(ctx, "get_stats", start, err)
+	return stats, err
+}
+
+func (r *InstrumentedRepository) NextSequence(ctx context.Context) (uint64, error) {
+	sequencer, ok := r.repo.(Sequencer)
+	if !ok {
+		return 0, ErrNoSequence
+	}
+	start := time.Now()
+	value, err := sequencer.NextSequence(ctx)
+	r.observe(ctx, "next_sequence", start, err)
+	return value, err
+}
+
+func (r *InstrumentedRepository) SeedSequence(ctx context.Context, value uint64) error {
+	sequencer, ok := r.repo.(Sequencer)
+	if !ok {
+		return ErrNoSequence
+	}
+	start := time.Now()
+	err := sequencer.SeedSequence(ctx, value)
+	r.observe(ctx, "seed_sequence", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) observe(ctx context.Context, operation string, start time.Time, err error) {
+	latency := time.Since(start)
+	failed := isFailure(err)
+	metrics.ObserveRepository(r.backend, operation, start, failed)
+
+	logger := logging.FromContext(ctx)
+	event := logger.Debug()
+	if failed {
+		event = logger.Error().Err(err)
+	}
+	event.
+		Str("backend", r.backend).
+		Str("operation", operation).
+		Dur("latency", latency).
+		Msg("repository operation")
+}
+
+// isFailure reports whether err means that storage failed.
+// Errors that describe state of urls, like missing or not unique url, are expected outcomes.
+func isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var notUniqueErr *NotUniqueURLError
+	var notUniqueBatchErr *NotUniqueBatchError
+	return !errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, ErrDeleted) &&
+		!errors.Is(err, ErrExpired) &&
+		!errors.Is(err, ErrClickLimitReached) &&
+		!errors.As(err, &notUniqueErr) &&
+		!errors.As(err, &notUniqueBatchErr)
+}
@@ -0,0 +1 @@
+create sequence if not exists url_sequence;
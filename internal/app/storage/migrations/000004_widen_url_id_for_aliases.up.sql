@@ -0,0 +1,2 @@
+alter table urls
+    alter column id type varchar(64);
@@ -0,0 +1,6 @@
+alter table urls
+    add column if not exists unique_scope varchar(36) not null default '';
+alter table urls
+    drop constraint if exists urls_original_url_key;
+create unique index if not exists urls_unique_scope_original_url_idx
+    on urls (unique_scope, original_url);
@@ -0,0 +1,4 @@
+alter table urls
+    add column if not exists expires_at timestamp;
+create index if not exists urls_expires_at_idx
+    on urls (expires_at);
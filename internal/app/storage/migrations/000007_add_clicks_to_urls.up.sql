@@ -0,0 +1,4 @@
+alter table urls
+    add column if not exists max_clicks integer not null default 0;
+alter table urls
+    add column if not exists clicks integer not null default 0;
@@ -0,0 +1,2 @@
+alter table urls
+    add column if not exists password_hash varchar(255) not null default '';
@@ -0,0 +1,9 @@
+create table if not exists click_events(
+    url_id varchar(64) not null,
+    clicked_at timestamp not null,
+    referrer text not null default '',
+    user_agent text not null default '',
+    ip_prefix varchar(64) not null default ''
+);
+create index if not exists click_events_url_id_idx
+    on click_events (url_id, clicked_at);
@@ -0,0 +1,4 @@
+alter table urls
+    add column if not exists created_at timestamp;
+create index if not exists urls_created_at_idx
+    on urls (created_at);
@@ -0,0 +1,2 @@
+create index if not exists urls_deleted_at_idx
+    on urls (deleted_at);
@@ -0,0 +1,3 @@
+create table if not exists url_sequence(
+    value integer primary key autoincrement
+);
@@ -0,0 +1 @@
+-- sqlite doesn't enforce length of varchar columns, so aliases already fit into id column
@@ -0,0 +1,14 @@
+-- sqlite can't drop constraints, so the table is recreated with the new unique constraint
+create table urls_scoped(
+    created_by varchar(36) not null,
+    original_url varchar not null,
+    id varchar(64) unique not null,
+    correlation_id varchar,
+    deleted_at timestamp,
+    unique_scope varchar(36) not null default '',
+    unique (unique_scope, original_url)
+);
+insert into urls_scoped (created_by, original_url, id, correlation_id, deleted_at)
+    select created_by, original_url, id, correlation_id, deleted_at from urls order by rowid;
+drop table urls;
+alter table urls_scoped rename to urls;
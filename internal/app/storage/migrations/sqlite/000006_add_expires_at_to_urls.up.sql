@@ -0,0 +1,5 @@
+-- sqlite doesn't support if not exists in add column, migrations are applied once by version anyway
+alter table urls
+    add column expires_at timestamp;
+create index if not exists urls_expires_at_idx
+    on urls (expires_at);
@@ -0,0 +1,5 @@
+-- sqlite doesn't support if not exists in add column, migrations are applied once by version anyway
+alter table urls
+    add column max_clicks integer not null default 0;
+alter table urls
+    add column clicks integer not null default 0;
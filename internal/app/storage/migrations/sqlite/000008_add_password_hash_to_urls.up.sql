@@ -0,0 +1,3 @@
+-- sqlite doesn't support if not exists in add column, migrations are applied once by version anyway
+alter table urls
+    add column password_hash varchar(255) not null default '';
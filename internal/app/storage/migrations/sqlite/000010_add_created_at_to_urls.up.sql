@@ -0,0 +1,5 @@
+-- sqlite doesn't support if not exists in add column, migrations are applied once by version anyway
+alter table urls
+    add column created_at timestamp;
+create index if not exists urls_created_at_idx
+    on urls (created_at);
@@ -0,0 +1,296 @@
+// Package migrator copies urls from one storage.Repository into another.
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+)
+
+// DefaultBatchSize is count of urls saved to the target repository at once.
+const DefaultBatchSize = 500
+
+// Options configures Migrator.
+type Options struct {
+	CheckpointPath string // file with id of the last migrated url, used for resuming. Empty disables resuming
+	BatchSize      int    // count of urls saved at once, DefaultBatchSize if not positive
+	DryRun         bool   // only count urls and conflicts, nothing is written
+}
+
+// Report contains results of migration.
+type Report struct {
+	Read        int    // count of urls read from the source
+	Saved       int    // count of urls saved to the target
+	Conflicts   int    // count of urls whose id or original url already exist in the target
+	ClickEvents int    // count of click events copied to the target
+	Sequence    uint64 // value the counter of the target was seeded with, zero if it wasn't
+}
+
+// Migrator streams urls from the source repository into the target repository.
+// Urls are copied as is, with ids, owners and deletion time.
+// Urls that conflict with urls of the target are skipped and counted.
+// Click events of saved urls are copied when the source stores raw events,
+// and after all urls the counter of sequential ids of the target is moved past the counter of the source,
+// so ids generated by the target don't collide with migrated ones.
+type Migrator struct {
+	source  storage.Exporter
+	target  storage.Repository
+	options Options
+}
+
+// New creates Migrator. Source must implement storage.Exporter,
+// in dry run mode target must implement it too.
+func New(source storage.Repository, target storage.Repository, options Options) (*Migrator, error) {
+	exporter, ok := source.(storage.Exporter)
+	if !ok {
+		return nil, fmt.Errorf("source repository %T can't export urls", source)
+	}
+	if _, ok = target.(storage.Exporter); options.DryRun && !ok {
+		return nil, fmt.Errorf("target repository %T can't export urls for dry run", target)
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+
+	return &Migrator{
+		source:  exporter,
+		target:  target,
+		options: options,
+	}, nil
+}
+
+// Run copies urls. When checkpoint path is set, migration continues after the last url
+// recorded in checkpoint file, and checkpoint is updated after every saved batch.
+// Saved urls whose click events aren't copied yet are recorded in checkpoint too,
+// so events of urls saved by interrupted migration are copied on resume. Events of urls
+// that were being copied at the moment of interruption may be copied twice.
+func (m *Migrator) Run(ctx context.Context) (Report, error) {
+	afterID, pending, err := m.readCheckpoint()
+	if err != nil {
+		return Report{}, err
+	}
+	if afterID != "" {
+		log.Info().Msgf("resuming migration after url %s", afterID)
+	}
+
+	if m.options.DryRun {
+		return m.dryRun(ctx, afterID)
+	}
+
+	events, copyEvents := m.source.(storage.ClickEventsExporter)
+	if !copyEvents {
+		log.Warn().Msgf("source repository %T stores only aggregated click stats, click events aren't migrated", m.source)
+	}
+
+	var report Report
+	if copyEvents && len(pending) > 0 {
+		log.Info().Msgf("copying click events of %d urls saved before interruption", len(pending))
+		if err = m.copyClickEvents(ctx, events, pending, &report); err != nil {
+			return report, err
+		}
+		if err = m.writeCheckpoint(afterID, nil); err != nil {
+			return report, err
+		}
+	}
+
+	batch := make([]models.ShortURL, 0, m.options.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		saved, errSave := m.saveBatch(ctx, batch, &report)
+		if errSave != nil {
+			return errSave
+		}
+		lastID := batch[len(batch)-1].ID
+		if copyEvents && len(saved) > 0 {
+			if errWrite := m.writeCheckpoint(lastID, saved); errWrite != nil {
+				return errWrite
+			}
+			if errCopy := m.copyClickEvents(ctx, events, saved, &report); errCopy != nil {
+				return errCopy
+			}
+		}
+		if errWrite := m.writeCheckpoint(lastID, nil); errWrite != nil {
+			return errWrite
+		}
+		log.Info().Msgf("migrated %d urls: %d saved, %d conflicts", report.Read, report.Saved, report.Conflicts)
+		batch = batch[:0]
+		return nil
+	}
+
+	err = m.source.Export(ctx, afterID, func(url models.ShortURL) error {
+		report.Read++
+		batch = append(batch, url)
+		if len(batch) < m.options.BatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return report, err
+	}
+	if err = flush(); err != nil {
+		return report, err
+	}
+
+	report.Sequence, err = m.seedSequence(ctx)
+	return report, err
+}
+
+// saveBatch saves batch to the target, counts saved and conflicting urls and returns ids of saved urls.
+func (m *Migrator) saveBatch(ctx context.Context, batch []models.ShortURL, report *Report) ([]string, error) {
+	err := m.target.SaveBatch(ctx, batch)
+
+	var notUniqueErr *storage.NotUniqueBatchError
+	if err != nil && !errors.As(err, &notUniqueErr) {
+		return nil, err
+	}
+
+	saved := make([]string, 0, len(batch))
+	for i, url := range batch {
+		if notUniqueErr != nil {
+			if existing, ok := notUniqueErr.Existing[i]; ok {
+				log.Warn().Msgf("url %s (%s) conflicts with stored url %s (%s), skipping", url.ID, url.OriginalURL, existing.ID, existing.OriginalURL)
+				report.Conflicts++
+				continue
+			}
+		}
+		saved = append(saved, url.ID)
+	}
+	report.Saved += len(saved)
+
+	return saved, nil
+}
+
+// copyClickEvents copies click events of urls with given ids to the target in batches.
+// Events of conflicting urls aren't copied, they would be counted as clicks of other urls of the target.
+func (m *Migrator) copyClickEvents(ctx context.Context, source storage.ClickEventsExporter, ids []string, report *Report) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	batch := make([]models.ClickEvent, 0, m.options.BatchSize)
+	err := source.ExportClickEvents(ctx, ids, func(event models.ClickEvent) error {
+		batch = append(batch, event)
+		if len(batch) < m.options.BatchSize {
+			return nil
+		}
+		if errSave := m.target.SaveClickEvents(ctx, batch); errSave != nil {
+			return errSave
+		}
+		report.ClickEvents += len(batch)
+		batch = batch[:0]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = m.target.SaveClickEvents(ctx, batch); err != nil {
+		return err
+	}
+	report.ClickEvents += len(batch)
+
+	return nil
+}
+
+// seedSequence moves the counter of the target past the counter of the source and returns the seeded value.
+// Reading the counter of the source takes one value of it, that's harmless since values are never reused.
+// When any of repositories has no counter, migration isn't failed, a warning is logged instead.
+func (m *Migrator) seedSequence(ctx context.Context) (uint64, error) {
+	source, ok := m.source.(storage.Sequencer)
+	if !ok {
+		log.Warn().Msgf("source repository %T has no counter of sequential ids, target counter isn't seeded", m.source)
+		return 0, nil
+	}
+	target, ok := m.target.(storage.Sequencer)
+	if !ok {
+		log.Warn().Msgf("target repository %T has no counter of sequential ids, sequential ids may collide with migrated ones", m.target)
+		return 0, nil
+	}
+
+	value, err := source.NextSequence(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err = target.SeedSequence(ctx, value); err != nil {
+		return 0, err
+	}
+	log.Info().Msgf("counter of sequential ids is seeded with %d", value)
+
+	return value, nil
+}
+
+// dryRun counts urls of the source and urls that conflict with urls of the target.
+func (m *Migrator) dryRun(ctx context.Context, afterID string) (Report, error) {
+	ids := make(map[string]bool)
+	originalURLs := make(map[string]bool)
+	err := m.target.(storage.Exporter).Export(ctx, "", func(url models.ShortURL) error {
+		ids[url.ID] = true
+		originalURLs[storage.UniqueKey(url)] = true
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	err = m.source.Export(ctx, afterID, func(url models.ShortURL) error {
+		report.Read++
+		if ids[url.ID] || originalURLs[storage.UniqueKey(url)] {
+			report.Conflicts++
+			return nil
+		}
+		ids[url.ID] = true
+		originalURLs[storage.UniqueKey(url)] = true
+		return nil
+	})
+
+	return report, err
+}
+
+// readCheckpoint returns id of the last migrated url and ids of saved urls whose click events aren't copied yet.
+// Empty id means there is no checkpoint.
+// Checkpoint file contains id of the last migrated url on the first line and pending ids on the following lines.
+func (m *Migrator) readCheckpoint() (string, []string, error) {
+	if m.options.CheckpointPath == "" {
+		return "", nil, nil
+	}
+
+	data, err := os.ReadFile(m.options.CheckpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	lines := strings.Fields(string(data))
+	if len(lines) == 0 {
+		return "", nil, nil
+	}
+
+	return lines[0], lines[1:], nil
+}
+
+// writeCheckpoint atomically replaces checkpoint file with the given id and ids of urls with pending click events.
+func (m *Migrator) writeCheckpoint(id string, pending []string) error {
+	if m.options.CheckpointPath == "" {
+		return nil
+	}
+
+	lines := append([]string{id}, pending...)
+	tmpPath := m.options.CheckpointPath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil { //nolint:gomnd
+		return err
+	}
+
+	return os.Rename(tmpPath, m.options.CheckpointPath)
+}
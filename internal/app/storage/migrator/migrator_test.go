@@ -0,0 +1,332 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testURLs() []models.ShortURL {
+	return []models.ShortURL{
+		{OriginalURL: "url1", ID: "id1", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user", CorrelationID: "cor id"},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user2", DeletedAt: time.Date(2022, time.November, 1, 12, 30, 0, 0, time.UTC)},
+		{OriginalURL: "url4", ID: "id4", CreatedByID: "user2"},
+		{OriginalURL: "url5", ID: "id5", CreatedByID: "user3"},
+	}
+}
+
+func exportAll(t *testing.T, repo storage.Repository) []models.ShortURL {
+	t.Helper()
+
+	var urls []models.ShortURL
+	err := repo.(storage.Exporter).Export(context.Background(), "", func(url models.ShortURL) error {
+		urls = append(urls, url)
+		return nil
+	})
+	require.NoError(t, err)
+	return urls
+}
+
+func TestMigrator_Run(t *testing.T) {
+	source := storage.NewInMemoryRepository()
+	require.NoError(t, source.SaveBatch(context.Background(), testURLs()))
+
+	target, err := storage.NewFileRepository(filepath.Join(t.TempDir(), "urls.json"))
+	require.NoError(t, err)
+	defer target.Close(context.Background())
+
+	m, err := New(source, target, Options{BatchSize: 2})
+	require.NoError(t, err)
+
+	report, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Report{Read: 5, Saved: 5, Sequence: 1}, report)
+
+	migrated := exportAll(t, target)
+	require.Len(t, migrated, 5)
+	for i, url := range testURLs() {
+		storagetest.AssertSameURL(t, url, migrated[i])
+	}
+}
+
+func TestMigrator_RunSkipsConflicts(t *testing.T) {
+	source := storage.NewInMemoryRepository()
+	require.NoError(t, source.SaveBatch(context.Background(), testURLs()))
+
+	target := storage.NewInMemoryRepository()
+	existing := []models.ShortURL{
+		{OriginalURL: "other url", ID: "id2", CreatedByID: "other user"},
+		{OriginalURL: "url4", ID: "other id", CreatedByID: "other user"},
+	}
+	require.NoError(t, target.SaveBatch(context.Background(), existing))
+
+	m, err := New(source, target, Options{})
+	require.NoError(t, err)
+
+	report, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Report{Read: 5, Saved: 3, Conflicts: 2, Sequence: 1}, report)
+
+	stored, err := target.GetByID(context.Background(), "id2")
+	require.NoError(t, err)
+	storagetest.AssertSameURL(t, existing[0], stored, "conflicting url must not be overwritten")
+
+	_, urlsCount, err := target.GetUsersAndUrlsCount(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, urlsCount)
+}
+
+func TestMigrator_RunResumesFromCheckpoint(t *testing.T) {
+	source := storage.NewInMemoryRepository()
+	require.NoError(t, source.SaveBatch(context.Background(), testURLs()))
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	require.NoError(t, os.WriteFile(checkpoint, []byte("id3\n"), 0o644))
+
+	target := storage.NewInMemoryRepository()
+	m, err := New(source, target, Options{CheckpointPath: checkpoint, BatchSize: 1})
+	require.NoError(t, err)
+
+	report, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Report{Read: 2, Saved: 2, Sequence: 1}, report)
+
+	migrated := exportAll(t, target)
+	require.Len(t, migrated, 2)
+	assert.Equal(t, "id4", migrated[0].ID)
+	assert.Equal(t, "id5", migrated[1].ID)
+
+	data, err := os.ReadFile(checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "id5\n", string(data))
+
+	report, err = m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Report{Sequence: 2}, report, "finished migration must not migrate anything again")
+}
+
+func TestMigrator_RunCopiesClickEvents(t *testing.T) {
+	ctx := context.Background()
+	source, err := storage.NewFileRepository(filepath.Join(t.TempDir(), "source.json"))
+	require.NoError(t, err)
+	defer source.Close(ctx)
+	require.NoError(t, source.SaveBatch(ctx, testURLs()))
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, source.SaveClickEvents(ctx, []models.ClickEvent{
+		{Time: day, URLID: "id1", UserAgent: "firefox"},
+		{Time: day.Add(time.Hour), URLID: "id1", UserAgent: "chrome"},
+		{Time: day, URLID: "id2", UserAgent: "firefox"},
+		{Time: day, URLID: "id5", UserAgent: "firefox"},
+	}))
+
+	target := storage.NewInMemoryRepository()
+	require.NoError(t, target.Save(ctx, models.ShortURL{OriginalURL: "other url", ID: "id2", CreatedByID: "other user"}))
+
+	m, err := New(source, target, Options{BatchSize: 2})
+	require.NoError(t, err)
+
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.ClickEvents)
+
+	stats, err := target.GetClickStats(ctx, "id1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+
+	stats, err = target.GetClickStats(ctx, "id2")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Total, "events of conflicting url must not be counted for url of the target")
+
+	stats, err = target.GetClickStats(ctx, "id5")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Total)
+}
+
+func TestMigrator_RunResumesCopyingOfClickEvents(t *testing.T) {
+	ctx := context.Background()
+	source, err := storage.NewFileRepository(filepath.Join(t.TempDir(), "source.json"))
+	require.NoError(t, err)
+	defer source.Close(ctx)
+	require.NoError(t, source.SaveBatch(ctx, testURLs()))
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, source.SaveClickEvents(ctx, []models.ClickEvent{
+		{Time: day, URLID: "id1", UserAgent: "firefox"},
+		{Time: day, URLID: "id2", UserAgent: "firefox"},
+		{Time: day, URLID: "id5", UserAgent: "firefox"},
+	}))
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	target := storage.NewInMemoryRepository()
+	interrupted := &failingEventsRepository{InMemoryRepository: target, err: errors.New("interrupted")}
+	m, err := New(source, interrupted, Options{CheckpointPath: checkpoint, BatchSize: 2})
+	require.NoError(t, err)
+
+	_, err = m.Run(ctx)
+	require.ErrorIs(t, err, interrupted.err)
+
+	data, err := os.ReadFile(checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "id2\nid1\nid2\n", string(data), "saved urls with pending click events must be recorded")
+
+	m, err = New(source, target, Options{CheckpointPath: checkpoint, BatchSize: 2})
+	require.NoError(t, err)
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Read)
+	assert.Equal(t, 3, report.Saved)
+	assert.Zero(t, report.Conflicts)
+	assert.Equal(t, 3, report.ClickEvents)
+
+	for _, id := range []string{"id1", "id2", "id5"} {
+		stats, errStats := target.GetClickStats(ctx, id)
+		require.NoError(t, errStats)
+		assert.Equal(t, 1, stats.Total, "click events of url %s must be copied once", id)
+	}
+
+	data, err = os.ReadFile(checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "id5\n", string(data))
+}
+
+func TestMigrator_RunSeedsSequence(t *testing.T) {
+	ctx := context.Background()
+	source := storage.NewInMemoryRepository()
+	require.NoError(t, source.SaveBatch(ctx, testURLs()))
+	require.NoError(t, source.SeedSequence(ctx, 41))
+
+	target := storage.NewInMemoryRepository()
+	m, err := New(source, target, Options{})
+	require.NoError(t, err)
+
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), report.Sequence)
+
+	next, err := target.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(43), next, "target must not generate values already used by the source")
+}
+
+func TestMigrator_RunDryRun(t *testing.T) {
+	source := storage.NewInMemoryRepository()
+	require.NoError(t, source.SaveBatch(context.Background(), testURLs()))
+
+	target := storage.NewInMemoryRepository()
+	require.NoError(t, target.Save(context.Background(), models.ShortURL{OriginalURL: "url1", ID: "other id", CreatedByID: "user"}))
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	m, err := New(source, target, Options{DryRun: true, CheckpointPath: checkpoint})
+	require.NoError(t, err)
+
+	report, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Report{Read: 5, Conflicts: 1}, report)
+
+	_, urlsCount, err := target.GetUsersAndUrlsCount(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, urlsCount, "dry run must not write anything")
+	assert.NoFileExists(t, checkpoint)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(notExporter{}, storage.NewInMemoryRepository(), Options{})
+	assert.Error(t, err)
+
+	_, err = New(storage.NewInMemoryRepository(), notExporter{}, Options{DryRun: true})
+	assert.Error(t, err)
+
+	_, err = New(storage.NewInMemoryRepository(), notExporter{}, Options{})
+	assert.NoError(t, err)
+}
+
+func TestOpenSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+
+	repo, err := Open(MemoryScheme+path, "")
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveBatch(context.Background(), testURLs()))
+	require.NoError(t, repo.Close(context.Background()))
+
+	reopened, err := Open(MemoryScheme+path, "")
+	require.NoError(t, err)
+	migrated := exportAll(t, reopened)
+	require.Len(t, migrated, 5)
+	for i, url := range testURLs() {
+		storagetest.AssertSameURL(t, url, migrated[i])
+	}
+	require.NoError(t, reopened.Close(context.Background()))
+
+	asFile, err := Open(FileScheme+path, "")
+	require.NoError(t, err)
+	defer asFile.Close(context.Background())
+	assert.Len(t, exportAll(t, asFile), 5, "snapshot must be readable by file repository")
+}
+
+func TestOpenSnapshotKeepsClickEvents(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	source, err := storage.NewFileRepository(filepath.Join(t.TempDir(), "source.json"))
+	require.NoError(t, err)
+	defer source.Close(ctx)
+	require.NoError(t, source.SaveBatch(ctx, testURLs()))
+	require.NoError(t, source.SaveClickEvents(ctx, []models.ClickEvent{
+		{Time: day, URLID: "id1", UserAgent: "firefox"},
+		{Time: day.Add(time.Hour), URLID: "id1", UserAgent: "chrome"},
+		{Time: day, URLID: "id5", UserAgent: "firefox"},
+	}))
+
+	target, err := Open(MemoryScheme+path, "")
+	require.NoError(t, err)
+	m, err := New(source, target, Options{})
+	require.NoError(t, err)
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.ClickEvents)
+	require.NoError(t, target.Close(ctx))
+
+	reopened, err := Open(MemoryScheme+path, "")
+	require.NoError(t, err)
+	stats, err := reopened.GetClickStats(ctx, "id1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total, "click events must be written to snapshot")
+	require.NoError(t, reopened.Close(ctx))
+
+	asFile, err := Open(FileScheme+path, "")
+	require.NoError(t, err)
+	defer asFile.Close(ctx)
+	stats, err = asFile.GetClickStats(ctx, "id5")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Total, "click events of snapshot must be readable by file repository")
+}
+
+func TestOpenUnknownScheme(t *testing.T) {
+	_, err := Open("unknown://dsn", "")
+	assert.Error(t, err)
+}
+
+// failingEventsRepository is in-memory repository that fails to save click events.
+type failingEventsRepository struct {
+	*storage.InMemoryRepository
+	err error
+}
+
+func (repo *failingEventsRepository) SaveClickEvents(context.Context, []models.ClickEvent) error {
+	return repo.err
+}
+
+// notExporter is repository that can't export urls.
+type notExporter struct {
+	storage.Repository
+}
@@ -3,7 +3,6 @@ package storage
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
@@ -14,29 +13,89 @@ import (
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/rs/zerolog/log"
 )
 
+// pgSelectExisting selects stored url with the same original url in the same unique scope or,
+// if there is none, with the same id.
+const pgSelectExisting = "select original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at from urls " +
+	"where id=$1 or (original_url=$2 and unique_scope=$3) order by (original_url=$2 and unique_scope=$3) desc limit 1"
+
 type PgRepository struct {
-	conn *pgx.Conn // connection to the database
-	Dsn  string    // data source name for the Postgres database. It's a string that contains the host, port, username, password, and database name
+	pool           *pgxpool.Pool // pool of connections to the database
+	Dsn            string        // data source name for the Postgres database. It's a string that contains the host, port, username, password, and database name
+	acquireTimeout time.Duration // how long to wait for a free connection of the pool
+}
+
+// PgPoolConfig contains settings of connection pool. Zero values mean pgxpool defaults.
+type PgPoolConfig struct {
+	MaxConnLifetime time.Duration // how long connection can be used before it's closed
+	AcquireTimeout  time.Duration // how long to wait for a free connection, zero means no limit
+	MaxConns        int32         // maximum size of the pool
+	MinConns        int32         // minimum count of connections kept open
 }
 
-// NewPgRepository creates a new Postgres connection, runs the migrations, and returns a new PgRepository.
-func NewPgRepository(dsn string, migrationsPath string) (*PgRepository, error) {
-	conn, err := pgx.Connect(context.Background(), dsn)
+// NewPgRepository creates a new Postgres connection pool, runs the migrations, and returns a new PgRepository.
+func NewPgRepository(dsn string, migrationsPath string, poolConfig PgPoolConfig) (*PgRepository, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if poolConfig.MaxConns > 0 {
+		cfg.MaxConns = poolConfig.MaxConns
+	}
+	if poolConfig.MinConns > 0 {
+		cfg.MinConns = poolConfig.MinConns
+	}
+	if poolConfig.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = poolConfig.MaxConnLifetime
+	}
+
+	pool, err := pgxpool.ConnectConfig(context.Background(), cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	if err = runMigrations(dsn, migrationsPath); err != nil {
+		pool.Close()
 		return nil, err
 	}
 	return &PgRepository{
-		Dsn:  dsn,
-		conn: conn,
+		Dsn:            dsn,
+		pool:           pool,
+		acquireTimeout: poolConfig.AcquireTimeout,
 	}, nil
 }
 
+// acquire acquires connection from the pool waiting for it no longer than acquire timeout.
+// Connection must be released after use.
+func (repo *PgRepository) acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	if repo.acquireTimeout <= 0 {
+		return repo.pool.Acquire(ctx)
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, repo.acquireTimeout)
+	defer cancel()
+
+	return repo.pool.Acquire(acquireCtx)
+}
+
+// PoolStats returns current statistics of the connection pool.
+func (repo *PgRepository) PoolStats() models.PoolStats {
+	stat := repo.pool.Stat()
+	return models.PoolStats{
+		AcquireCount:         stat.AcquireCount(),
+		AcquireDuration:      stat.AcquireDuration().String(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		TotalConns:           stat.TotalConns(),
+		MaxConns:             stat.MaxConns(),
+	}
+}
+
 // runMigrations runs migrations that hasn't been run.
 func runMigrations(dsn string, migrationsPath string) error {
 	m, err := migrate.New(migrationsPath, dsn)
@@ -46,75 +105,217 @@ func runMigrations(dsn string, migrationsPath string) error {
 
 	err = m.Up()
 	if errors.Is(err, migrate.ErrNoChange) {
-		fmt.Println("Nothing to migrate")
+		log.Info().Msg("nothing to migrate")
 		return nil
 	}
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Migrated successfully")
+	log.Info().Msg("migrated successfully")
 	return nil
 }
 
 // Save inserting a new row into the urls table.
 func (repo *PgRepository) Save(ctx context.Context, shortURL models.ShortURL) error {
-	_, err := repo.conn.Exec(
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(
 		ctx,
-		"insert into urls (original_url, id, created_by, correlation_id, deleted_at) values ($1, $2, $3, $4, $5)",
+		"insert into urls (original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
 		shortURL.OriginalURL,
 		shortURL.ID,
 		shortURL.CreatedByID,
 		shortURL.CorrelationID,
-		shortURL.DeletedAt,
+		shortURL.DeletedAt.UTC(),
+		shortURL.UniqueScope,
+		nullTime(shortURL.ExpiresAt),
+		shortURL.MaxClicks,
+		shortURL.Clicks,
+		shortURL.PasswordHash,
+		creationTime(shortURL),
 	)
 
 	// TODO: уникальнось!
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
 		if pgErr.Code == pgerrcode.UniqueViolation {
-			return NewNotUniqueURLError(shortURL, err)
+			existing, errScan := scanShortURL(conn.QueryRow(ctx, pgSelectExisting, shortURL.ID, shortURL.OriginalURL, shortURL.UniqueScope))
+			if errScan != nil && !errors.Is(errScan, pgx.ErrNoRows) {
+				return errScan
+			}
+			return NewNotUniqueURLError(shortURL, existing, err)
 		}
 	}
 	return err
 }
 
 // SaveBatch is a batch insert operation.
+// Urls that are already exist are skipped and reported with NotUniqueBatchError,
+// all other urls of the batch are saved.
 func (repo *PgRepository) SaveBatch(ctx context.Context, batch []models.ShortURL) error {
-	_, err := repo.conn.CopyFrom(
-		ctx,
-		pgx.Identifier{"urls"},
-		[]string{"original_url", "id", "created_by", "correlation_id", "deleted_at"},
-		pgx.CopyFromSlice(len(batch), func(i int) ([]interface{}, error) {
-			return []interface{}{batch[i].OriginalURL, batch[i].ID, batch[i].CreatedByID, batch[i].CorrelationID, batch[i].DeletedAt}, nil
-		}),
-	)
-	return err
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	inserts := &pgx.Batch{}
+	for _, shortURL := range batch {
+		inserts.Queue(
+			"insert into urls (original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) on conflict do nothing",
+			shortURL.OriginalURL,
+			shortURL.ID,
+			shortURL.CreatedByID,
+			shortURL.CorrelationID,
+			shortURL.DeletedAt.UTC(),
+			shortURL.UniqueScope,
+			nullTime(shortURL.ExpiresAt),
+			shortURL.MaxClicks,
+			shortURL.Clicks,
+			shortURL.PasswordHash,
+			creationTime(shortURL),
+		)
+	}
+
+	results := tx.SendBatch(ctx, inserts)
+	var skipped []int
+	for i := range batch {
+		tag, errExec := results.Exec()
+		if errExec != nil {
+			results.Close() //nolint:errcheck
+			return errExec
+		}
+		if tag.RowsAffected() == 0 {
+			skipped = append(skipped, i)
+		}
+	}
+	if err = results.Close(); err != nil {
+		return err
+	}
+
+	existing := make(map[int]models.ShortURL, len(skipped))
+	for _, i := range skipped {
+		stored, errScan := scanShortURL(tx.QueryRow(
+			ctx,
+			pgSelectExisting,
+			batch[i].ID,
+			batch[i].OriginalURL,
+			batch[i].UniqueScope,
+		))
+		if errScan != nil {
+			return errScan
+		}
+		existing[i] = stored
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if len(existing) > 0 {
+		return NewNotUniqueBatchError(existing)
+	}
+
+	return nil
 }
 
 // GetByID gets url by id.
 func (repo *PgRepository) GetByID(ctx context.Context, id string) (models.ShortURL, error) {
-	var model models.ShortURL
-	var deletedAt pgtype.Timestamp
-	var correlationID pgtype.Text
-	err := repo.conn.QueryRow(
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+	defer conn.Release()
+
+	return getByID(ctx, conn, id)
+}
+
+// getByID reads available url with id using conn.
+func getByID(ctx context.Context, conn *pgxpool.Conn, id string) (models.ShortURL, error) {
+	model, err := scanShortURL(conn.QueryRow(
+		ctx,
+		"select original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at from urls where id=$1",
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ShortURL{}, ErrNotFound
+	}
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+
+	return checkAvailable(model)
+}
+
+// Click gets url by id and counts the redirect if url has click limit.
+// Clicks are incremented by conditional update that checks availability of url too, so concurrent redirects
+// can't exceed the limit and redirects of url deleted or expired after it was read aren't counted.
+// Url is read and updated on the same connection, so a redirect never holds two connections of the pool.
+func (repo *PgRepository) Click(ctx context.Context, id string) (models.ShortURL, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+	defer conn.Release()
+
+	model, err := getByID(ctx, conn, id)
+	if err != nil || model.MaxClicks == 0 {
+		return model, err
+	}
+
+	err = conn.QueryRow(
 		ctx,
-		"select original_url, id, created_by, correlation_id, deleted_at from urls where id=$1",
+		"update urls set clicks = clicks + 1 where id=$1 and clicks < max_clicks "+
+			"and (deleted_at is null or deleted_at = '0001-01-01 00:00:00') and (expires_at is null or expires_at > $2) returning clicks",
 		id,
-	).Scan(&model.OriginalURL, &model.ID, &model.CreatedByID, &correlationID, &deletedAt)
+		time.Now().UTC(),
+	).Scan(&model.Clicks)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return clickNotCounted(getByID(ctx, conn, id))
+	}
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+
+	return model, nil
+}
+
+// scanShortURL scans row selected with original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash and created_at columns.
+func scanShortURL(row pgx.Row) (models.ShortURL, error) {
+	var model models.ShortURL
+	var deletedAt, expiresAt, createdAt pgtype.Timestamp
+	var correlationID pgtype.Text
+	err := row.Scan(&model.OriginalURL, &model.ID, &model.CreatedByID, &correlationID, &deletedAt, &model.UniqueScope, &expiresAt, &model.MaxClicks, &model.Clicks, &model.PasswordHash, &createdAt)
 	model.DeletedAt = deletedAt.Time
+	model.ExpiresAt = expiresAt.Time
+	model.CreatedAt = createdAt.Time
 	model.CorrelationID = correlationID.String
 	return model, err
 }
 
-// GetUsersUrls returns all the urls created by a user.
-func (repo *PgRepository) GetUsersUrls(ctx context.Context, userID string) ([]models.ShortURL, error) {
+// GetUsersUrls returns the urls created by a user that match the query.
+func (repo *PgRepository) GetUsersUrls(ctx context.Context, userID string, query models.UsersURLsQuery) ([]models.ShortURL, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
 	var URLs []models.ShortURL
 
-	rows, err := repo.conn.Query(
-		ctx,
-		"select original_url, id, created_by, correlation_id, deleted_at from urls where created_by=$1",
-		userID)
+	statement, args := pgUsersURLs.usersURLsSQL("original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at", userID, query)
+	rows, err := conn.Query(ctx, statement, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -122,14 +323,10 @@ func (repo *PgRepository) GetUsersUrls(ctx context.Context, userID string) ([]mo
 	defer rows.Close()
 
 	for rows.Next() {
-		model := models.ShortURL{}
-		var deletedAt pgtype.Timestamp
-		var correlationID pgtype.Text
-		if err = rows.Scan(&model.OriginalURL, &model.ID, &model.CreatedByID, &correlationID, &deletedAt); err != nil {
-			return nil, err
+		model, errScan := scanShortURL(rows)
+		if errScan != nil {
+			return nil, errScan
 		}
-		model.DeletedAt = deletedAt.Time
-		model.CorrelationID = correlationID.String
 		URLs = append(URLs, model)
 	}
 
@@ -140,47 +337,463 @@ func (repo *PgRepository) GetUsersUrls(ctx context.Context, userID string) ([]mo
 	return URLs, nil
 }
 
-// Close closes the connection to the database.
-func (repo *PgRepository) Close(ctx context.Context) error {
-	return repo.conn.Close(ctx)
+// Close closes all connections of the pool.
+func (repo *PgRepository) Close(_ context.Context) error {
+	repo.pool.Close()
+	return nil
 }
 
 // Check checks if the database is up and running.
 func (repo *PgRepository) Check(ctx context.Context) error {
-	return repo.conn.Ping(ctx)
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.Ping(ctx)
 }
 
-// DeleteUrls deletes all given urls.
-func (repo *PgRepository) DeleteUrls(ctx context.Context, urls []models.ShortURL) error {
+// DeleteUrls deletes all given urls and returns outcome of deletion of every url.
+// Stored urls are locked until deletion is committed, so outcomes can't be changed by concurrent restore or purge.
+func (repo *PgRepository) DeleteUrls(ctx context.Context, urls []models.ShortURL) ([]models.DeleteResult, error) {
 	if len(urls) == 0 {
-		return nil
+		return nil, nil
+	}
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	ids := make([]string, 0, len(urls))
+	for _, url := range urls {
+		ids = append(ids, url.ID)
+	}
+	rows, err := tx.Query(ctx, "select id, created_by, deleted_at from urls where id = any($1) for update", ids)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]models.ShortURL, len(urls))
+	for rows.Next() {
+		var url models.ShortURL
+		var deletedAt pgtype.Timestamp
+		if err = rows.Scan(&url.ID, &url.CreatedByID, &deletedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		url.DeletedAt = deletedAt.Time
+		stored[url.ID] = url
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// timestamp column drops time zone, times are stored in UTC to be comparable
+	deletedAt := time.Now().UTC()
+	results := make([]models.DeleteResult, 0, len(urls))
+	var urlIDs []string
+	for _, url := range urls {
+		storedURL, ok := stored[url.ID]
+		result := deleteOutcome(storedURL, ok, url.CreatedByID)
+		if result == models.DeleteResultDeleted {
+			storedURL.DeletedAt = deletedAt
+			stored[url.ID] = storedURL
+			urlIDs = append(urlIDs, url.ID)
+		}
+		results = append(results, result)
+	}
+
+	if len(urlIDs) > 0 {
+		if _, err = tx.Exec(ctx, "update urls set deleted_at = $1 where id = any($2)", deletedAt, urlIDs); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// RestoreUrls clears deletion time of given urls deleted at deletedSince or later by their creators.
+func (repo *PgRepository) RestoreUrls(ctx context.Context, urls []models.ShortURL, deletedSince time.Time) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return nil, err
 	}
-	deletedAt := time.Now()
-	urlsToDelete := make(map[string][]string)
+	defer conn.Release()
 
+	urlsToRestore := make(map[string][]string)
 	for _, url := range urls {
-		urlsToDelete[url.CreatedByID] = append(urlsToDelete[url.CreatedByID], url.ID)
+		urlsToRestore[url.CreatedByID] = append(urlsToRestore[url.CreatedByID], url.ID)
 	}
 
-	for userID, urlIDs := range urlsToDelete {
-		if _, err := repo.conn.Exec(
+	restoredIDs := make(map[string]bool)
+	for userID, urlIDs := range urlsToRestore {
+		rows, errQuery := conn.Query(
 			ctx,
-			"update urls set deleted_at = $1 where created_by = $2 and id = any($3)",
-			deletedAt,
+			"update urls set deleted_at = null where created_by = $1 and id = any($2) and deleted_at >= $3 returning id",
 			userID,
 			urlIDs,
-		); err != nil {
+			deletedSince.UTC(),
+		)
+		if errQuery != nil {
+			return nil, errQuery
+		}
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			restoredIDs[id] = true
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var restored []string
+	for _, url := range urls {
+		if restoredIDs[url.ID] {
+			restored = append(restored, url.ID)
+			delete(restoredIDs, url.ID)
+		}
+	}
+
+	return restored, nil
+}
+
+// PurgeDeleted removes urls deleted before deletedBefore along with their click events in one transaction.
+// Live urls have null deleted_at or zero time, which is stored by Save.
+func (repo *PgRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if _, err = tx.Exec(
+		ctx,
+		"delete from click_events where url_id in (select id from urls where deleted_at > '0001-01-01 00:00:00' and deleted_at < $1)",
+		deletedBefore.UTC(),
+	); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(
+		ctx,
+		"delete from urls where deleted_at > '0001-01-01 00:00:00' and deleted_at < $1",
+		deletedBefore.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// DeleteExpired marks urls that are expired at the moment now as deleted.
+// Live urls have null deleted_at or zero time, which is stored by Save.
+func (repo *PgRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(
+		ctx,
+		"update urls set deleted_at = $1 where expires_at <= $1 and (deleted_at is null or deleted_at = '0001-01-01 00:00:00')",
+		now.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// GetStats aggregates stats of urls and click events by queries to the database.
+// Periods of created urls are computed by date_trunc, which accepts names of granularities.
+func (repo *PgRepository) GetStats(ctx context.Context, query models.StatsQuery) (models.Stats, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return models.Stats{}, err
+	}
+	defer conn.Release()
+
+	stats := models.Stats{
+		Created:     []models.PeriodCount{},
+		TopCreators: []models.CreatorCount{},
+		TopURLs:     []models.URLClicks{},
+	}
+	err = conn.QueryRow(
+		ctx,
+		"select count(*), count(distinct created_by), count(*) filter (where deleted_at is null or deleted_at = '0001-01-01 00:00:00') from urls",
+	).Scan(&stats.UrlsCount, &stats.UsersCount, &stats.ActiveUrlsCount)
+	if err != nil {
+		return models.Stats{}, err
+	}
+	stats.DeletedUrlsCount = stats.UrlsCount - stats.ActiveUrlsCount
+
+	err = pgQueryRows(
+		ctx,
+		conn,
+		func(rows pgx.Rows) error {
+			var period pgtype.Timestamp
+			var count int
+			if errScan := rows.Scan(&period, &count); errScan != nil {
+				return errScan
+			}
+			stats.Created = append(stats.Created, models.PeriodCount{Start: period.Time, Count: count})
+			return nil
+		},
+		"select date_trunc($1, created_at) as period, count(*) from urls where created_at >= $2 and created_at < $3 group by period order by period",
+		string(query.Granularity),
+		query.From.UTC(),
+		query.To.UTC(),
+	)
+	if err != nil {
+		return models.Stats{}, err
+	}
+
+	err = pgQueryRows(
+		ctx,
+		conn,
+		func(rows pgx.Rows) error {
+			var creator models.CreatorCount
+			if errScan := rows.Scan(&creator.UserID, &creator.Count); errScan != nil {
+				return errScan
+			}
+			stats.TopCreators = append(stats.TopCreators, creator)
+			return nil
+		},
+		`select created_by, count(*) as count from urls group by created_by order by count desc, created_by collate "C" limit $1`,
+		query.Top,
+	)
+	if err != nil {
+		return models.Stats{}, err
+	}
+
+	err = pgQueryRows(
+		ctx,
+		conn,
+		func(rows pgx.Rows) error {
+			var url models.URLClicks
+			if errScan := rows.Scan(&url.ID, &url.Clicks); errScan != nil {
+				return errScan
+			}
+			stats.TopURLs = append(stats.TopURLs, url)
+			return nil
+		},
+		`select url_id, count(*) as clicks from click_events group by url_id order by clicks desc, url_id collate "C" limit $1`,
+		query.Top,
+	)
+	if err != nil {
+		return models.Stats{}, err
+	}
+
+	return stats, nil
+}
+
+// pgQueryRows runs query and calls scan for every selected row.
+func pgQueryRows(ctx context.Context, conn *pgxpool.Conn, scan func(rows pgx.Rows) error, sql string, args ...interface{}) error {
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
 			return err
 		}
 	}
+	return rows.Err()
+}
 
-	return nil
+// SaveClickEvents copies events to click_events table.
+func (repo *PgRepository) SaveClickEvents(ctx context.Context, events []models.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.CopyFrom(
+		ctx,
+		pgx.Identifier{"click_events"},
+		[]string{"url_id", "clicked_at", "referrer", "user_agent", "ip_prefix"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]interface{}, error) {
+			event := events[i]
+			return []interface{}{event.URLID, event.Time.UTC(), event.Referrer, event.UserAgent, event.IPPrefix}, nil
+		}),
+	)
+	return err
+}
+
+// GetClickStats aggregates click events of url with id.
+// Visitors are counted by the same key as models.ClickEvent.VisitorKey.
+func (repo *PgRepository) GetClickStats(ctx context.Context, id string) (models.ClickStats, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return models.ClickStats{}, err
+	}
+	defer conn.Release()
+
+	stats := models.ClickStats{Daily: []models.DailyClicks{}}
+	err = conn.QueryRow(
+		ctx,
+		"select count(*), count(distinct ip_prefix || ' ' || user_agent) from click_events where url_id=$1",
+		id,
+	).Scan(&stats.Total, &stats.UniqueVisitors)
+	if err != nil {
+		return models.ClickStats{}, err
+	}
+
+	rows, err := conn.Query(
+		ctx,
+		"select to_char(clicked_at, 'YYYY-MM-DD') as day, count(*) from click_events where url_id=$1 group by day order by day",
+		id,
+	)
+	if err != nil {
+		return models.ClickStats{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day models.DailyClicks
+		if err = rows.Scan(&day.Date, &day.Clicks); err != nil {
+			return models.ClickStats{}, err
+		}
+		stats.Daily = append(stats.Daily, day)
+	}
+
+	return stats, rows.Err()
+}
+
+// Export streams all the urls with id greater than afterID in byte order of ids.
+func (repo *PgRepository) Export(ctx context.Context, afterID string, fn func(models.ShortURL) error) error {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(
+		ctx,
+		`select original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at from urls where id > $1 collate "C" order by id collate "C"`,
+		afterID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		model, errScan := scanShortURL(rows)
+		if errScan != nil {
+			return errScan
+		}
+		if err = fn(model); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// NextSequence returns the next value of url_sequence.
+func (repo *PgRepository) NextSequence(ctx context.Context) (uint64, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	var value int64
+	err = conn.QueryRow(ctx, "select nextval('url_sequence')").Scan(&value)
+	return uint64(value), err
+}
+
+// SeedSequence advances url_sequence so that the next value is greater than value.
+func (repo *PgRepository) SeedSequence(ctx context.Context, value uint64) error {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "select setval('url_sequence', $1) from url_sequence where $1 >= last_value", int64(value))
+	return err
+}
+
+// ExportClickEvents streams click events of urls with given ids.
+func (repo *PgRepository) ExportClickEvents(ctx context.Context, ids []string, fn func(models.ClickEvent) error) error {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(
+		ctx,
+		"select url_id, clicked_at, referrer, user_agent, ip_prefix from click_events where url_id = any($1) order by url_id, clicked_at",
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.ClickEvent
+		if err = rows.Scan(&event.URLID, &event.Time, &event.Referrer, &event.UserAgent, &event.IPPrefix); err != nil {
+			return err
+		}
+		if err = fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
 func (repo *PgRepository) GetUsersAndUrlsCount(ctx context.Context) (int, int, error) {
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Release()
+
 	var urlsCount int
 	var usersCount int
-	err := repo.conn.QueryRow(
+	err = conn.QueryRow(
 		ctx,
 		"select count('*'), count( distinct created_by) from urls",
 	).Scan(&urlsCount, &usersCount)
@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RedisRepositoryTestSuite struct {
+	suite.Suite
+	server *miniredis.Miniredis
+	repo   *RedisRepository
+}
+
+func (s *RedisRepositoryTestSuite) SetupTest() {
+	s.server = miniredis.RunT(s.T())
+	repo, err := NewRedisRepository("redis://" + s.server.Addr())
+	require.NoError(s.T(), err)
+	s.repo = repo
+}
+
+func (s *RedisRepositoryTestSuite) TearDownTest() {
+	require.NoError(s.T(), s.repo.Close(context.Background()))
+}
+
+func (s *RedisRepositoryTestSuite) TestSave() {
+	model := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user",
+	}
+	err := s.repo.Save(context.Background(), model)
+	require.NoError(s.T(), err)
+
+	fetched, err := s.repo.GetByID(context.Background(), model.ID)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), model, withoutCreatedAt(fetched)[0])
+
+	model = models.ShortURL{
+		OriginalURL:   "url2",
+		ID:            "id2",
+		CreatedByID:   "user",
+		CorrelationID: "cor id",
+		DeletedAt:     truncate(time.Now()).UTC(),
+	}
+	err = s.repo.Save(context.Background(), model)
+	require.NoError(s.T(), err)
+
+	fetched, err = s.repo.GetByID(context.Background(), model.ID)
+	assert.ErrorIs(s.T(), err, ErrDeleted)
+	assert.Equal(s.T(), model, withoutCreatedAt(fetched)[0])
+}
+
+func (s *RedisRepositoryTestSuite) TestSaveNotUnique() {
+	model := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user",
+	}
+	err := s.repo.Save(context.Background(), model)
+	require.NoError(s.T(), err)
+
+	var notUniqueErr *NotUniqueURLError
+
+	err = s.repo.Save(context.Background(), models.ShortURL{OriginalURL: "another url", ID: "id", CreatedByID: "user2"})
+	assert.ErrorAs(s.T(), err, &notUniqueErr)
+
+	err = s.repo.Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "another id", CreatedByID: "user2"})
+	assert.ErrorAs(s.T(), err, &notUniqueErr)
+
+	usersCount, urlsCount, err := s.repo.GetUsersAndUrlsCount(context.Background())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, usersCount)
+	assert.Equal(s.T(), 1, urlsCount)
+}
+
+func (s *RedisRepositoryTestSuite) TestSaveBatch() {
+	existing := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user id",
+	}
+	err := s.repo.Save(context.Background(), existing)
+	require.NoError(s.T(), err)
+
+	sameURL := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "other id",
+		CreatedByID: "user2",
+	}
+	m2 := models.ShortURL{
+		OriginalURL:   "url2",
+		ID:            "id2",
+		CreatedByID:   "user2",
+		CorrelationID: "cor id",
+	}
+	m3 := models.ShortURL{
+		OriginalURL: "url3",
+		ID:          "id3",
+		CreatedByID: "user2",
+		DeletedAt:   truncate(time.Now()).UTC(),
+	}
+	err = s.repo.SaveBatch(context.Background(), []models.ShortURL{sameURL, m2, m3, m2})
+
+	var notUniqueErr *NotUniqueBatchError
+	require.ErrorAs(s.T(), err, &notUniqueErr)
+	assert.Equal(s.T(), map[int]models.ShortURL{0: existing, 3: m2}, mapWithoutCreatedAt(notUniqueErr.Existing))
+
+	fetched, err := s.repo.GetUsersUrls(context.Background(), "user2", models.UsersURLsQuery{})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.ShortURL{m2, m3}, withoutCreatedAt(fetched...))
+
+	_, err = s.repo.GetByID(context.Background(), sameURL.ID)
+	assert.Error(s.T(), err)
+}
+
+func (s *RedisRepositoryTestSuite) TestGetUsersUrls() {
+	m1 := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user id",
+	}
+	m2 := models.ShortURL{
+		OriginalURL: "url2",
+		ID:          "id2",
+		CreatedByID: "user id",
+		DeletedAt:   truncate(time.Now()).UTC(),
+	}
+	m3 := models.ShortURL{
+		OriginalURL: "url3",
+		ID:          "id3",
+		CreatedByID: "user3",
+	}
+	err := s.repo.SaveBatch(context.Background(), []models.ShortURL{m1, m2, m3})
+	require.NoError(s.T(), err)
+
+	fetched, err := s.repo.GetUsersUrls(context.Background(), "user id", models.UsersURLsQuery{})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.ShortURL{m1, m2}, withoutCreatedAt(fetched...))
+
+	fetched, err = s.repo.GetUsersUrls(context.Background(), "unknown user", models.UsersURLsQuery{})
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), fetched)
+}
+
+func (s *RedisRepositoryTestSuite) TestGetUsersAndUrlsCount() {
+	usersCount, urlsCount, err := s.repo.GetUsersAndUrlsCount(context.Background())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 0, usersCount)
+	assert.Equal(s.T(), 0, urlsCount)
+
+	err = s.repo.SaveBatch(context.Background(), []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user id"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user id"},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user3"},
+	})
+	require.NoError(s.T(), err)
+
+	usersCount, urlsCount, err = s.repo.GetUsersAndUrlsCount(context.Background())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, usersCount)
+	assert.Equal(s.T(), 3, urlsCount)
+}
+
+func (s *RedisRepositoryTestSuite) TestDeleteUrls() {
+	m1 := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user id",
+	}
+	m2 := models.ShortURL{
+		OriginalURL: "url2",
+		ID:          "id2",
+		CreatedByID: "user3",
+	}
+	err := s.repo.SaveBatch(context.Background(), []models.ShortURL{m1, m2})
+	require.NoError(s.T(), err)
+
+	results, err := s.repo.DeleteUrls(context.Background(), []models.ShortURL{
+		m1,
+		{ID: m2.ID, CreatedByID: "another user"},
+		{ID: "missing", CreatedByID: "user id"},
+		m1,
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.DeleteResult{
+		models.DeleteResultDeleted,
+		models.DeleteResultNotOwned,
+		models.DeleteResultNotFound,
+		models.DeleteResultAlreadyDeleted,
+	}, results)
+
+	fetched, err := s.repo.GetByID(context.Background(), m1.ID)
+	assert.ErrorIs(s.T(), err, ErrDeleted)
+	assert.False(s.T(), fetched.DeletedAt.IsZero())
+
+	fetched, err = s.repo.GetByID(context.Background(), m2.ID)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), fetched.DeletedAt.IsZero())
+
+	_, err = s.repo.GetByID(context.Background(), "missing")
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+
+	_, err = s.repo.DeleteUrls(context.Background(), []models.ShortURL{})
+	assert.NoError(s.T(), err)
+}
+
+func (s *RedisRepositoryTestSuite) TestCheck() {
+	assert.NoError(s.T(), s.repo.Check(context.Background()))
+
+	s.server.Close()
+	assert.Error(s.T(), s.repo.Check(context.Background()))
+}
+
+func TestRedisRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RedisRepositoryTestSuite))
+}
+
+func (s *RedisRepositoryTestSuite) TestClickScriptChecksAvailability() {
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(s.T(), s.repo.SaveBatch(ctx, []models.ShortURL{
+		{OriginalURL: "url", ID: "live", CreatedByID: "user", MaxClicks: 2},
+		{OriginalURL: "url2", ID: "deleted", CreatedByID: "user", MaxClicks: 2},
+		{OriginalURL: "url3", ID: "expiring", CreatedByID: "user", MaxClicks: 2, ExpiresAt: now.Add(time.Minute)},
+	}))
+	_, err := s.repo.DeleteUrls(ctx, []models.ShortURL{{ID: "deleted", CreatedByID: "user"}})
+	require.NoError(s.T(), err)
+
+	// script runs after url is read, so it must check availability by itself
+	click := func(id string, at time.Time) int {
+		result, err := clickScript.Run(ctx, s.repo.client, []string{redisURLKey(id), redisExpiringKey}, id, at.UnixMilli()).Int()
+		require.NoError(s.T(), err)
+		return result
+	}
+	assert.Equal(s.T(), 1, click("live", now))
+	assert.Equal(s.T(), redisClickNotFound, click("missing", now))
+	assert.Equal(s.T(), redisClickDeleted, click("deleted", now))
+	assert.Equal(s.T(), redisClickExpired, click("expiring", now.Add(time.Hour)))
+	assert.Equal(s.T(), 1, click("expiring", now))
+	assert.Equal(s.T(), 2, click("live", now))
+	assert.Equal(s.T(), redisClickLimitReached, click("live", now))
+}
@@ -3,27 +3,203 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
 	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 )
 
 // Repository saves and retrieves data from storage.
+// GetByID returns ErrNotFound for unknown id, the url along with ErrExpired for expired url
+// and the url along with ErrDeleted for deleted url.
+// Click gets url by id like GetByID and counts the redirect if url has click limit.
+// When the limit is used up, the redirect isn't counted and the url is returned along with ErrClickLimitReached.
+// Clicks are counted atomically, so concurrent redirects never exceed the limit.
+// DeleteExpired marks urls that are expired at the moment now as deleted and returns count of them.
+// SaveClickEvents saves redirects by short urls, GetClickStats aggregates saved redirects of url with id,
+// url without redirects has zero stats.
+// Save and SaveBatch set creation time of urls that don't have it.
+// DeleteUrls marks given urls as deleted, only urls created by the same user that aren't deleted yet are changed,
+// and returns outcome of deletion of every given url in order of given urls.
+// RestoreUrls clears deletion time of given urls that were deleted at deletedSince or later,
+// only urls created by the same user are restored, and returns ids of restored urls in order of given urls.
+// PurgeDeleted removes urls deleted before deletedBefore along with their click events and returns count of them.
+// GetUsersUrls returns urls created by user that match the query, ordered by creation time and then by id,
+// urls saved without creation time are ordered as created at zero time.
+// GetStats returns counts of urls created in query window only for periods that have them, in order of periods,
+// and top lists of query size ordered by count, order of equal counts depends on storage.
 type Repository interface {
 	Save(ctx context.Context, shortURL models.ShortURL) error
 	GetByID(ctx context.Context, id string) (models.ShortURL, error)
-	GetUsersUrls(ctx context.Context, userID string) ([]models.ShortURL, error)
+	Click(ctx context.Context, id string) (models.ShortURL, error)
+	GetUsersUrls(ctx context.Context, userID string, query models.UsersURLsQuery) ([]models.ShortURL, error)
 	Close(_ context.Context) error
 	Check(ctx context.Context) error
 	SaveBatch(ctx context.Context, batch []models.ShortURL) error
-	DeleteUrls(ctx context.Context, urls []models.ShortURL) error
+	DeleteUrls(ctx context.Context, urls []models.ShortURL) ([]models.DeleteResult, error)
+	RestoreUrls(ctx context.Context, urls []models.ShortURL, deletedSince time.Time) ([]string, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error)
 	GetUsersAndUrlsCount(ctx context.Context) (int, int, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+	SaveClickEvents(ctx context.Context, events []models.ClickEvent) error
+	GetClickStats(ctx context.Context, id string) (models.ClickStats, error)
+	GetStats(ctx context.Context, query models.StatsQuery) (models.Stats, error)
+}
+
+var (
+	// ErrNotFound is returned when there is no url with the given id.
+	ErrNotFound = errors.New("url not found")
+	// ErrDeleted is returned along with the url when the url was deleted by its creator.
+	ErrDeleted = errors.New("url is deleted")
+	// ErrExpired is returned along with the url when expiration time of the url has passed.
+	ErrExpired = errors.New("url is expired")
+	// ErrClickLimitReached is returned along with the url when all clicks of the url are used up.
+	ErrClickLimitReached = errors.New("click limit of url is reached")
+	// ErrNoSequence is returned by wrappers of repositories that don't implement Sequencer.
+	ErrNoSequence = errors.New("repository has no counter of sequential ids")
+)
+
+// checkAvailable returns ErrExpired along with url if url is expired
+// and ErrDeleted along with url if url is deleted.
+func checkAvailable(url models.ShortURL) (models.ShortURL, error) {
+	if isExpired(url, time.Now()) {
+		return url, ErrExpired
+	}
+	if !url.DeletedAt.IsZero() {
+		return url, ErrDeleted
+	}
+	return url, nil
+}
+
+// countClick returns url with the redirect counted if url has click limit
+// and ErrClickLimitReached along with url if the limit is used up.
+func countClick(url models.ShortURL) (models.ShortURL, error) {
+	if url.MaxClicks == 0 {
+		return url, nil
+	}
+	if url.Clicks >= url.MaxClicks {
+		return url, ErrClickLimitReached
+	}
+	url.Clicks++
+	return url, nil
+}
+
+// clickNotCounted returns result of redirect by url that was read again after conditional update
+// didn't count the click: error of url that became unavailable, or ErrClickLimitReached otherwise.
+func clickNotCounted(url models.ShortURL, err error) (models.ShortURL, error) {
+	if err != nil {
+		return url, err
+	}
+	url.Clicks = url.MaxClicks
+	return url, ErrClickLimitReached
+}
+
+// isExpired reports whether url has expiration time that isn't after now.
+func isExpired(url models.ShortURL, now time.Time) bool {
+	return !url.ExpiresAt.IsZero() && !url.ExpiresAt.After(now)
+}
+
+// isRestorable reports whether stored url was deleted at deletedSince or later and created by userID.
+func isRestorable(url models.ShortURL, userID string, deletedSince time.Time) bool {
+	return !url.DeletedAt.IsZero() && url.CreatedByID == userID && !url.DeletedAt.Before(deletedSince)
+}
+
+// deleteOutcome returns outcome of deletion of stored url by userID, found is false when there is no url with the id.
+// Url is deleted only when outcome is DeleteResultDeleted.
+func deleteOutcome(url models.ShortURL, found bool, userID string) models.DeleteResult {
+	switch {
+	case !found:
+		return models.DeleteResultNotFound
+	case url.CreatedByID != userID:
+		return models.DeleteResultNotOwned
+	case !url.DeletedAt.IsZero():
+		return models.DeleteResultAlreadyDeleted
+	default:
+		return models.DeleteResultDeleted
+	}
+}
+
+// isPurgeable reports whether url was deleted before deletedBefore.
+func isPurgeable(url models.ShortURL, deletedBefore time.Time) bool {
+	return !url.DeletedAt.IsZero() && url.DeletedAt.Before(deletedBefore)
+}
+
+// nullTime returns nil for zero time, so it's stored as null, and time in UTC otherwise.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// Exporter is implemented by repositories that can list all stored urls.
+type Exporter interface {
+	// Export calls fn for every stored url, including deleted ones, with id greater than afterID
+	// in ascending byte order of ids. Export stops on the first error returned by fn.
+	Export(ctx context.Context, afterID string, fn func(models.ShortURL) error) error
+}
+
+// Sequencer is implemented by repositories that keep a counter for sequential ids.
+type Sequencer interface {
+	// NextSequence returns the next value of the counter. Values start from 1 and are never returned twice.
+	NextSequence(ctx context.Context) (uint64, error)
+	// SeedSequence advances the counter so that the next value is greater than value.
+	// The counter never goes back, so seeding with a smaller value does nothing.
+	SeedSequence(ctx context.Context, value uint64) error
+}
+
+// ClickEventsExporter is implemented by repositories that store raw click events
+// rather than only aggregated click stats.
+type ClickEventsExporter interface {
+	// ExportClickEvents calls fn for every stored click event of urls with given ids.
+	// Export stops on the first error returned by fn.
+	ExportClickEvents(ctx context.Context, ids []string, fn func(models.ClickEvent) error) error
+}
+
+// exportSorted calls fn for urls with id greater than afterID in ascending order of ids.
+func exportSorted(urls []models.ShortURL, afterID string, fn func(models.ShortURL) error) error {
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].ID < urls[j].ID
+	})
+	for _, url := range urls {
+		if url.ID <= afterID {
+			continue
+		}
+		if err := fn(url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unwrap returns repository wrapped by decorators like InstrumentedRepository,
+// so optional interfaces of it can be checked. Repository that isn't wrapped is returned as is.
+func Unwrap(repo Repository) Repository {
+	for {
+		wrapper, ok := repo.(interface{ Unwrap() Repository })
+		if !ok {
+			return repo
+		}
+		repo = wrapper.Unwrap()
+	}
+}
+
+// PoolStatsProvider is implemented by repositories that use connection pool.
+type PoolStatsProvider interface {
+	PoolStats() models.PoolStats
 }
 
 // NotUniqueURLError — ошибка, возникшая при сохранении URL, который уже существует.
+// Existing is the stored url with the same original url in the same unique scope or, if there is none, with the same id.
+// See IsCollision for telling a collision of ids from a duplicate.
 type NotUniqueURLError struct {
 	Err      error
-	ShortURL models.ShortURL
+	ShortURL models.ShortURL // url that wasn't saved
+	Existing models.ShortURL // already stored url, empty if unknown
 }
 
 func (err *NotUniqueURLError) Error() string {
@@ -34,32 +210,120 @@ func (err *NotUniqueURLError) Unwrap() error {
 	return err.Err
 }
 
-func NewNotUniqueURLError(shortURL models.ShortURL, err error) error {
+// IsCollision reports whether url wasn't saved because its id is taken by another url,
+// not because the same original url is already stored.
+func (err *NotUniqueURLError) IsCollision() bool {
+	return IsCollision(err.ShortURL, err.Existing)
+}
+
+// IsCollision reports whether stored url existing, that prevented saving of shortURL,
+// is another url with the same id rather than the same original url in the same unique scope.
+// Empty existing means that stored url is unknown, it isn't a collision.
+func IsCollision(shortURL models.ShortURL, existing models.ShortURL) bool {
+	if existing.OriginalURL == "" {
+		return false
+	}
+	return existing.OriginalURL != shortURL.OriginalURL || existing.UniqueScope != shortURL.UniqueScope
+}
+
+// UniqueKey returns key of url that can't be stored twice: original url within unique scope of url.
+// Key of url with global scope is the original url itself.
+func UniqueKey(shortURL models.ShortURL) string {
+	if shortURL.UniqueScope == "" {
+		return shortURL.OriginalURL
+	}
+	return shortURL.UniqueScope + " " + shortURL.OriginalURL
+}
+
+func NewNotUniqueURLError(shortURL models.ShortURL, existing models.ShortURL, err error) error {
 	return &NotUniqueURLError{
 		Err:      err,
 		ShortURL: shortURL,
+		Existing: existing,
 	}
 }
 
 var ErrNotUnique = func() error { return &NotUniqueURLError{} }()
 
+// NotUniqueBatchError — ошибка пакетного сохранения, в пакете которого есть уже существующие URL.
+// Такие URL пропускаются, остальные URL пакета сохраняются.
+type NotUniqueBatchError struct {
+	Existing map[int]models.ShortURL // already stored urls by index of the conflicting url in the batch
+}
+
+func (err *NotUniqueBatchError) Error() string {
+	return fmt.Sprintf("%d urls of batch are already exist", len(err.Existing))
+}
+
+func NewNotUniqueBatchError(existing map[int]models.ShortURL) error {
+	return &NotUniqueBatchError{
+		Existing: existing,
+	}
+}
+
+// Storage backends, see Backend.
+const (
+	BackendSqlite   = "sqlite"
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+	BackendFile     = "file"
+	BackendMemory   = "memory"
+)
+
+// Backend returns name of storage backend selected by cfg.
+func Backend(cfg *config.Config) string {
+	switch {
+	case strings.HasPrefix(cfg.DatabaseDSN, SqliteScheme):
+		return BackendSqlite
+	case cfg.DatabaseDSN != "":
+		return BackendPostgres
+	case cfg.RedisDSN != "":
+		return BackendRedis
+	case cfg.FilePath != "":
+		return BackendFile
+	default:
+		return BackendMemory
+	}
+}
+
 // GetRepo is fabric that returns
 // repository implementation based on cfg.
 func GetRepo(cfg *config.Config) Repository {
-	if cfg.DatabaseDSN != "" {
-		repo, err := NewPgRepository(cfg.DatabaseDSN, cfg.MigrationsPath)
+	switch Backend(cfg) {
+	case BackendSqlite:
+		repo, err := NewSqliteRepository(cfg.DatabaseDSN, cfg.MigrationsPath)
 		if err != nil {
 			panic(err)
 		}
 		return repo
-	}
-	if cfg.FilePath != "" {
-		repo, err := NewFileRepository(cfg.FilePath)
+	case BackendPostgres:
+		repo, err := NewPgRepository(cfg.DatabaseDSN, cfg.MigrationsPath, PgPoolConfig{
+			MaxConns:        int32(cfg.DatabaseMaxConns),
+			MinConns:        int32(cfg.DatabaseMinConns),
+			MaxConnLifetime: time.Duration(cfg.DatabaseMaxConnLifetime) * time.Second,
+			AcquireTimeout:  cfg.DatabaseAcquireTimeoutDuration(),
+		})
+		if err != nil {
+			panic(err)
+		}
+		return repo
+	case BackendRedis:
+		repo, err := NewRedisRepository(cfg.RedisDSN)
 		if err != nil {
 			panic(err)
 		}
 		return repo
+	case BackendFile:
+		var opts []FileRepositoryOption
+		if cfg.FileSyncMode != "" {
+			opts = append(opts, WithSync(SyncMode(cfg.FileSyncMode), time.Duration(cfg.FileSyncInterval)*time.Millisecond))
+		}
+		repo, err := NewFileRepository(cfg.FilePath, opts...)
+		if err != nil {
+			panic(err)
+		}
+		return repo
+	default:
+		return NewInMemoryRepository()
 	}
-
-	return NewInMemoryRepository()
 }
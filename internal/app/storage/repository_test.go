@@ -1,10 +1,13 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/belamov/ypgo-url-shortener/internal/app/config"
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +48,32 @@ func TestGetRepo(t *testing.T) {
 		})
 	}
 }
+
+func TestBackend(t *testing.T) {
+	tests := []struct {
+		cfg  *config.Config
+		want string
+	}{
+		{cfg: &config.Config{DatabaseDSN: SqliteScheme + "urls.db", RedisDSN: "redis://localhost", FilePath: "urls.json"}, want: BackendSqlite},
+		{cfg: &config.Config{DatabaseDSN: "postgres://localhost", RedisDSN: "redis://localhost"}, want: BackendPostgres},
+		{cfg: &config.Config{RedisDSN: "redis://localhost", FilePath: "urls.json"}, want: BackendRedis},
+		{cfg: &config.Config{FilePath: "urls.json"}, want: BackendFile},
+		{cfg: &config.Config{}, want: BackendMemory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, Backend(tt.cfg))
+		})
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	assert.False(t, isFailure(nil))
+	assert.False(t, isFailure(ErrNotFound))
+	assert.False(t, isFailure(fmt.Errorf("wrapped: %w", ErrDeleted)))
+	assert.False(t, isFailure(ErrExpired))
+	assert.False(t, isFailure(ErrClickLimitReached))
+	assert.False(t, isFailure(NewNotUniqueURLError(models.ShortURL{}, models.ShortURL{}, nil)))
+	assert.False(t, isFailure(NewNotUniqueBatchError(map[int]models.ShortURL{})))
+	assert.True(t, isFailure(errors.New("connection refused")))
+}
@@ -0,0 +1,750 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	sqlite "github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SqliteScheme is the scheme of dsn that selects SqliteRepository, e.g. sqlite://data/urls.db.
+	SqliteScheme = "sqlite://"
+	// sqliteDialect is the name of subdirectory of migrations directory
+	// with migrations that replace migrations with the same name for SQLite.
+	sqliteDialect = "sqlite"
+	// sqliteDefaultParams are used when dsn doesn't contain any connection params.
+	sqliteDefaultParams = "_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+	// sqliteSelectExisting selects stored url with the same original url in the same unique scope or,
+	// if there is none, with the same id.
+	sqliteSelectExisting = "select original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at from urls " +
+		"where id = ?1 or (original_url = ?2 and unique_scope = ?3) order by (original_url = ?2 and unique_scope = ?3) desc limit 1"
+)
+
+// SqliteRepository is repository that uses embedded SQLite database for storage.
+type SqliteRepository struct {
+	db *sql.DB // database handle, safe for concurrent use
+}
+
+// NewSqliteRepository opens SQLite database with the given dsn (sqlite://path/to/file.db?params),
+// runs the migrations and returns a new SqliteRepository.
+func NewSqliteRepository(dsn string, migrationsPath string) (*SqliteRepository, error) {
+	source := strings.TrimPrefix(dsn, SqliteScheme)
+	if !strings.Contains(source, "?") {
+		source += "?" + sqliteDefaultParams
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+source)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = runSqliteMigrations(db, migrationsPath); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SqliteRepository{
+		db: db,
+	}, nil
+}
+
+// runSqliteMigrations applies migrations from migrationsPath.
+// Files from sqlite subdirectory replace files with the same name.
+func runSqliteMigrations(db *sql.DB, migrationsPath string) error {
+	dir := strings.TrimPrefix(migrationsPath, "file://")
+	source, err := iofs.New(dialectFS{base: os.DirFS(dir), dialect: sqliteDialect}, ".")
+	if err != nil {
+		return err
+	}
+
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite3", driver)
+	if err != nil {
+		return err
+	}
+
+	err = m.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Info().Msg("nothing to migrate")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msg("migrated successfully")
+	return nil
+}
+
+// dialectFS is migrations file system where files from dialect subdirectory
+// replace files with the same name from the base directory.
+type dialectFS struct {
+	base    fs.FS  // directory with migrations
+	dialect string // name of subdirectory with dialect specific migrations
+}
+
+func (f dialectFS) Open(name string) (fs.File, error) {
+	if file, err := f.base.Open(path.Join(f.dialect, name)); err == nil {
+		return file, nil
+	}
+	return f.base.Open(name)
+}
+
+func (f dialectFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.base, name)
+	if err != nil {
+		return nil, err
+	}
+
+	dialectEntries, err := fs.ReadDir(f.base, path.Join(f.dialect, name))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	byName := make(map[string]fs.DirEntry, len(entries)+len(dialectEntries))
+	for _, entry := range append(entries, dialectEntries...) {
+		if !entry.IsDir() {
+			byName[entry.Name()] = entry
+		}
+	}
+
+	merged := make([]fs.DirEntry, 0, len(byName))
+	for _, entry := range byName {
+		merged = append(merged, entry)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Name() < merged[j].Name()
+	})
+
+	return merged, nil
+}
+
+// Save inserting a new row into the urls table.
+func (repo *SqliteRepository) Save(ctx context.Context, shortURL models.ShortURL) error {
+	_, err := repo.db.ExecContext(
+		ctx,
+		"insert into urls (original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		shortURL.OriginalURL,
+		shortURL.ID,
+		shortURL.CreatedByID,
+		shortURL.CorrelationID,
+		shortURL.DeletedAt.UTC(),
+		shortURL.UniqueScope,
+		nullTime(shortURL.ExpiresAt),
+		shortURL.MaxClicks,
+		shortURL.Clicks,
+		shortURL.PasswordHash,
+		creationTime(shortURL),
+	)
+
+	var sqliteErr sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite.ErrConstraintUnique {
+		existing, errScan := scanSqliteShortURL(repo.db.QueryRowContext(ctx, sqliteSelectExisting, shortURL.ID, shortURL.OriginalURL, shortURL.UniqueScope))
+		if errScan != nil && !errors.Is(errScan, sql.ErrNoRows) {
+			return errScan
+		}
+		return NewNotUniqueURLError(shortURL, existing, err)
+	}
+	return err
+}
+
+// SaveBatch saves multiple urls in one transaction.
+// Urls that are already exist are skipped and reported with NotUniqueBatchError,
+// all other urls of the batch are saved.
+func (repo *SqliteRepository) SaveBatch(ctx context.Context, batch []models.ShortURL) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	insert, err := tx.PrepareContext(
+		ctx,
+		"insert into urls (original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) on conflict do nothing",
+	)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	existing := make(map[int]models.ShortURL)
+	for i, shortURL := range batch {
+		result, errExec := insert.ExecContext(
+			ctx,
+			shortURL.OriginalURL,
+			shortURL.ID,
+			shortURL.CreatedByID,
+			shortURL.CorrelationID,
+			shortURL.DeletedAt.UTC(),
+			shortURL.UniqueScope,
+			nullTime(shortURL.ExpiresAt),
+			shortURL.MaxClicks,
+			shortURL.Clicks,
+			shortURL.PasswordHash,
+			creationTime(shortURL),
+		)
+		if errExec != nil {
+			return errExec
+		}
+
+		inserted, errExec := result.RowsAffected()
+		if errExec != nil {
+			return errExec
+		}
+		if inserted > 0 {
+			continue
+		}
+
+		stored, errScan := scanSqliteShortURL(tx.QueryRowContext(
+			ctx,
+			sqliteSelectExisting,
+			shortURL.ID,
+			shortURL.OriginalURL,
+			shortURL.UniqueScope,
+		))
+		if errScan != nil {
+			return errScan
+		}
+		existing[i] = stored
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(existing) > 0 {
+		return NewNotUniqueBatchError(existing)
+	}
+
+	return nil
+}
+
+// GetByID gets url by id.
+func (repo *SqliteRepository) GetByID(ctx context.Context, id string) (models.ShortURL, error) {
+	model, err := scanSqliteShortURL(repo.db.QueryRowContext(
+		ctx,
+		"select original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at from urls where id = ?",
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ShortURL{}, ErrNotFound
+	}
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+
+	return checkAvailable(model)
+}
+
+// Click gets url by id and counts the redirect if url has click limit.
+// Clicks are incremented by conditional update that checks availability of url too, so concurrent redirects
+// can't exceed the limit and redirects of url deleted or expired after it was read aren't counted.
+func (repo *SqliteRepository) Click(ctx context.Context, id string) (models.ShortURL, error) {
+	model, err := repo.GetByID(ctx, id)
+	if err != nil || model.MaxClicks == 0 {
+		return model, err
+	}
+
+	err = repo.db.QueryRowContext(
+		ctx,
+		"update urls set clicks = clicks + 1 where id = ?1 and clicks < max_clicks "+
+			"and (deleted_at is null or deleted_at < '0001-01-02') and (expires_at is null or expires_at > ?2) returning clicks",
+		id,
+		time.Now().UTC(),
+	).Scan(&model.Clicks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return clickNotCounted(repo.GetByID(ctx, id))
+	}
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+
+	return model, nil
+}
+
+// GetUsersUrls returns the urls created by a user that match the query.
+func (repo *SqliteRepository) GetUsersUrls(ctx context.Context, userID string, query models.UsersURLsQuery) ([]models.ShortURL, error) {
+	statement, args := sqliteUsersURLs.usersURLsSQL("original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at", userID, query)
+	rows, err := repo.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var URLs []models.ShortURL
+	for rows.Next() {
+		URL, errScan := scanSqliteShortURL(rows)
+		if errScan != nil {
+			return nil, errScan
+		}
+		URLs = append(URLs, URL)
+	}
+
+	return URLs, rows.Err()
+}
+
+// Close closes the database.
+func (repo *SqliteRepository) Close(_ context.Context) error {
+	return repo.db.Close()
+}
+
+// Check checks if the database is available.
+func (repo *SqliteRepository) Check(ctx context.Context) error {
+	return repo.db.PingContext(ctx)
+}
+
+// DeleteUrls marks given urls as deleted and returns outcome of deletion of every url.
+// Only urls created by the same user are deleted.
+func (repo *SqliteRepository) DeleteUrls(ctx context.Context, urls []models.ShortURL) ([]models.DeleteResult, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// update goes first, so the transaction holds write lock while stored urls are read
+	update, err := tx.PrepareContext(ctx, "update urls set deleted_at = ?1 where created_by = ?2 and id = ?3 and (deleted_at is null or deleted_at < '0001-01-02')")
+	if err != nil {
+		return nil, err
+	}
+	defer update.Close()
+
+	get, err := tx.PrepareContext(ctx, "select created_by from urls where id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer get.Close()
+
+	// times are compared as strings, so they are stored in UTC
+	deletedAt := time.Now().UTC()
+	results := make([]models.DeleteResult, 0, len(urls))
+	for _, url := range urls {
+		result, errUpdate := update.ExecContext(ctx, deletedAt, url.CreatedByID, url.ID)
+		if errUpdate != nil {
+			return nil, errUpdate
+		}
+		count, errCount := result.RowsAffected()
+		if errCount != nil {
+			return nil, errCount
+		}
+		if count > 0 {
+			results = append(results, models.DeleteResultDeleted)
+			continue
+		}
+
+		var createdBy string
+		switch errGet := get.QueryRowContext(ctx, url.ID).Scan(&createdBy); {
+		case errors.Is(errGet, sql.ErrNoRows):
+			results = append(results, models.DeleteResultNotFound)
+		case errGet != nil:
+			return nil, errGet
+		case createdBy != url.CreatedByID:
+			results = append(results, models.DeleteResultNotOwned)
+		default:
+			results = append(results, models.DeleteResultAlreadyDeleted)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// RestoreUrls clears deletion time of given urls deleted at deletedSince or later by their creators.
+func (repo *SqliteRepository) RestoreUrls(ctx context.Context, urls []models.ShortURL, deletedSince time.Time) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	update, err := tx.PrepareContext(ctx, "update urls set deleted_at = null where created_by = ? and id = ? and deleted_at >= ?")
+	if err != nil {
+		return nil, err
+	}
+	defer update.Close()
+
+	var restored []string
+	for _, url := range urls {
+		result, errExec := update.ExecContext(ctx, url.CreatedByID, url.ID, deletedSince.UTC())
+		if errExec != nil {
+			return nil, errExec
+		}
+		count, errCount := result.RowsAffected()
+		if errCount != nil {
+			return nil, errCount
+		}
+		if count > 0 {
+			restored = append(restored, url.ID)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// PurgeDeleted removes urls deleted before deletedBefore along with their click events in one transaction.
+// Zero time of live urls, which is stored by Save, is excluded like in DeleteExpired.
+func (repo *SqliteRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err = tx.ExecContext(
+		ctx,
+		"delete from click_events where url_id in (select id from urls where deleted_at >= '0001-01-02' and deleted_at < ?)",
+		deletedBefore.UTC(),
+	); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, "delete from urls where deleted_at >= '0001-01-02' and deleted_at < ?", deletedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// DeleteExpired marks urls that are expired at the moment now as deleted.
+// Times are stored as text in UTC, so they are compared as strings. Live urls have null deleted_at
+// or zero time, which is stored by Save.
+func (repo *SqliteRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := repo.db.ExecContext(
+		ctx,
+		"update urls set deleted_at = ?1 where expires_at <= ?1 and (deleted_at is null or deleted_at < '0001-01-02')",
+		now.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// sqlitePeriodLayouts are layouts of prefixes of stored times that identify periods of granularities.
+var sqlitePeriodLayouts = map[models.StatsGranularity]string{ //nolint:gochecknoglobals
+	models.StatsByDay:  "2006-01-02",
+	models.StatsByHour: "2006-01-02 15",
+}
+
+// GetStats aggregates stats of urls and click events by queries to the database.
+// Times are stored as text in UTC, so periods of created urls are prefixes of the text.
+func (repo *SqliteRepository) GetStats(ctx context.Context, query models.StatsQuery) (models.Stats, error) {
+	layout, ok := sqlitePeriodLayouts[query.Granularity]
+	if !ok {
+		return models.Stats{}, fmt.Errorf("unknown stats granularity %q", query.Granularity)
+	}
+
+	stats := models.Stats{
+		Created:     []models.PeriodCount{},
+		TopCreators: []models.CreatorCount{},
+		TopURLs:     []models.URLClicks{},
+	}
+	err := repo.db.QueryRowContext(
+		ctx,
+		"select count(*), count(distinct created_by), count(case when deleted_at is null or deleted_at < '0001-01-02' then 1 end) from urls",
+	).Scan(&stats.UrlsCount, &stats.UsersCount, &stats.ActiveUrlsCount)
+	if err != nil {
+		return models.Stats{}, err
+	}
+	stats.DeletedUrlsCount = stats.UrlsCount - stats.ActiveUrlsCount
+
+	err = repo.queryRows(
+		ctx,
+		func(rows *sql.Rows) error {
+			var period string
+			var count int
+			if errScan := rows.Scan(&period, &count); errScan != nil {
+				return errScan
+			}
+			start, errParse := time.Parse(layout, period)
+			if errParse != nil {
+				return errParse
+			}
+			stats.Created = append(stats.Created, models.PeriodCount{Start: start, Count: count})
+			return nil
+		},
+		"select substr(created_at, 1, ?1) as period, count(*) from urls where created_at >= ?2 and created_at < ?3 group by period order by period",
+		len(layout),
+		query.From.UTC(),
+		query.To.UTC(),
+	)
+	if err != nil {
+		return models.Stats{}, err
+	}
+
+	err = repo.queryRows(
+		ctx,
+		func(rows *sql.Rows) error {
+			var creator models.CreatorCount
+			if errScan := rows.Scan(&creator.UserID, &creator.Count); errScan != nil {
+				return errScan
+			}
+			stats.TopCreators = append(stats.TopCreators, creator)
+			return nil
+		},
+		"select created_by, count(*) as count from urls group by created_by order by count desc, created_by limit ?",
+		query.Top,
+	)
+	if err != nil {
+		return models.Stats{}, err
+	}
+
+	err = repo.queryRows(
+		ctx,
+		func(rows *sql.Rows) error {
+			var url models.URLClicks
+			if errScan := rows.Scan(&url.ID, &url.Clicks); errScan != nil {
+				return errScan
+			}
+			stats.TopURLs = append(stats.TopURLs, url)
+			return nil
+		},
+		"select url_id, count(*) as clicks from click_events group by url_id order by clicks desc, url_id limit ?",
+		query.Top,
+	)
+	if err != nil {
+		return models.Stats{}, err
+	}
+
+	return stats, nil
+}
+
+// queryRows runs query and calls scan for every selected row.
+func (repo *SqliteRepository) queryRows(ctx context.Context, scan func(rows *sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SaveClickEvents inserts events to click_events table in one transaction.
+func (repo *SqliteRepository) SaveClickEvents(ctx context.Context, events []models.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	insert, err := tx.PrepareContext(ctx, "insert into click_events (url_id, clicked_at, referrer, user_agent, ip_prefix) values (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, event := range events {
+		if _, err = insert.ExecContext(ctx, event.URLID, event.Time.UTC(), event.Referrer, event.UserAgent, event.IPPrefix); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetClickStats aggregates click events of url with id.
+// Times are stored as text in UTC, so the day is the date part of the text.
+func (repo *SqliteRepository) GetClickStats(ctx context.Context, id string) (models.ClickStats, error) {
+	stats := models.ClickStats{Daily: []models.DailyClicks{}}
+	err := repo.db.QueryRowContext(
+		ctx,
+		"select count(*), count(distinct ip_prefix || ' ' || user_agent) from click_events where url_id = ?",
+		id,
+	).Scan(&stats.Total, &stats.UniqueVisitors)
+	if err != nil {
+		return models.ClickStats{}, err
+	}
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		"select substr(clicked_at, 1, 10) as day, count(*) from click_events where url_id = ? group by day order by day",
+		id,
+	)
+	if err != nil {
+		return models.ClickStats{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day models.DailyClicks
+		if err = rows.Scan(&day.Date, &day.Clicks); err != nil {
+			return models.ClickStats{}, err
+		}
+		stats.Daily = append(stats.Daily, day)
+	}
+
+	return stats, rows.Err()
+}
+
+// Export streams all the urls with id greater than afterID in order of ids.
+func (repo *SqliteRepository) Export(ctx context.Context, afterID string, fn func(models.ShortURL) error) error {
+	rows, err := repo.db.QueryContext(
+		ctx,
+		"select original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash, created_at from urls where id > ? order by id",
+		afterID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		URL, errScan := scanSqliteShortURL(rows)
+		if errScan != nil {
+			return errScan
+		}
+		if err = fn(URL); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// NextSequence returns the next value of url_sequence. Autoincrement never reuses values,
+// so previous values are removed.
+func (repo *SqliteRepository) NextSequence(ctx context.Context) (uint64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	result, err := tx.ExecContext(ctx, "insert into url_sequence default values")
+	if err != nil {
+		return 0, err
+	}
+	value, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, "delete from url_sequence where value < ?", value); err != nil {
+		return 0, err
+	}
+
+	return uint64(value), tx.Commit()
+}
+
+// SeedSequence advances url_sequence so that the next value is greater than value.
+// Autoincrement continues from the largest inserted value, so it's enough to insert value itself.
+func (repo *SqliteRepository) SeedSequence(ctx context.Context, value uint64) error {
+	_, err := repo.db.ExecContext(
+		ctx,
+		"insert into url_sequence (value) select ? where ? > coalesce((select max(value) from url_sequence), 0)",
+		int64(value),
+		int64(value),
+	)
+	return err
+}
+
+// ExportClickEvents streams click events of urls with given ids.
+func (repo *SqliteRepository) ExportClickEvents(ctx context.Context, ids []string, fn func(models.ClickEvent) error) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := repo.db.QueryContext(
+		ctx,
+		"select url_id, clicked_at, referrer, user_agent, ip_prefix from click_events where url_id in (?"+strings.Repeat(", ?", len(ids)-1)+") order by url_id, clicked_at",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.ClickEvent
+		if err = rows.Scan(&event.URLID, &event.Time, &event.Referrer, &event.UserAgent, &event.IPPrefix); err != nil {
+			return err
+		}
+		if err = fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (repo *SqliteRepository) GetUsersAndUrlsCount(ctx context.Context) (int, int, error) {
+	var urlsCount int
+	var usersCount int
+	err := repo.db.QueryRowContext(
+		ctx,
+		"select count(*), count(distinct created_by) from urls",
+	).Scan(&urlsCount, &usersCount)
+	return usersCount, urlsCount, err
+}
+
+// scanSqliteShortURL scans row selected with original_url, id, created_by, correlation_id, deleted_at, unique_scope, expires_at, max_clicks, clicks, password_hash and created_at columns.
+func scanSqliteShortURL(row interface{ Scan(dest ...any) error }) (models.ShortURL, error) {
+	var model models.ShortURL
+	var deletedAt, expiresAt, createdAt sql.NullTime
+	var correlationID sql.NullString
+	err := row.Scan(&model.OriginalURL, &model.ID, &model.CreatedByID, &correlationID, &deletedAt, &model.UniqueScope, &expiresAt, &model.MaxClicks, &model.Clicks, &model.PasswordHash, &createdAt)
+	if err != nil {
+		return models.ShortURL{}, err
+	}
+	model.DeletedAt = deletedAt.Time
+	model.ExpiresAt = expiresAt.Time
+	model.CreatedAt = createdAt.Time
+	model.CorrelationID = correlationID.String
+	return model, nil
+}
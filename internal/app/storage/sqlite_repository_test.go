@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type SqliteRepositoryTestSuite struct {
+	suite.Suite
+	repo *SqliteRepository
+}
+
+func (s *SqliteRepositoryTestSuite) SetupTest() {
+	dsn := SqliteScheme + filepath.Join(s.T().TempDir(), "urls.db")
+	repo, err := NewSqliteRepository(dsn, "file://migrations/")
+	require.NoError(s.T(), err)
+	s.repo = repo
+}
+
+func (s *SqliteRepositoryTestSuite) TearDownTest() {
+	require.NoError(s.T(), s.repo.Close(context.Background()))
+}
+
+func (s *SqliteRepositoryTestSuite) TestSave() {
+	model := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user",
+	}
+	err := s.repo.Save(context.Background(), model)
+	require.NoError(s.T(), err)
+
+	fetched, err := s.repo.GetByID(context.Background(), model.ID)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), model, withoutCreatedAt(fetched)[0])
+
+	model = models.ShortURL{
+		OriginalURL:   "url2",
+		ID:            "id2",
+		CreatedByID:   "user",
+		CorrelationID: "cor id",
+		DeletedAt:     truncate(time.Now()).UTC(),
+	}
+	err = s.repo.Save(context.Background(), model)
+	require.NoError(s.T(), err)
+
+	fetched, err = s.repo.GetByID(context.Background(), model.ID)
+	assert.ErrorIs(s.T(), err, ErrDeleted)
+	assert.Equal(s.T(), model, withoutCreatedAt(fetched)[0])
+
+	var notUniqueErr *NotUniqueURLError
+	err = s.repo.Save(context.Background(), models.ShortURL{OriginalURL: "url", ID: "another id", CreatedByID: "user"})
+	assert.ErrorAs(s.T(), err, &notUniqueErr)
+	err = s.repo.Save(context.Background(), models.ShortURL{OriginalURL: "another url", ID: "id", CreatedByID: "user"})
+	assert.ErrorAs(s.T(), err, &notUniqueErr)
+}
+
+func (s *SqliteRepositoryTestSuite) TestSaveBatchWithExistingUrls() {
+	existing := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user id",
+	}
+	err := s.repo.Save(context.Background(), existing)
+	require.NoError(s.T(), err)
+
+	sameURL := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "other id",
+		CreatedByID: "user2",
+	}
+	newURL := models.ShortURL{
+		OriginalURL:   "url2",
+		ID:            "id2",
+		CreatedByID:   "user2",
+		CorrelationID: "cor id",
+	}
+	err = s.repo.SaveBatch(context.Background(), []models.ShortURL{sameURL, newURL, newURL})
+
+	var notUniqueErr *NotUniqueBatchError
+	require.ErrorAs(s.T(), err, &notUniqueErr)
+	assert.Equal(s.T(), map[int]models.ShortURL{0: existing, 2: newURL}, mapWithoutCreatedAt(notUniqueErr.Existing))
+
+	fetched, err := s.repo.GetUsersUrls(context.Background(), "user2", models.UsersURLsQuery{})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.ShortURL{newURL}, withoutCreatedAt(fetched...))
+}
+
+func (s *SqliteRepositoryTestSuite) TestGetUsersUrls() {
+	m1 := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user id",
+		DeletedAt:   truncate(time.Now()).UTC(),
+	}
+	m2 := models.ShortURL{
+		OriginalURL: "url2",
+		ID:          "id2",
+		CreatedByID: "user id",
+	}
+	m3 := models.ShortURL{
+		OriginalURL: "url3",
+		ID:          "id3",
+		CreatedByID: "user3",
+	}
+	err := s.repo.SaveBatch(context.Background(), []models.ShortURL{m1, m2, m3})
+	require.NoError(s.T(), err)
+
+	fetched, err := s.repo.GetUsersUrls(context.Background(), "user id", models.UsersURLsQuery{})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.ShortURL{m1, m2}, withoutCreatedAt(fetched...))
+
+	usersCount, urlsCount, err := s.repo.GetUsersAndUrlsCount(context.Background())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, usersCount)
+	assert.Equal(s.T(), 3, urlsCount)
+}
+
+func (s *SqliteRepositoryTestSuite) TestDeleteUrls() {
+	m1 := models.ShortURL{
+		OriginalURL: "url",
+		ID:          "id",
+		CreatedByID: "user id",
+	}
+	m2 := models.ShortURL{
+		OriginalURL: "url2",
+		ID:          "id2",
+		CreatedByID: "user3",
+	}
+	err := s.repo.SaveBatch(context.Background(), []models.ShortURL{m1, m2})
+	require.NoError(s.T(), err)
+
+	_, err = s.repo.DeleteUrls(context.Background(), []models.ShortURL{m1, {ID: m2.ID, CreatedByID: "another user"}})
+	assert.NoError(s.T(), err)
+
+	fetched, err := s.repo.GetByID(context.Background(), m1.ID)
+	assert.ErrorIs(s.T(), err, ErrDeleted)
+	assert.False(s.T(), fetched.DeletedAt.IsZero())
+
+	fetched, err = s.repo.GetByID(context.Background(), m2.ID)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), fetched.DeletedAt.IsZero())
+
+	_, err = s.repo.GetByID(context.Background(), "not existing")
+	assert.ErrorIs(s.T(), err, ErrNotFound)
+}
+
+func (s *SqliteRepositoryTestSuite) TestConcurrentSaves() {
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := string(rune('a' + i))
+			assert.NoError(s.T(), s.repo.Save(context.Background(), models.ShortURL{OriginalURL: "url" + id, ID: id, CreatedByID: "user"}))
+		}(i)
+	}
+	wg.Wait()
+
+	_, urlsCount, err := s.repo.GetUsersAndUrlsCount(context.Background())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 20, urlsCount)
+}
+
+func TestSqliteRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(SqliteRepositoryTestSuite))
+}
+
+func TestDialectFS(t *testing.T) {
+	fsys := dialectFS{
+		base: fstest.MapFS{
+			"1_create.up.sql":         {Data: []byte("generic create")},
+			"2_alter.up.sql":          {Data: []byte("generic alter")},
+			"sqlite/2_alter.up.sql":   {Data: []byte("sqlite alter")},
+			"sqlite/3_only.up.sql":    {Data: []byte("sqlite only")},
+			"postgres/3_pg.up.sql":    {Data: []byte("postgres only")},
+			"postgres/2_alter.up.sql": {Data: []byte("postgres alter")},
+		},
+		dialect: "sqlite",
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"1_create.up.sql", "2_alter.up.sql", "3_only.up.sql"}, names)
+
+	content, err := fs.ReadFile(fsys, "1_create.up.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "generic create", string(content))
+
+	content, err = fs.ReadFile(fsys, "2_alter.up.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite alter", string(content))
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+)
+
+// creationTime returns moment of creation of url being saved.
+// Urls copied from another storage keep their creation time, new urls are created now.
+func creationTime(url models.ShortURL) time.Time {
+	if url.CreatedAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return url.CreatedAt.UTC()
+}
+
+// collectStats aggregates stats of urls and their click stats for repositories that keep them in memory.
+func collectStats(urls map[string]models.ShortURL, clicks map[string]*clickStats, query models.StatsQuery) models.Stats {
+	stats := models.Stats{UrlsCount: len(urls)}
+
+	users := make(map[string]int)
+	created := make(map[time.Time]int)
+	for _, url := range urls {
+		users[url.CreatedByID]++
+		if url.DeletedAt.IsZero() {
+			stats.ActiveUrlsCount++
+		} else {
+			stats.DeletedUrlsCount++
+		}
+		if !url.CreatedAt.Before(query.From) && url.CreatedAt.Before(query.To) {
+			created[query.Granularity.PeriodStart(url.CreatedAt)]++
+		}
+	}
+	stats.UsersCount = len(users)
+
+	stats.Created = make([]models.PeriodCount, 0, len(created))
+	for start, count := range created {
+		stats.Created = append(stats.Created, models.PeriodCount{Start: start, Count: count})
+	}
+	sort.Slice(stats.Created, func(i, j int) bool {
+		return stats.Created[i].Start.Before(stats.Created[j].Start)
+	})
+
+	stats.TopCreators = make([]models.CreatorCount, 0, len(users))
+	for userID, count := range users {
+		stats.TopCreators = append(stats.TopCreators, models.CreatorCount{UserID: userID, Count: count})
+	}
+	sort.Slice(stats.TopCreators, func(i, j int) bool {
+		if stats.TopCreators[i].Count != stats.TopCreators[j].Count {
+			return stats.TopCreators[i].Count > stats.TopCreators[j].Count
+		}
+		return stats.TopCreators[i].UserID < stats.TopCreators[j].UserID
+	})
+	stats.TopCreators = stats.TopCreators[:minInt(query.Top, len(stats.TopCreators))]
+
+	stats.TopURLs = make([]models.URLClicks, 0, len(clicks))
+	for id, urlClicks := range clicks {
+		stats.TopURLs = append(stats.TopURLs, models.URLClicks{ID: id, Clicks: urlClicks.total})
+	}
+	sort.Slice(stats.TopURLs, func(i, j int) bool {
+		if stats.TopURLs[i].Clicks != stats.TopURLs[j].Clicks {
+			return stats.TopURLs[i].Clicks > stats.TopURLs[j].Clicks
+		}
+		return stats.TopURLs[i].ID < stats.TopURLs[j].ID
+	})
+	stats.TopURLs = stats.TopURLs[:minInt(query.Top, len(stats.TopURLs))]
+
+	return stats
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,958 @@
+// Package storagetest contains conformance tests for storage.Repository implementations.
+//
+// Every implementation runs the same tests, so all backends behave identically:
+//
+//	func TestMyRepository(t *testing.T) {
+//		storagetest.RunRepositoryTests(t, func(t *testing.T) storage.Repository {
+//			return newEmptyRepository(t)
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+	"github.com/belamov/ypgo-url-shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentWriters is count of goroutines used in concurrency tests.
+const concurrentWriters = 20
+
+// NewRepository returns new empty repository. It's called for every test,
+// repository must be closed and cleaned up with t.Cleanup.
+type NewRepository func(t *testing.T) storage.Repository
+
+// RunRepositoryTests runs all conformance tests against repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository NewRepository) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo storage.Repository)
+	}{
+		{name: "save and get by id", test: testSaveAndGetByID},
+		{name: "get missing id", test: testGetMissingID},
+		{name: "save duplicates", test: testSaveDuplicates},
+		{name: "save batch", test: testSaveBatch},
+		{name: "save batch with duplicates", test: testSaveBatchWithDuplicates},
+		{name: "unique scope", test: testUniqueScope},
+		{name: "expiration", test: testExpiration},
+		{name: "click limit", test: testClickLimit},
+		{name: "concurrent clicks", test: testConcurrentClicks},
+		{name: "click events", test: testClickEvents},
+		{name: "get users urls", test: testGetUsersUrls},
+		{name: "get users urls page", test: testGetUsersUrlsPage},
+		{name: "delete urls", test: testDeleteUrls},
+		{name: "restore urls", test: testRestoreUrls},
+		{name: "purge deleted", test: testPurgeDeleted},
+		{name: "get users and urls count", test: testGetUsersAndUrlsCount},
+		{name: "stats", test: testStats},
+		{name: "concurrent saves", test: testConcurrentSaves},
+		{name: "concurrent saves of the same url", test: testConcurrentSavesOfSameURL},
+		{name: "check", test: testCheck},
+		{name: "export", test: testExport},
+		{name: "next sequence", test: testNextSequence},
+		{name: "seed sequence", test: testSeedSequence},
+		{name: "export click events", test: testExportClickEvents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepository(t))
+		})
+	}
+}
+
+func testSaveAndGetByID(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	urls := []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user", CorrelationID: "cor id"},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user", DeletedAt: time.Now().Truncate(time.Millisecond)},
+		{OriginalURL: "url4", ID: strings.Repeat("alias-", 10) + "long", CreatedByID: "user"}, // custom aliases are up to 64 characters long
+		{OriginalURL: "url5", ID: "id5", CreatedByID: "user", PasswordHash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"},
+	}
+
+	for _, url := range urls {
+		require.NoError(t, repo.Save(ctx, url))
+	}
+
+	for _, url := range urls {
+		fetched, err := repo.GetByID(ctx, url.ID)
+		if url.DeletedAt.IsZero() {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, storage.ErrDeleted, "deleted url must be returned along with ErrDeleted")
+		}
+		AssertSameURL(t, url, fetched)
+	}
+}
+
+func testGetMissingID(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}))
+
+	fetched, err := repo.GetByID(ctx, "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Equal(t, models.ShortURL{}, fetched)
+}
+
+func testSaveDuplicates(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	url := models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}
+	require.NoError(t, repo.Save(ctx, url))
+
+	var notUniqueErr *storage.NotUniqueURLError
+
+	err := repo.Save(ctx, models.ShortURL{OriginalURL: "another url", ID: url.ID, CreatedByID: "user2"})
+	require.ErrorAs(t, err, &notUniqueErr, "url with the same id must not be saved")
+	AssertSameURL(t, url, notUniqueErr.Existing)
+	assert.True(t, notUniqueErr.IsCollision(), "url with the same id and another original url is a collision")
+
+	err = repo.Save(ctx, models.ShortURL{OriginalURL: url.OriginalURL, ID: "another id", CreatedByID: "user2"})
+	require.ErrorAs(t, err, &notUniqueErr, "url with the same original url must not be saved")
+	AssertSameURL(t, url, notUniqueErr.Existing)
+	assert.False(t, notUniqueErr.IsCollision(), "url with the same original url is a duplicate")
+
+	another := models.ShortURL{OriginalURL: "another url", ID: "another id", CreatedByID: "user2"}
+	require.NoError(t, repo.Save(ctx, another))
+	err = repo.Save(ctx, models.ShortURL{OriginalURL: another.OriginalURL, ID: url.ID, CreatedByID: "user2"})
+	require.ErrorAs(t, err, &notUniqueErr)
+	AssertSameURL(t, another, notUniqueErr.Existing, "url with the same original url must be preferred to url with the same id")
+
+	fetched, err := repo.GetByID(ctx, url.ID)
+	require.NoError(t, err)
+	AssertSameURL(t, url, fetched)
+}
+
+func testUniqueScope(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	global := models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}
+	scoped := models.ShortURL{OriginalURL: "url", ID: "scoped id", CreatedByID: "user2", UniqueScope: "user2"}
+	require.NoError(t, repo.Save(ctx, global))
+	require.NoError(t, repo.Save(ctx, scoped), "the same url must be saved in another scope")
+
+	var notUniqueErr *storage.NotUniqueURLError
+	err := repo.Save(ctx, models.ShortURL{OriginalURL: "url", ID: "another id", CreatedByID: "user2", UniqueScope: "user2"})
+	require.ErrorAs(t, err, &notUniqueErr, "url must be unique within scope")
+	AssertSameURL(t, scoped, notUniqueErr.Existing)
+	assert.False(t, notUniqueErr.IsCollision())
+
+	err = repo.Save(ctx, models.ShortURL{OriginalURL: "url", ID: scoped.ID, CreatedByID: "user3", UniqueScope: "user3"})
+	require.ErrorAs(t, err, &notUniqueErr)
+	AssertSameURL(t, scoped, notUniqueErr.Existing)
+	assert.True(t, notUniqueErr.IsCollision(), "the same url of another scope with the same id is a collision")
+
+	batch := []models.ShortURL{
+		{OriginalURL: "url", ID: "id3", CreatedByID: "user3", UniqueScope: "user3"},
+		{OriginalURL: "url", ID: "id4", CreatedByID: "user4", UniqueScope: "user4"},
+		{OriginalURL: "url", ID: "id5", CreatedByID: "user4", UniqueScope: "user4"},
+	}
+	var notUniqueBatchErr *storage.NotUniqueBatchError
+	err = repo.SaveBatch(ctx, batch)
+	require.ErrorAs(t, err, &notUniqueBatchErr)
+	require.Len(t, notUniqueBatchErr.Existing, 1)
+	AssertSameURL(t, batch[1], notUniqueBatchErr.Existing[2])
+
+	for _, url := range []models.ShortURL{global, scoped, batch[0], batch[1]} {
+		fetched, errGet := repo.GetByID(ctx, url.ID)
+		require.NoError(t, errGet)
+		AssertSameURL(t, url, fetched)
+	}
+
+	usersUrls, err := repo.GetUsersUrls(ctx, "user2", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	require.Len(t, usersUrls, 1)
+	AssertSameURL(t, scoped, usersUrls[0])
+}
+
+func testExpiration(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	urls := []models.ShortURL{
+		{OriginalURL: "url", ID: "expired", CreatedByID: "user", ExpiresAt: now.Add(-time.Hour)},
+		{OriginalURL: "url2", ID: "expiring", CreatedByID: "user", ExpiresAt: now.Add(time.Hour)},
+		{OriginalURL: "url3", ID: "eternal", CreatedByID: "user"},
+		{OriginalURL: "url4", ID: "deleted", CreatedByID: "user", ExpiresAt: now.Add(-time.Hour), DeletedAt: now.Add(-2 * time.Hour)},
+	}
+	require.NoError(t, repo.SaveBatch(ctx, urls[:2]))
+	require.NoError(t, repo.Save(ctx, urls[2]))
+	require.NoError(t, repo.Save(ctx, urls[3]))
+
+	fetched, err := repo.GetByID(ctx, "expired")
+	require.ErrorIs(t, err, storage.ErrExpired, "expired url must be returned along with ErrExpired")
+	AssertSameURL(t, urls[0], fetched)
+
+	fetched, err = repo.GetByID(ctx, "expiring")
+	require.NoError(t, err)
+	AssertSameURL(t, urls[1], fetched)
+
+	count, err := repo.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "only expired urls that aren't deleted yet must be deleted")
+
+	fetched, err = repo.GetByID(ctx, "expired")
+	require.ErrorIs(t, err, storage.ErrExpired)
+	assert.True(t, now.Equal(fetched.DeletedAt), "expired url must be deleted at the moment of sweeping, got %v", fetched.DeletedAt)
+
+	fetched, err = repo.GetByID(ctx, "deleted")
+	require.Error(t, err)
+	AssertSameURL(t, urls[3], fetched, "deletion time of already deleted url must not change")
+
+	count, err = repo.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = repo.DeleteExpired(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	fetched, err = repo.GetByID(ctx, "eternal")
+	require.NoError(t, err)
+	AssertSameURL(t, urls[2], fetched)
+}
+
+func testClickLimit(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	limited := models.ShortURL{OriginalURL: "url", ID: "limited", CreatedByID: "user", MaxClicks: 2}
+	unlimited := models.ShortURL{OriginalURL: "url2", ID: "unlimited", CreatedByID: "user"}
+	require.NoError(t, repo.Save(ctx, limited))
+	require.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{unlimited}))
+
+	for clicks := 1; clicks <= limited.MaxClicks; clicks++ {
+		clicked, err := repo.Click(ctx, "limited")
+		require.NoError(t, err)
+		assert.Equal(t, clicks, clicked.Clicks)
+	}
+
+	clicked, err := repo.Click(ctx, "limited")
+	require.ErrorIs(t, err, storage.ErrClickLimitReached, "url must be returned along with ErrClickLimitReached when clicks are used up")
+	assert.Equal(t, "url", clicked.OriginalURL)
+	assert.Equal(t, 2, clicked.Clicks)
+
+	fetched, err := repo.GetByID(ctx, "limited")
+	require.NoError(t, err, "GetByID doesn't check click limit")
+	assert.Equal(t, 2, fetched.Clicks)
+
+	urls, err := repo.GetUsersUrls(ctx, "user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	AssertContainsURL(t, urls, models.ShortURL{OriginalURL: "url", ID: "limited", CreatedByID: "user", MaxClicks: 2, Clicks: 2})
+
+	for i := 0; i < 3; i++ {
+		clicked, err = repo.Click(ctx, "unlimited")
+		require.NoError(t, err)
+		AssertSameURL(t, unlimited, clicked, "clicks of url without limit aren't counted")
+	}
+
+	_, err = repo.Click(ctx, "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	deleteUrls(t, repo, unlimited)
+	_, err = repo.Click(ctx, "unlimited")
+	assert.ErrorIs(t, err, storage.ErrDeleted)
+}
+
+func testClickEvents(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []models.ClickEvent{
+		{Time: day, URLID: "id", Referrer: "https://example.com", UserAgent: "firefox", IPPrefix: "203.0.113.0/24"},
+		// days are counted in UTC, it's still the first day
+		{Time: time.Date(2024, 3, 2, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60)), URLID: "id", UserAgent: "firefox", IPPrefix: "203.0.113.0/24"},
+		{Time: day.Add(24 * time.Hour), URLID: "id", UserAgent: "chrome", IPPrefix: "203.0.113.0/24"},
+		{Time: day.Add(24 * time.Hour), URLID: "another", UserAgent: "chrome", IPPrefix: "198.51.100.0/24"},
+	}
+	require.NoError(t, repo.SaveClickEvents(ctx, events[:2]))
+	require.NoError(t, repo.SaveClickEvents(ctx, events[2:]))
+	require.NoError(t, repo.SaveClickEvents(ctx, nil))
+
+	stats, err := repo.GetClickStats(ctx, "id")
+	require.NoError(t, err)
+	assert.Equal(t, models.ClickStats{
+		Daily:          []models.DailyClicks{{Date: "2024-03-01", Clicks: 2}, {Date: "2024-03-02", Clicks: 1}},
+		Total:          3,
+		UniqueVisitors: 2,
+	}, stats)
+
+	stats, err = repo.GetClickStats(ctx, "missing")
+	require.NoError(t, err)
+	assert.Equal(t, models.ClickStats{Daily: []models.DailyClicks{}}, stats, "url without clicks has zero stats")
+}
+
+func testConcurrentClicks(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	maxClicks := concurrentWriters / 2
+	require.NoError(t, repo.Save(ctx, models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user", MaxClicks: maxClicks}))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrentWriters)
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Click(ctx, "id")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	clicked := 0
+	for err := range errs {
+		if err == nil {
+			clicked++
+			continue
+		}
+		assert.ErrorIs(t, err, storage.ErrClickLimitReached)
+	}
+	assert.Equal(t, maxClicks, clicked, "url must be clicked exactly max clicks times")
+
+	fetched, err := repo.GetByID(ctx, "id")
+	require.NoError(t, err)
+	assert.Equal(t, maxClicks, fetched.Clicks)
+}
+
+func testSaveBatch(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	batch := []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user", CorrelationID: "cor id"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user", CorrelationID: "cor id2"},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user2"},
+	}
+	require.NoError(t, repo.SaveBatch(ctx, batch))
+
+	for _, url := range batch {
+		fetched, err := repo.GetByID(ctx, url.ID)
+		require.NoError(t, err)
+		AssertSameURL(t, url, fetched)
+	}
+
+	require.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{}))
+}
+
+func testSaveBatchWithDuplicates(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	existing := models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}
+	require.NoError(t, repo.Save(ctx, existing))
+
+	newURL := models.ShortURL{OriginalURL: "url2", ID: "id2", CreatedByID: "user2"}
+	err := repo.SaveBatch(ctx, []models.ShortURL{
+		{OriginalURL: "url", ID: "another id", CreatedByID: "user2"},
+		newURL,
+		{OriginalURL: "another url", ID: "id", CreatedByID: "user2"},
+		newURL,
+	})
+
+	var notUniqueErr *storage.NotUniqueBatchError
+	require.ErrorAs(t, err, &notUniqueErr)
+	require.Len(t, notUniqueErr.Existing, 3)
+	AssertSameURL(t, existing, notUniqueErr.Existing[0])
+	AssertSameURL(t, existing, notUniqueErr.Existing[2])
+	AssertSameURL(t, newURL, notUniqueErr.Existing[3])
+
+	fetched, err := repo.GetByID(ctx, newURL.ID)
+	require.NoError(t, err)
+	AssertSameURL(t, newURL, fetched)
+
+	_, err = repo.GetByID(ctx, "another id")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	fetched, err = repo.GetByID(ctx, existing.ID)
+	require.NoError(t, err)
+	AssertSameURL(t, existing, fetched)
+}
+
+func testGetUsersUrls(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	usersURLs := []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user", DeletedAt: time.Now().Truncate(time.Millisecond)},
+	}
+	require.NoError(t, repo.SaveBatch(ctx, usersURLs))
+	require.NoError(t, repo.Save(ctx, models.ShortURL{OriginalURL: "url3", ID: "id3", CreatedByID: "user2"}))
+
+	fetched, err := repo.GetUsersUrls(ctx, "user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	require.Len(t, fetched, len(usersURLs))
+	for _, url := range usersURLs {
+		AssertContainsURL(t, fetched, url)
+	}
+
+	fetched, err = repo.GetUsersUrls(ctx, "unknown user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, fetched)
+}
+
+func testGetUsersUrlsPage(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{
+		{OriginalURL: "https://example.com/d", ID: "d", CreatedByID: "user", CreatedAt: start.Add(2 * time.Hour)},
+		{OriginalURL: "https://example.com/c", ID: "c", CreatedByID: "user", CreatedAt: start.Add(time.Hour)},
+		{OriginalURL: "https://example.org/b", ID: "b", CreatedByID: "user", CreatedAt: start.Add(time.Hour), DeletedAt: start.Add(3 * time.Hour)},
+		{OriginalURL: "https://example.com/a", ID: "a", CreatedByID: "user", CreatedAt: start},
+		{OriginalURL: "https://example.com/e", ID: "e", CreatedByID: "user2", CreatedAt: start},
+	}))
+
+	after := func(id string) *models.URLCursor {
+		url, err := repo.GetByID(ctx, id)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrDeleted)
+		}
+		cursor := models.CursorOf(url)
+		return &cursor
+	}
+	tests := []struct {
+		name  string
+		query models.UsersURLsQuery
+		want  []string
+	}{
+		{name: "by creation time and id", query: models.UsersURLsQuery{}, want: []string{"a", "b", "c", "d"}},
+		{name: "newest first", query: models.UsersURLsQuery{Order: models.SortNewestFirst}, want: []string{"d", "c", "b", "a"}},
+		{name: "active", query: models.UsersURLsQuery{Status: models.URLsActive}, want: []string{"a", "c", "d"}},
+		{name: "deleted", query: models.UsersURLsQuery{Status: models.URLsDeleted}, want: []string{"b"}},
+		{
+			name:  "creation window",
+			query: models.UsersURLsQuery{CreatedFrom: start.Add(time.Hour), CreatedTo: start.Add(2 * time.Hour)},
+			want:  []string{"b", "c"},
+		},
+		{name: "search", query: models.UsersURLsQuery{Search: "example.com"}, want: []string{"a", "c", "d"}},
+		{name: "first page", query: models.UsersURLsQuery{Limit: 2}, want: []string{"a", "b"}},
+		{name: "next page", query: models.UsersURLsQuery{After: after("b"), Limit: 2}, want: []string{"c", "d"}},
+		{name: "after the last page", query: models.UsersURLsQuery{After: after("d"), Limit: 2}},
+		{
+			name:  "next page of newest first",
+			query: models.UsersURLsQuery{After: after("c"), Order: models.SortNewestFirst, Limit: 2},
+			want:  []string{"b", "a"},
+		},
+		{
+			name:  "next page of filtered urls",
+			query: models.UsersURLsQuery{After: after("a"), Status: models.URLsActive, Search: "example.com", Limit: 1},
+			want:  []string{"c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetched, err := repo.GetUsersUrls(ctx, "user", tt.query)
+			require.NoError(t, err)
+			ids := make([]string, 0, len(fetched))
+			for _, url := range fetched {
+				ids = append(ids, url.ID)
+			}
+			assert.Equal(t, append([]string{}, tt.want...), ids)
+		})
+	}
+}
+
+func testDeleteUrls(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	own := models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}
+	own2 := models.ShortURL{OriginalURL: "url2", ID: "id2", CreatedByID: "user"}
+	foreign := models.ShortURL{OriginalURL: "url3", ID: "id3", CreatedByID: "user2"}
+	require.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{own, own2, foreign}))
+
+	results, err := repo.DeleteUrls(ctx, []models.ShortURL{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = repo.DeleteUrls(ctx, []models.ShortURL{
+		{ID: own.ID, CreatedByID: "user"},
+		{ID: foreign.ID, CreatedByID: "user"},
+		{ID: "missing", CreatedByID: "user"},
+		{ID: own.ID, CreatedByID: "user"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []models.DeleteResult{
+		models.DeleteResultDeleted,
+		models.DeleteResultNotOwned,
+		models.DeleteResultNotFound,
+		models.DeleteResultAlreadyDeleted,
+	}, results, "outcomes must be reported in order of urls")
+
+	fetched, err := repo.GetByID(ctx, own.ID)
+	require.ErrorIs(t, err, storage.ErrDeleted, "deleted url must be returned along with ErrDeleted")
+	assert.False(t, fetched.DeletedAt.IsZero(), "url must be marked as deleted")
+	assert.Equal(t, own.OriginalURL, fetched.OriginalURL)
+	deletedAt := fetched.DeletedAt
+
+	fetched, err = repo.GetByID(ctx, own2.ID)
+	require.NoError(t, err)
+	assert.True(t, fetched.DeletedAt.IsZero(), "url that wasn't requested must not be deleted")
+
+	fetched, err = repo.GetByID(ctx, foreign.ID)
+	require.NoError(t, err)
+	assert.True(t, fetched.DeletedAt.IsZero(), "url of another user must not be deleted")
+
+	usersURLs, err := repo.GetUsersUrls(ctx, "user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	assert.Len(t, usersURLs, 2, "deleted urls must be still listed")
+
+	var notUniqueErr *storage.NotUniqueURLError
+	err = repo.Save(ctx, models.ShortURL{OriginalURL: own.OriginalURL, ID: own.ID, CreatedByID: "user"})
+	assert.ErrorAs(t, err, &notUniqueErr, "deleted url still occupies its id and original url")
+
+	time.Sleep(5 * time.Millisecond)
+	results, err = repo.DeleteUrls(ctx, []models.ShortURL{{ID: own.ID, CreatedByID: "user"}})
+	require.NoError(t, err)
+	assert.Equal(t, []models.DeleteResult{models.DeleteResultAlreadyDeleted}, results)
+	fetched, err = repo.GetByID(ctx, own.ID)
+	require.ErrorIs(t, err, storage.ErrDeleted)
+	assert.True(t, deletedAt.Equal(fetched.DeletedAt), "deletion time of already deleted url must be kept")
+
+	stats, err := repo.GetStats(ctx, models.StatsQuery{Granularity: models.StatsByDay})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.DeletedUrlsCount, "already deleted url must be counted once")
+}
+
+// deleteUrls deletes urls and fails test if storage fails.
+func deleteUrls(t *testing.T, repo storage.Repository, urls ...models.ShortURL) {
+	t.Helper()
+	_, err := repo.DeleteUrls(context.Background(), urls)
+	require.NoError(t, err)
+}
+
+func testRestoreUrls(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	own := models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}
+	own2 := models.ShortURL{OriginalURL: "url2", ID: "id2", CreatedByID: "user"}
+	foreign := models.ShortURL{OriginalURL: "url3", ID: "id3", CreatedByID: "user2"}
+	live := models.ShortURL{OriginalURL: "url4", ID: "id4", CreatedByID: "user"}
+	require.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{own, own2, foreign, live}))
+
+	beforeDeletion := time.Now().Add(-time.Second)
+	deleteUrls(t, repo, own, own2, foreign)
+
+	restored, err := repo.RestoreUrls(ctx, nil, beforeDeletion)
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+
+	restored, err = repo.RestoreUrls(ctx, []models.ShortURL{
+		{ID: live.ID, CreatedByID: "user"},
+		{ID: own.ID, CreatedByID: "user"},
+		{ID: foreign.ID, CreatedByID: "user"},
+		{ID: "missing", CreatedByID: "user"},
+	}, beforeDeletion)
+	require.NoError(t, err)
+	assert.Equal(t, []string{own.ID}, restored, "only deleted urls of the user are restored")
+
+	fetched, err := repo.GetByID(ctx, own.ID)
+	require.NoError(t, err)
+	assert.True(t, fetched.DeletedAt.IsZero(), "restored url must not be marked as deleted")
+
+	_, err = repo.GetByID(ctx, foreign.ID)
+	assert.ErrorIs(t, err, storage.ErrDeleted, "url of another user must not be restored")
+
+	restored, err = repo.RestoreUrls(ctx, []models.ShortURL{{ID: own2.ID, CreatedByID: "user"}}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, restored, "url deleted before grace period must not be restored")
+	_, err = repo.GetByID(ctx, own2.ID)
+	assert.ErrorIs(t, err, storage.ErrDeleted)
+
+	stats, err := repo.GetStats(ctx, models.StatsQuery{Granularity: models.StatsByDay})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.ActiveUrlsCount)
+	assert.Equal(t, 2, stats.DeletedUrlsCount)
+
+	deleteUrls(t, repo, own)
+	_, err = repo.GetByID(ctx, own.ID)
+	assert.ErrorIs(t, err, storage.ErrDeleted, "restored url can be deleted again")
+}
+
+func testPurgeDeleted(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	old := models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: "user"}
+	recent := models.ShortURL{OriginalURL: "url2", ID: "id2", CreatedByID: "user"}
+	live := models.ShortURL{OriginalURL: "url3", ID: "id3", CreatedByID: "user"}
+	imported := models.ShortURL{OriginalURL: "url4", ID: "id4", CreatedByID: "user2", DeletedAt: time.Now().Add(-time.Hour)}
+	require.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{old, recent, live, imported}))
+	require.NoError(t, repo.SaveClickEvents(ctx, []models.ClickEvent{
+		{Time: time.Now(), URLID: old.ID, UserAgent: "firefox", IPPrefix: "203.0.113.0/24"},
+		{Time: time.Now(), URLID: live.ID, UserAgent: "firefox", IPPrefix: "203.0.113.0/24"},
+	}))
+
+	deleteUrls(t, repo, old)
+	time.Sleep(5 * time.Millisecond)
+	retention := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	deleteUrls(t, repo, recent)
+
+	count, err := repo.PurgeDeleted(ctx, retention)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = repo.GetByID(ctx, old.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "purged url must be removed")
+	_, err = repo.GetByID(ctx, imported.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "url saved as deleted must be purged too")
+	_, err = repo.GetByID(ctx, recent.ID)
+	assert.ErrorIs(t, err, storage.ErrDeleted, "url deleted after retention must be kept")
+	_, err = repo.GetByID(ctx, live.ID)
+	assert.NoError(t, err)
+
+	clickStats, err := repo.GetClickStats(ctx, old.ID)
+	require.NoError(t, err)
+	assert.Zero(t, clickStats.Total, "click events of purged url must be removed")
+	clickStats, err = repo.GetClickStats(ctx, live.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, clickStats.Total)
+
+	usersURLs, err := repo.GetUsersUrls(ctx, "user", models.UsersURLsQuery{})
+	require.NoError(t, err)
+	require.Len(t, usersURLs, 2)
+	AssertContainsURL(t, usersURLs, live)
+	assert.Equal(t, recent.ID, usersURLs[0].ID, "urls must be listed in order of creation")
+
+	usersCount, urlsCount, err := repo.GetUsersAndUrlsCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, usersCount, "user without urls isn't counted")
+	assert.Equal(t, 2, urlsCount)
+
+	stats, err := repo.GetStats(ctx, models.StatsQuery{Granularity: models.StatsByDay, Top: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.UrlsCount)
+	assert.Equal(t, 1, stats.DeletedUrlsCount)
+	assert.Equal(t, []models.CreatorCount{{UserID: "user", Count: 2}}, stats.TopCreators)
+
+	require.NoError(t, repo.Save(ctx, old), "id and original url of purged url are free")
+
+	count, err = repo.PurgeDeleted(ctx, retention)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func testGetUsersAndUrlsCount(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	usersCount, urlsCount, err := repo.GetUsersAndUrlsCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, usersCount)
+	assert.Equal(t, 0, urlsCount)
+
+	require.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user"},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user"},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user2"},
+	}))
+	deleteUrls(t, repo, models.ShortURL{ID: "id3", CreatedByID: "user2"})
+
+	usersCount, urlsCount, err = repo.GetUsersAndUrlsCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, usersCount)
+	assert.Equal(t, 3, urlsCount, "deleted urls are counted")
+}
+
+func testStats(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	urls := []models.ShortURL{
+		{OriginalURL: "url", ID: "id", CreatedByID: "user", CreatedAt: day.Add(time.Hour)},
+		{OriginalURL: "url2", ID: "id2", CreatedByID: "user", CreatedAt: day.Add(90 * time.Minute)},
+		{OriginalURL: "url3", ID: "id3", CreatedByID: "user", CreatedAt: day.Add(25 * time.Hour)},
+		// periods are counted in UTC, it's still the first day
+		{OriginalURL: "url4", ID: "id4", CreatedByID: "user2", CreatedAt: time.Date(2024, 3, 1, 5, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))},
+		{OriginalURL: "url5", ID: "id5", CreatedByID: "user2", CreatedAt: day.Add(-time.Hour)},
+		{OriginalURL: "url6", ID: "id6", CreatedByID: "user3", CreatedAt: day.Add(72 * time.Hour)},
+	}
+	require.NoError(t, repo.SaveBatch(ctx, urls))
+	deleteUrls(t, repo, models.ShortURL{ID: "id2", CreatedByID: "user"})
+
+	events := make([]models.ClickEvent, 0, 6)
+	for id, clicks := range map[string]int{"id": 3, "id4": 2, "id6": 1} {
+		for i := 0; i < clicks; i++ {
+			events = append(events, models.ClickEvent{Time: day, URLID: id, UserAgent: "firefox", IPPrefix: "203.0.113.0/24"})
+		}
+	}
+	require.NoError(t, repo.SaveClickEvents(ctx, events))
+
+	stats, err := repo.GetStats(ctx, models.StatsQuery{From: day, To: day.Add(48 * time.Hour), Granularity: models.StatsByDay, Top: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 6, stats.UrlsCount)
+	assert.Equal(t, 3, stats.UsersCount)
+	assert.Equal(t, 5, stats.ActiveUrlsCount)
+	assert.Equal(t, 1, stats.DeletedUrlsCount)
+	assertSamePeriods(t, []models.PeriodCount{{Start: day, Count: 3}, {Start: day.Add(24 * time.Hour), Count: 1}}, stats.Created)
+	assert.Equal(t, []models.CreatorCount{{UserID: "user", Count: 3}, {UserID: "user2", Count: 2}}, stats.TopCreators)
+	assert.Equal(t, []models.URLClicks{{ID: "id", Clicks: 3}, {ID: "id4", Clicks: 2}}, stats.TopURLs)
+
+	stats, err = repo.GetStats(ctx, models.StatsQuery{From: day, To: day.Add(3 * time.Hour), Granularity: models.StatsByHour, Top: 1})
+	require.NoError(t, err)
+	assertSamePeriods(t, []models.PeriodCount{{Start: day.Add(time.Hour), Count: 2}, {Start: day.Add(2 * time.Hour), Count: 1}}, stats.Created)
+	assert.Equal(t, []models.CreatorCount{{UserID: "user", Count: 3}}, stats.TopCreators)
+	assert.Equal(t, []models.URLClicks{{ID: "id", Clicks: 3}}, stats.TopURLs)
+
+	stats, err = repo.GetStats(ctx, models.StatsQuery{From: day.Add(96 * time.Hour), To: day.Add(120 * time.Hour), Granularity: models.StatsByDay, Top: 2})
+	require.NoError(t, err)
+	assert.Empty(t, stats.Created, "periods without created urls are omitted")
+}
+
+// assertSamePeriods asserts that period counts are equal, starts of periods are compared as moments in time.
+func assertSamePeriods(t *testing.T, expected []models.PeriodCount, actual []models.PeriodCount) {
+	t.Helper()
+
+	if !assert.Len(t, actual, len(expected)) {
+		return
+	}
+	for i := range expected {
+		assert.True(t, expected[i].Start.Equal(actual[i].Start), "start: expected %v, actual %v", expected[i].Start, actual[i].Start)
+		assert.Equal(t, expected[i].Count, actual[i].Count, "count of period %v", expected[i].Start)
+	}
+}
+
+func testConcurrentSaves(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := models.ShortURL{
+				OriginalURL: fmt.Sprintf("url%d", i),
+				ID:          fmt.Sprintf("id%d", i),
+				CreatedByID: fmt.Sprintf("user%d", i%2),
+			}
+			if i%3 == 0 {
+				assert.NoError(t, repo.SaveBatch(ctx, []models.ShortURL{url}))
+			} else {
+				assert.NoError(t, repo.Save(ctx, url))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	usersCount, urlsCount, err := repo.GetUsersAndUrlsCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, usersCount)
+	assert.Equal(t, concurrentWriters, urlsCount)
+
+	for i := 0; i < concurrentWriters; i++ {
+		fetched, errGet := repo.GetByID(ctx, fmt.Sprintf("id%d", i))
+		require.NoError(t, errGet)
+		assert.Equal(t, fmt.Sprintf("url%d", i), fetched.OriginalURL)
+	}
+}
+
+func testConcurrentSavesOfSameURL(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrentWriters)
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- repo.Save(ctx, models.ShortURL{OriginalURL: "url", ID: "id", CreatedByID: fmt.Sprintf("user%d", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	saved := 0
+	for err := range errs {
+		if err == nil {
+			saved++
+			continue
+		}
+		var notUniqueErr *storage.NotUniqueURLError
+		assert.ErrorAs(t, err, &notUniqueErr)
+	}
+	assert.Equal(t, 1, saved, "url must be saved exactly once")
+
+	_, urlsCount, err := repo.GetUsersAndUrlsCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, urlsCount)
+}
+
+func testCheck(t *testing.T, repo storage.Repository) {
+	assert.NoError(t, repo.Check(context.Background()))
+}
+
+func testExport(t *testing.T, repo storage.Repository) {
+	exporter, ok := repo.(storage.Exporter)
+	if !ok {
+		t.Skip("repository doesn't implement storage.Exporter")
+	}
+
+	ctx := context.Background()
+	urls := []models.ShortURL{
+		{OriginalURL: "url2", ID: "b", CreatedByID: "user"},
+		{OriginalURL: "url1", ID: "a", CreatedByID: "user", CorrelationID: "cor id"},
+		{OriginalURL: "url3", ID: "c", CreatedByID: "user2", DeletedAt: time.Now().Truncate(time.Millisecond)},
+	}
+	require.NoError(t, repo.SaveBatch(ctx, urls))
+
+	var exported []models.ShortURL
+	collect := func(url models.ShortURL) error {
+		exported = append(exported, url)
+		return nil
+	}
+
+	require.NoError(t, exporter.Export(ctx, "", collect))
+	require.Len(t, exported, 3)
+	AssertSameURL(t, urls[1], exported[0])
+	AssertSameURL(t, urls[0], exported[1])
+	AssertSameURL(t, urls[2], exported[2])
+
+	exported = nil
+	require.NoError(t, exporter.Export(ctx, "a", collect))
+	require.Len(t, exported, 2, "urls with id less or equal to afterID must be skipped")
+	assert.Equal(t, "b", exported[0].ID)
+	assert.Equal(t, "c", exported[1].ID)
+
+	stop := errors.New("stop")
+	calls := 0
+	err := exporter.Export(ctx, "", func(models.ShortURL) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls, "export must stop on the first error")
+}
+
+func testNextSequence(t *testing.T, repo storage.Repository) {
+	sequencer, ok := repo.(storage.Sequencer)
+	if !ok {
+		t.Skip("repository doesn't implement storage.Sequencer")
+	}
+
+	ctx := context.Background()
+	first, err := sequencer.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, first)
+
+	second, err := sequencer.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, second, first)
+
+	var mutex sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, errNext := sequencer.NextSequence(ctx)
+			assert.NoError(t, errNext)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			assert.False(t, seen[value], "value %d is returned twice", value)
+			seen[value] = true
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, concurrentWriters)
+}
+
+// AssertSameURL asserts that urls are equal. DeletedAt, ExpiresAt and CreatedAt are compared as moments in time,
+// so backends are free to store it in any location. Expected url without CreatedAt matches any creation time
+// set by storage.
+func AssertSameURL(t *testing.T, expected models.ShortURL, actual models.ShortURL, msgAndArgs ...interface{}) {
+	t.Helper()
+
+	if expected.CreatedAt.IsZero() {
+		assert.False(t, actual.CreatedAt.IsZero(), "created_at must be set by storage")
+	} else {
+		assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created_at: expected %v, actual %v", expected.CreatedAt, actual.CreatedAt)
+	}
+	expected.CreatedAt = time.Time{}
+	actual.CreatedAt = time.Time{}
+	assert.True(t, expected.DeletedAt.Equal(actual.DeletedAt), "deleted_at: expected %v, actual %v", expected.DeletedAt, actual.DeletedAt)
+	assert.True(t, expected.ExpiresAt.Equal(actual.ExpiresAt), "expires_at: expected %v, actual %v", expected.ExpiresAt, actual.ExpiresAt)
+	expected.DeletedAt = time.Time{}
+	actual.DeletedAt = time.Time{}
+	expected.ExpiresAt = time.Time{}
+	actual.ExpiresAt = time.Time{}
+	assert.Equal(t, expected, actual, msgAndArgs...)
+}
+
+// AssertContainsURL asserts that urls contain url, compared as in AssertSameURL.
+func AssertContainsURL(t *testing.T, urls []models.ShortURL, url models.ShortURL) {
+	t.Helper()
+
+	for _, candidate := range urls {
+		if candidate.ID == url.ID {
+			AssertSameURL(t, url, candidate)
+			return
+		}
+	}
+	assert.Failf(t, "url not found", "url with id %q not found in %v", url.ID, urls)
+}
+
+func testSeedSequence(t *testing.T, repo storage.Repository) {
+	sequencer, ok := repo.(storage.Sequencer)
+	if !ok {
+		t.Skip("repository doesn't implement storage.Sequencer")
+	}
+
+	ctx := context.Background()
+	require.NoError(t, sequencer.SeedSequence(ctx, 100))
+	value, err := sequencer.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(101), value)
+
+	require.NoError(t, sequencer.SeedSequence(ctx, 50))
+	value, err = sequencer.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(102), value, "seeding with smaller value must not move the counter back")
+
+	require.NoError(t, sequencer.SeedSequence(ctx, 102))
+	value, err = sequencer.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(103), value)
+}
+
+func testExportClickEvents(t *testing.T, repo storage.Repository) {
+	exporter, ok := repo.(storage.ClickEventsExporter)
+	if !ok {
+		t.Skip("repository doesn't implement storage.ClickEventsExporter")
+	}
+
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []models.ClickEvent{
+		{Time: day, URLID: "a", Referrer: "https://example.com", UserAgent: "firefox", IPPrefix: "203.0.113.0/24"},
+		{Time: day.Add(time.Hour), URLID: "b", UserAgent: "chrome"},
+		{Time: day.Add(2 * time.Hour), URLID: "a", IPPrefix: "198.51.100.0/24"},
+		{Time: day, URLID: "c"},
+	}
+	require.NoError(t, repo.SaveClickEvents(ctx, events))
+
+	var exported []models.ClickEvent
+	collect := func(event models.ClickEvent) error {
+		exported = append(exported, event)
+		return nil
+	}
+
+	require.NoError(t, exporter.ExportClickEvents(ctx, []string{"a", "b", "missing"}, collect))
+	sort.Slice(exported, func(i, j int) bool { return exported[i].Time.Before(exported[j].Time) })
+	require.Len(t, exported, 3)
+	for i, expected := range events[:3] {
+		assert.Equal(t, expected.URLID, exported[i].URLID)
+		assert.True(t, expected.Time.Equal(exported[i].Time), "expected time %s, got %s", expected.Time, exported[i].Time)
+		assert.Equal(t, expected.Referrer, exported[i].Referrer)
+		assert.Equal(t, expected.UserAgent, exported[i].UserAgent)
+		assert.Equal(t, expected.IPPrefix, exported[i].IPPrefix)
+	}
+
+	exported = nil
+	require.NoError(t, exporter.ExportClickEvents(ctx, nil, collect))
+	assert.Empty(t, exported)
+
+	stop := errors.New("stop")
+	calls := 0
+	err := exporter.ExportClickEvents(ctx, []string{"a"}, func(models.ClickEvent) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls, "export must stop on the first error")
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/belamov/ypgo-url-shortener/internal/app/models"
+)
+
+// usersURLsDialect describes how sql repository selects urls of user.
+type usersURLsDialect struct {
+	placeholder string // prefix of numbered parameters
+	createdAt   string // creation time of url, urls without it are created at zero time
+	id          string // id of url compared by bytes
+	active      string // condition of urls that aren't deleted
+	deleted     string // condition of deleted urls
+	contains    string // format of condition of substring of original url, %s is parameter
+}
+
+var (
+	pgUsersURLs = usersURLsDialect{
+		placeholder: "$",
+		createdAt:   "coalesce(created_at, '0001-01-01 00:00:00')",
+		id:          `id collate "C"`,
+		active:      "(deleted_at is null or deleted_at = '0001-01-01 00:00:00')",
+		deleted:     "deleted_at > '0001-01-01 00:00:00'",
+		contains:    "strpos(original_url, %s) > 0",
+	}
+	// times are stored as text in UTC, so they are compared as strings
+	sqliteUsersURLs = usersURLsDialect{
+		placeholder: "?",
+		createdAt:   "coalesce(created_at, '0001-01-01 00:00:00+00:00')",
+		id:          "id",
+		active:      "(deleted_at is null or deleted_at < '0001-01-02')",
+		deleted:     "deleted_at >= '0001-01-02'",
+		contains:    "instr(original_url, %s) > 0",
+	}
+)
+
+// usersURLsSQL builds select of urls of user that match the query with its arguments.
+func (dialect usersURLsDialect) usersURLsSQL(columns string, userID string, query models.UsersURLsQuery) (string, []interface{}) {
+	args := []interface{}{userID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return dialect.placeholder + strconv.Itoa(len(args))
+	}
+
+	conditions := []string{"created_by = " + dialect.placeholder + "1"}
+	switch query.Status {
+	case models.URLsActive:
+		conditions = append(conditions, dialect.active)
+	case models.URLsDeleted:
+		conditions = append(conditions, dialect.deleted)
+	}
+	if !query.CreatedFrom.IsZero() {
+		conditions = append(conditions, dialect.createdAt+" >= "+arg(query.CreatedFrom.UTC()))
+	}
+	if !query.CreatedTo.IsZero() {
+		conditions = append(conditions, dialect.createdAt+" < "+arg(query.CreatedTo.UTC()))
+	}
+	if query.Search != "" {
+		conditions = append(conditions, fmt.Sprintf(dialect.contains, arg(query.Search)))
+	}
+
+	direction, after := "asc", ">"
+	if query.Order == models.SortNewestFirst {
+		direction, after = "desc", "<"
+	}
+	if query.After != nil {
+		conditions = append(conditions, "("+dialect.createdAt+", "+dialect.id+") "+after+" ("+arg(query.After.CreatedAt.UTC())+", "+arg(query.After.ID)+")")
+	}
+
+	statement := "select " + columns + " from urls where " + strings.Join(conditions, " and ") +
+		" order by " + dialect.createdAt + " " + direction + ", " + dialect.id + " " + direction
+	if query.Limit > 0 {
+		statement += " limit " + arg(query.Limit)
+	}
+
+	return statement, args
+}
+
+// selectUsersURLs selects page of urls of user for repositories that filter urls in memory.
+func selectUsersURLs(urls []models.ShortURL, query models.UsersURLsQuery) []models.ShortURL {
+	var selected []models.ShortURL
+	for _, url := range urls {
+		if !query.Matches(url) {
+			continue
+		}
+		if query.After != nil && !query.After.Precedes(models.CursorOf(url), query.Order) {
+			continue
+		}
+		selected = append(selected, url)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return models.CursorOf(selected[i]).Precedes(models.CursorOf(selected[j]), query.Order)
+	})
+	if query.Limit > 0 && len(selected) > query.Limit {
+		selected = selected[:query.Limit]
+	}
+
+	return selected
+}